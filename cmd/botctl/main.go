@@ -0,0 +1,213 @@
+// botctl 聊天机器人运维命令行工具
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"gochat/internal/middleware"
+	"gochat/internal/service/chatbot"
+	"gochat/internal/service/feedbackjob"
+	"gochat/internal/service/queue"
+	"gochat/internal/service/sentiment"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"train-intent", "使用标注语料训练意图分类模型", trainIntent},
+	{"graph", "导出对话状态图（Graphviz DOT 或 Mermaid）", exportGraph},
+	{"backfill-sentiment", "补齐尚未分析情感倾向的历史反馈", backfillSentiment},
+	{"admin-token", "使用 auth.admin_key 生成后台管理令牌", adminToken},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				log.Fatalf("%s 执行失败: %v", cmd.name, err)
+			}
+			return
+		}
+	}
+	printUsage()
+	os.Exit(2)
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "用法: botctl <命令> [参数]")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", cmd.name, cmd.usage)
+	}
+}
+
+// trainIntent 训练朴素贝叶斯意图模型，默认保存到规则文件中的 ml_model.path
+func trainIntent(args []string) error {
+	fs := flag.NewFlagSet("train-intent", flag.ExitOnError)
+	rulesPath := fs.String("rules", "config/chatbot_rules.yml", "规则文件")
+	dataDir := fs.String("data", "config/intents", "标注语料目录，每个 <intent>.txt 一行一条")
+	output := fs.String("out", "", "模型输出路径，默认为规则中的 ml_model.path")
+	fs.Parse(args)
+
+	out := *output
+	if out == "" {
+		rules, err := chatbot.LoadChatBotRulesFromFile(*rulesPath)
+		if err != nil {
+			return err
+		}
+		out = rules.IntentDetection.MLModel.Path
+	}
+	if out == "" {
+		return fmt.Errorf("未指定模型输出路径")
+	}
+
+	samples, err := chatbot.LoadLabeledUtterances(*dataDir)
+	if err != nil {
+		return err
+	}
+	model, err := chatbot.TrainNaiveBayes(samples)
+	if err != nil {
+		return err
+	}
+	if err := model.Save(out); err != nil {
+		return err
+	}
+	log.Printf("训练完成: %d 条语料，%d 个意图，模型已保存到 %s", len(samples), len(model.Intents), out)
+	return nil
+}
+
+// exportGraph 将规则中的对话状态机输出为 DOT 或 Mermaid，默认输出到标准输出
+func exportGraph(args []string) error {
+	fs := flag.NewFlagSet("graph", flag.ExitOnError)
+	rulesPath := fs.String("rules", "config/chatbot_rules.yml", "规则文件")
+	format := fs.String("format", chatbot.GraphFormatDOT, "输出格式: dot 或 mermaid")
+	output := fs.String("out", "", "输出文件，默认为标准输出")
+	fs.Parse(args)
+
+	rules, err := chatbot.LoadChatBotRulesFromFile(*rulesPath)
+	if err != nil {
+		return err
+	}
+	graph := chatbot.NewFlowGraph(rules)
+	content, err := graph.Render(*format)
+	if err != nil {
+		return err
+	}
+	for _, node := range graph.Nodes {
+		if node.Unreachable {
+			log.Printf("不可达状态: %s", node.Name)
+		}
+		if node.DeadEnd {
+			log.Printf("无出口状态: %s", node.Name)
+		}
+	}
+
+	if *output == "" {
+		_, err = fmt.Print(content)
+		return err
+	}
+	return os.WriteFile(*output, []byte(content), 0644)
+}
+
+// backfillSentiment 分析 analyzed_at 为空的反馈；-enqueue 时投递到服务使用的 Redis 队列，否则在本进程逐条分析
+func backfillSentiment(args []string) error {
+	fs := flag.NewFlagSet("backfill-sentiment", flag.ExitOnError)
+	configPath := fs.String("config", "config/config.yaml", "服务配置文件")
+	batch := fs.Int("batch", 100, "每批读取的反馈数")
+	limit := fs.Int("limit", 0, "最多处理的反馈数，0 表示全部")
+	enqueue := fs.Bool("enqueue", false, "投递到 Redis 队列由服务异步处理，需要 queue.driver 为 redis")
+	fs.Parse(args)
+
+	v := viper.New()
+	v.SetConfigFile(*configPath)
+	if err := v.ReadInConfig(); err != nil {
+		return err
+	}
+	db, err := gorm.Open(mysql.Open(v.GetString("database.mysql.dsn")), &gorm.Config{})
+	if err != nil {
+		return err
+	}
+	store := feedbackjob.NewStore(db)
+	ctx := context.Background()
+
+	var process func(id uint) error
+	if *enqueue {
+		var queueConfig queue.Config
+		if err := v.UnmarshalKey("queue", &queueConfig); err != nil {
+			return err
+		}
+		if queueConfig.Driver != queue.DriverRedis {
+			return fmt.Errorf("-enqueue 需要 queue.driver 为 redis，进程内队列无法被服务消费")
+		}
+		rdb := redis.NewClient(&redis.Options{
+			Addr:     v.GetString("database.redis.addr"),
+			Password: v.GetString("database.redis.password"),
+			DB:       v.GetInt("database.redis.db"),
+		})
+		defer rdb.Close()
+		q, err := queue.New(queueConfig, feedbackjob.QueueName, rdb)
+		if err != nil {
+			return err
+		}
+		process = func(id uint) error { return feedbackjob.Publish(ctx, q, id) }
+	} else {
+		var sentimentConfig sentiment.Config
+		if err := v.UnmarshalKey("sentiment", &sentimentConfig); err != nil {
+			return err
+		}
+		analyzer, err := sentiment.New(sentimentConfig)
+		if err != nil {
+			return err
+		}
+		worker := feedbackjob.NewWorker(store, analyzer)
+		process = func(id uint) error { return worker.Process(ctx, id) }
+	}
+
+	count, err := feedbackjob.Backfill(ctx, store, *batch, *limit, process)
+	if *enqueue {
+		log.Printf("已投递 %d 条反馈的情感分析任务", count)
+	} else {
+		log.Printf("已分析 %d 条反馈", count)
+	}
+	return err
+}
+
+// adminToken 生成访问 /admin/、/bot/ 接口的管理员令牌
+func adminToken(args []string) error {
+	fs := flag.NewFlagSet("admin-token", flag.ExitOnError)
+	configPath := fs.String("config", "config/config.yaml", "服务配置文件")
+	customerID := fs.String("id", "1", "管理员的客户ID")
+	fs.Parse(args)
+
+	v := viper.New()
+	v.SetConfigFile(*configPath)
+	if err := v.ReadInConfig(); err != nil {
+		return err
+	}
+	if err := middleware.SetAdminKey(v.GetString("auth.admin_key")); err != nil {
+		return err
+	}
+	token, err := middleware.AdminToken(*customerID)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Println(token)
+	return err
+}
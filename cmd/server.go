@@ -12,6 +12,15 @@ import (
 
 	"gochat/internal/middleware"
 	"gochat/internal/router"
+	"gochat/internal/service/chatbot"
+	"gochat/internal/service/event"
+	"gochat/internal/service/faq"
+	"gochat/internal/service/feedbackjob"
+	"gochat/internal/service/llm"
+	"gochat/internal/service/queue"
+	"gochat/internal/service/reminder"
+	"gochat/internal/service/rulestore"
+	"gochat/internal/service/sentiment"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
@@ -65,6 +74,10 @@ func main() {
 	initConfig()
 	initMySQL()
 	// initRedis()
+	// 反馈情感分析使用 Redis 队列时需要 Redis 连接
+	if viper.GetString("queue.driver") == queue.DriverRedis {
+		initRedis()
+	}
 
 	r := gin.Default()
 
@@ -93,8 +106,92 @@ func main() {
 			LimiterType: "global",
 		}))
 	*/
+	// 后台管理令牌使用单独的密钥，未配置时后台管理接口一律拒绝
+	if err := middleware.SetAdminKey(viper.GetString("auth.admin_key")); err != nil {
+		log.Fatalf("后台管理密钥配置无效: %v", err)
+	}
+	if viper.GetString("auth.admin_key") == "" {
+		log.Printf("未配置 auth.admin_key，/admin/ 与 /bot/ 接口不可用")
+	}
 	router.InitRouter(r)
 
+	// 会话升级事件：记录日志，其他子系统可按需订阅
+	event.Default.Subscribe(chatbot.EventEscalation, func(e event.Event) {
+		log.Printf("会话升级: %+v", e.Payload)
+	})
+
+	// 加载多机器人规则，未配置时使用 config/chatbot_rules.yml 作为默认机器人
+	botsConfig := chatbot.BotsConfig{
+		Bots: []chatbot.BotConfig{{ID: chatbot.DefaultBotID, Rules: "config/chatbot_rules.yml"}},
+	}
+	if viper.IsSet("chatbot.bots") {
+		if err := viper.UnmarshalKey("chatbot", &botsConfig); err != nil {
+			log.Fatalf("机器人配置解析失败: %v", err)
+		}
+	}
+	// 规则中的 call_api 只能访问白名单内的地址
+	var callAPIConfig chatbot.CallAPIConfig
+	if err := viper.UnmarshalKey("chatbot.call_api", &callAPIConfig); err != nil {
+		log.Fatalf("call_api 配置解析失败: %v", err)
+	}
+	chatbot.SetCallAPIConfig(callAPIConfig)
+	registry, err := chatbot.LoadBotRegistry(botsConfig)
+	if err != nil {
+		log.Fatalf("机器人规则加载失败: %v", err)
+	}
+	chatbot.DefaultRegistry = registry
+	log.Printf("已加载机器人: %v", registry.IDs())
+
+	// 数据库中激活的规则版本覆盖文件规则，定期同步使所有节点切换到新版本
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go rulestore.NewWatcher(rulestore.NewStore(db), registry, viper.GetDuration("chatbot.rules_sync_interval")).Run(watchCtx)
+
+	// 定时消息保存在数据库中，各节点轮询领取到期任务，推送给本节点在线的客户
+	reminderStore := reminder.NewStore(db)
+	reminder.Default = reminderStore
+	go reminder.NewWorker(reminderStore, reminder.DefaultSessions, viper.GetString("scheduler.node_id"),
+		viper.GetDuration("scheduler.poll_interval")).Run(watchCtx)
+
+	// 加载常见问题检索索引，管理接口修改后会自动重建
+	if err := faq.Default.Reload(db); err != nil {
+		log.Printf("常见问题索引加载失败: %v", err)
+	}
+
+	// 配置了生成式模型服务时，未识别的消息由模型生成回复
+	if viper.IsSet("llm.base_url") {
+		var llmConfig llm.OpenAIConfig
+		if err := viper.UnmarshalKey("llm", &llmConfig); err != nil {
+			log.Printf("生成式模型配置无效: %v", err)
+		} else {
+			llm.Default = llm.NewOpenAIGenerator(llmConfig)
+		}
+	}
+
+	// 按配置选择情感分析提供方，配置无效时保留本地词典分析
+	var sentimentConfig sentiment.Config
+	if err := viper.UnmarshalKey("sentiment", &sentimentConfig); err != nil {
+		log.Printf("情感分析配置解析失败: %v", err)
+	} else if analyzer, err := sentiment.New(sentimentConfig); err != nil {
+		log.Printf("情感分析提供方创建失败，使用本地词典: %v", err)
+	} else {
+		sentiment.Default = analyzer
+	}
+
+	// 反馈保存后投递情感分析任务，工作协程异步写回 feedback.sentiment，失败按退避重试，超过次数进入死信队列
+	var queueConfig queue.Config
+	if err := viper.UnmarshalKey("queue", &queueConfig); err != nil {
+		log.Printf("队列配置解析失败: %v", err)
+	} else if feedbackQueue, err := queue.New(queueConfig, feedbackjob.QueueName, rdb); err != nil {
+		log.Printf("反馈情感分析队列创建失败: %v", err)
+	} else {
+		feedbackjob.Default = feedbackQueue
+		worker := feedbackjob.NewWorker(feedbackjob.NewStore(db), sentiment.Default)
+		for i := 0; i < max(queueConfig.Workers, 1); i++ {
+			go worker.Run(watchCtx, feedbackQueue)
+		}
+	}
+
 	// 创建HTTP服务器
 	srv := &http.Server{
 		Addr:    ":" + viper.GetString("server.port"),
@@ -4,6 +4,9 @@ CREATE TABLE customers (
     `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT '客户唯一标识',
     `customer_name` VARCHAR(128) NOT NULL COMMENT '客户名称（中文支持）',
     `password` VARCHAR(255) NOT NULL COMMENT 'BCrypt加密密码',
+    `locale` VARCHAR(16) NOT NULL DEFAULT '' COMMENT '语言偏好，如 zh-CN、en-US',
+    `level` TINYINT NOT NULL DEFAULT 0 COMMENT '客户等级，用于个性化分群',
+    `bot_id` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '专属机器人ID，为空时按路由策略选择',
     `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '记录创建时间',
     `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后更新时间',
     PRIMARY KEY (`id`),    
@@ -17,21 +20,128 @@ CREATE TABLE messages (
     `sender` VARCHAR(32) NOT NULL COMMENT '发送者标识',
     `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '消息创建时间',
     `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后更新时间',
-    `message_type` tinyint(4) NOT NULL DEFAULT 0 COMMENT '0:普通消息,1:feedback引导消息',
+    `message_type` tinyint(4) NOT NULL DEFAULT 0 COMMENT '0:普通消息,1:feedback引导消息,2:富媒体消息,3:按钮回传',
+    `payload` TEXT NULL COMMENT '富媒体/按钮回传的JSON内容',
+    `conversation_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '会话ID',
+    `variant` VARCHAR(255) NOT NULL DEFAULT '' COMMENT 'A/B实验版本',
+    `intent` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '机器人回复对应的意图',
+    `fallback` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否为兜底回复',
+    `trace` TEXT NULL COMMENT '机器人回复的决策记录JSON',
     PRIMARY KEY (`id`),
-    INDEX idx_customer_at (customer_id, created_at)
+    INDEX idx_customer_at (customer_id, created_at),
+    INDEX idx_conversation (conversation_id)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='用户消息记录表';
 
 CREATE TABLE `feedback` (
   `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '反馈唯一ID',
   `customer_id` bigint unsigned NOT NULL COMMENT '关联客户ID',
+  `conversation_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '会话ID',
   `score` tinyint unsigned NOT NULL COMMENT '用户评分 (0-10)',
   `comment` varchar(1024) COLLATE utf8mb4_general_ci NOT NULL COMMENT '反馈内容（支持中文）',
   `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '反馈创建时间',
   `sentiment` tinyint NOT NULL DEFAULT '0' COMMENT '0:neutral,1:positive,-1:negative',
+  `analyzed_at` timestamp NULL DEFAULT NULL COMMENT '情感分析完成时间，为空表示待分析',
   `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后更新时间',
   PRIMARY KEY (`id`),
-  KEY `idx_customer_feedback` (`customer_id`,`created_at`)
+  KEY `idx_customer_feedback` (`customer_id`,`created_at`),
+  KEY `idx_conversation` (`conversation_id`),
+  KEY `idx_analyzed_at` (`analyzed_at`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='用户反馈记录表';
 
+CREATE TABLE conversations (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT '会话ID',
+    `customer_id` BIGINT UNSIGNED NOT NULL COMMENT '关联客户ID',
+    `bot_id` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '服务该会话的机器人ID',
+    `status` VARCHAR(16) NOT NULL DEFAULT 'open' COMMENT 'open:进行中,closed:已结束,handoff:已转人工,timeout:客户沉默后结束',
+    `variants` VARCHAR(255) NOT NULL DEFAULT '' COMMENT 'A/B实验版本，如 welcome_message=control',
+    `ended_at` TIMESTAMP NULL COMMENT '会话结束时间',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '会话开始时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后更新时间',
+    PRIMARY KEY (`id`),
+    INDEX idx_customer_at (customer_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='会话记录表';
+
+CREATE TABLE faqs (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT '问题ID',
+    `question` VARCHAR(255) NOT NULL COMMENT '标准问题',
+    `answer` TEXT NOT NULL COMMENT '答案',
+    `tags` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '标签，逗号分隔',
+    `enabled` TINYINT(1) NOT NULL DEFAULT 1 COMMENT '是否参与检索',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后更新时间',
+    PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='常见问题表';
+
+CREATE TABLE rule_versions (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT '版本记录ID',
+    `bot_id` VARCHAR(64) NOT NULL COMMENT '机器人ID',
+    `version` INT NOT NULL COMMENT '版本号，机器人内递增',
+    `content` MEDIUMTEXT NOT NULL COMMENT 'YAML规则内容，创建后不可修改',
+    `checksum` VARCHAR(64) NOT NULL COMMENT '内容SHA-256',
+    `author` VARCHAR(64) NOT NULL COMMENT '上传人',
+    `comment` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '版本说明',
+    `active` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否为生效版本',
+    `shadow` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否正在影子评估',
+    `activated_at` TIMESTAMP NULL COMMENT '最近一次激活时间',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX idx_bot_version (bot_id, version),
+    INDEX idx_active (active)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='机器人规则版本表';
+
+CREATE TABLE shadow_results (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT '记录ID',
+    `bot_id` VARCHAR(64) NOT NULL COMMENT '机器人ID',
+    `candidate_version` INT NOT NULL COMMENT '候选规则版本号',
+    `conversation_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '会话ID',
+    `customer_id` BIGINT UNSIGNED NOT NULL COMMENT '客户ID',
+    `message` TEXT NOT NULL COMMENT '用户消息或按钮回传payload',
+    `intent` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '生产规则识别的意图',
+    `candidate_intent` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '候选规则识别的意图',
+    `response` TEXT COMMENT '生产回复',
+    `candidate_response` TEXT COMMENT '候选回复',
+    `fallback` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '生产是否兜底',
+    `candidate_fallback` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '候选是否兜底',
+    `handoff` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '生产是否转人工',
+    `candidate_handoff` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '候选是否转人工',
+    `intent_changed` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '意图是否不同',
+    `response_changed` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '回复是否不同',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '记录时间',
+    PRIMARY KEY (`id`),
+    INDEX idx_bot_candidate (bot_id, candidate_version),
+    INDEX idx_conversation (conversation_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='规则影子评估记录表';
+
+CREATE TABLE customer_memories (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT '记录ID',
+    `customer_id` BIGINT UNSIGNED NOT NULL COMMENT '客户ID',
+    `key` VARCHAR(64) NOT NULL COMMENT '记忆键，默认与槽位同名',
+    `value` VARCHAR(1024) NOT NULL COMMENT '记忆值',
+    `bot_id` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '写入记忆的机器人',
+    `expires_at` TIMESTAMP NULL DEFAULT NULL COMMENT '过期时间，为空表示不过期',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX uk_customer_key (customer_id, `key`),
+    INDEX idx_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='客户长期记忆表';
+
+CREATE TABLE scheduled_messages (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT '记录ID',
+    `customer_id` BIGINT UNSIGNED NOT NULL COMMENT '客户ID',
+    `bot_id` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '创建定时消息的机器人',
+    `message` TEXT NOT NULL COMMENT '消息内容',
+    `due_at` TIMESTAMP NOT NULL COMMENT '计划发送时间',
+    `status` VARCHAR(16) NOT NULL DEFAULT 'pending' COMMENT '状态：pending/sending/inbox/delivered/cancelled/failed',
+    `attempts` INT NOT NULL DEFAULT 0 COMMENT '被领取的次数',
+    `locked_by` VARCHAR(128) NOT NULL DEFAULT '' COMMENT '领取任务的节点',
+    `locked_until` TIMESTAMP NULL DEFAULT NULL COMMENT '领取租约到期时间',
+    `delivered_at` TIMESTAMP NULL DEFAULT NULL COMMENT '推送时间',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后更新时间',
+    PRIMARY KEY (`id`),
+    INDEX idx_status_due (status, due_at),
+    INDEX idx_customer_status (customer_id, status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='定时消息表';
+
 insert into customers (customer_name, password) values ('admin', '$2a$10$3Jj2V5s933h86X46z1z5Y.5z1z1z1z1z1z1z1z1z1z1z1z1z1z1z1z1z1z');
\ No newline at end of file
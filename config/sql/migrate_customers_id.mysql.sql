@@ -0,0 +1,6 @@
+-- customers 表主键列为 id（见 init_db.mysql.sql，客户查询均使用 id = ?），
+-- Customer 模型曾映射为 customer_id 列，与建表语句不一致；
+-- 按旧映射由 GORM 自动建表（主键列为 customer_id）的库执行以下语句迁移
+use gochat;
+
+ALTER TABLE customers CHANGE `customer_id` `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '客户唯一标识';
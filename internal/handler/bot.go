@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"gochat/internal/model"
+	"gochat/internal/service/chatbot"
+	"log"
+	"net/url"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	// fallbackEngine 未加载机器人注册表时所有连接共享的引擎
+	fallbackEngine     *chatbot.ChatBotEngine
+	fallbackEngineOnce sync.Once
+)
+
+// chatBotEngine 按路由策略为连接选择机器人，返回该机器人共享的引擎
+func chatBotEngine(c *gin.Context, db *gorm.DB, customerID uint64) *chatbot.ChatBotEngine {
+	registry := chatbot.DefaultRegistry
+	if registry == nil {
+		fallbackEngineOnce.Do(func() {
+			fallbackEngine = chatbot.NewChatBotEngine(db)
+		})
+		return fallbackEngine
+	}
+
+	routing := registry.Routing()
+	candidates := map[string]string{
+		chatbot.BotSourceQuery:    c.Query(routing.QueryParam),
+		chatbot.BotSourceCustomer: customerBot(db, customerID),
+	}
+	if claims, ok := c.Get("token_claims"); ok {
+		if values, ok := claims.(url.Values); ok {
+			candidates[chatbot.BotSourceToken] = values.Get(routing.TokenClaim)
+		}
+	}
+	return registry.Engine(db, registry.Select(candidates))
+}
+
+// customerBot 查询客户的专属机器人
+func customerBot(db *gorm.DB, customerID uint64) string {
+	var bots []string
+	if err := db.Model(&model.Customer{}).Where("id = ?", customerID).Pluck("bot_id", &bots).Error; err != nil {
+		log.Printf("查询客户专属机器人失败: %v", err)
+		return ""
+	}
+	if len(bots) == 0 {
+		return ""
+	}
+	return bots[0]
+}
@@ -5,6 +5,9 @@ import (
 	"gochat/internal/model"
 	"gochat/internal/service"
 	"gochat/internal/service/chatbot"
+	"gochat/internal/service/feedbackjob"
+	"gochat/internal/service/i18n"
+	"gochat/internal/service/reminder"
 	"log"
 	"net/http"
 	"strconv"
@@ -23,6 +26,10 @@ import (
 var (
 	feedbackKeywords []string
 	keywordsOnce     sync.Once
+	feedbackPrompts  = map[string]string{
+		i18n.LocaleZhCN: "感谢您的反馈，我们会在3天内处理。",
+		i18n.LocaleEnUS: "Tanks for your feedback, we will deal in 3 days.",
+	}
 )
 
 func init() {
@@ -47,23 +54,84 @@ func ServeWebSocket(c *gin.Context, upgrader websocket.Upgrader) {
 		return
 	}
 
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	// 设置读写超时（单位：秒）
 
 	if err != nil {
 		log.Println(err)
 		return
 	}
-	defer conn.Close()
+	defer ws.Close()
+	conn := &wsConn{Conn: ws}
 
 	db := c.MustGet("DB").(*gorm.DB)
 
-	chatbotEngine := chatbot.NewChatBotEngine(db)
+	// 按连接参数、令牌声明或客户属性选择机器人，引擎在连接间共享，连接期间保留客户上下文
+	chatbotEngine := chatBotEngine(c, db, validCustomerID)
+	customerKey := strconv.FormatUint(validCustomerID, 10)
+	chatbotEngine.OpenSession(customerKey)
+	defer chatbotEngine.CloseSession(customerKey)
+
+	// 会话语言：客户偏好 → Accept-Language，均未设置时由引擎根据首条消息检测
+	locale := i18n.Resolve(customerLocale(db, validCustomerID), i18n.FromRequest(c.Request))
+	chatbotEngine.SetLocale(customerKey, locale)
+
+	// 每个连接对应一个会话，记录客户所在的A/B实验版本
+	variant := chatbot.FormatVariants(chatbotEngine.Variants(customerKey))
+	conversation := startConversation(db, validCustomerID, chatbotEngine.BotID(), variant)
+	defer endConversation(db, conversation)
+
+	// 机器人正在影子评估候选规则时，消息会在候选规则上再处理一次用于比较
+	shadow := newShadowSession(db, chatbotEngine.BotID(), customerKey, locale, conversation.ID)
+	defer shadow.close(customerKey)
+
+	// 定时消息到期时推送到本连接，客户离线期间到期的消息在连接建立后从收件箱投递
+	unregister := reminder.DefaultSessions.Register(validCustomerID, func(item model.ScheduledMessage) error {
+		if err := writeReply(conn, chatbot.Reply{Text: item.Message}); err != nil {
+			return err
+		}
+		saveScheduledMessage(db, item, conversation.ID, variant)
+		return nil
+	})
+	defer unregister()
+
+	// 客户沉默时按当前状态的规则跟进，多次沉默后发送结束语并断开连接
+	inactivity := newInactivityMonitor(chatbotEngine, customerKey, func(reply chatbot.Reply) error {
+		if err := writeReply(conn, reply); err != nil {
+			return err
+		}
+		message := model.Message{
+			CustomerID:     validCustomerID,
+			Message:        reply.PlainText(),
+			Sender:         "robot",
+			CreatedAt:      time.Now().Local(),
+			MessageType:    model.MessageTypeNormal,
+			ConversationID: conversation.ID,
+			Variant:        variant,
+			Intent:         reply.Intent,
+			Trace:          reply.TraceJSON(),
+		}
+		if result := db.Create(&message); result.Error != nil {
+			log.Printf("Failed to save chat: %v", result.Error)
+		}
+		return nil
+	}, func() {
+		closing := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "inactive")
+		if err := ws.WriteControl(websocket.CloseMessage, closing, time.Now().Add(time.Second)); err != nil {
+			log.Println(err)
+		}
+		ws.Close()
+	})
+	defer inactivity.stop()
+	inactivity.reset()
 
 	for {
 		// 读取客户端消息
 		messageType, p, err := conn.ReadMessage()
 		if err != nil {
+			if inactivity.endedByInactivity() && conversation.Status == model.ConversationStatusOpen {
+				conversation.Status = model.ConversationStatusTimeout
+			}
 			log.Println(err)
 			return
 		}
@@ -73,21 +141,26 @@ func ServeWebSocket(c *gin.Context, upgrader websocket.Upgrader) {
 		msg := string(p)
 		// 修改消息存储部分
 		message := model.Message{
-			CustomerID:  validCustomerID, // 替换原有硬编码 0
-			Message:     msg,
-			Sender:      "user", // 假设用户发送的消息为 "user"
-			CreatedAt:   now,
-			MessageType: model.MessageTypeNormal,
+			CustomerID:     validCustomerID, // 替换原有硬编码 0
+			Message:        msg,
+			Sender:         "user", // 假设用户发送的消息为 "user"
+			CreatedAt:      now,
+			MessageType:    model.MessageTypeNormal,
+			ConversationID: conversation.ID,
+			Variant:        variant,
 		}
 
+		postback, isPostback := chatbot.ParsePostback(msg)
+
 		// 检测反馈关键词
-		if containsFeedbackKeywords(msg) {
+		if !isPostback && containsFeedbackKeywords(msg) {
 			message.MessageType = model.MessageTypeFeedback
 			if result := db.Create(&message); result.Error != nil {
 				log.Printf("Failed to save chat: %v", result.Error)
 			}
 			// 发送反馈提示
-			feedbackPrompt := "Tanks for your feedback, we will deal in 3 days."
+			feedbackLocale := i18n.Resolve(chatbotEngine.GetContext(customerKey).Locale, i18n.Detect(msg), i18n.DefaultLocale)
+			feedbackPrompt := i18n.Pick(feedbackPrompts, feedbackLocale, feedbackPrompts[i18n.LocaleEnUS])
 			if err := conn.WriteMessage(messageType, []byte(feedbackPrompt)); err != nil {
 				log.Println(err)
 				return
@@ -95,11 +168,13 @@ func ServeWebSocket(c *gin.Context, upgrader websocket.Upgrader) {
 
 			// 机器人消息也关联客户ID
 			feedbackResponse := model.Message{
-				CustomerID:  validCustomerID, // 替换原有硬编码 0
-				Message:     feedbackPrompt,
-				Sender:      "robot", // 假设用户发送的消息为 "user"
-				MessageType: model.MessageTypeNormal,
-				CreatedAt:   now,
+				CustomerID:     validCustomerID, // 替换原有硬编码 0
+				Message:        feedbackPrompt,
+				Sender:         "robot", // 假设用户发送的消息为 "user"
+				MessageType:    model.MessageTypeNormal,
+				CreatedAt:      now,
+				ConversationID: conversation.ID,
+				Variant:        variant,
 			}
 			if result := db.Create(&feedbackResponse); result.Error != nil {
 				log.Printf("Failed to save chat: %v", result.Error)
@@ -107,53 +182,132 @@ func ServeWebSocket(c *gin.Context, upgrader websocket.Upgrader) {
 
 			// 机器人消息也关联客户ID
 			feedback := model.Feedback{
-				CustomerID: validCustomerID, // 替换原有硬编码 0
-				Comment:    msg,
-				CreatedAt:  now,
+				CustomerID:     validCustomerID, // 替换原有硬编码 0
+				ConversationID: conversation.ID,
+				Comment:        msg,
+				CreatedAt:      now,
 			}
 			if result := db.Create(&feedback); result.Error != nil {
 				log.Printf("Failed to save feedback: %v", result.Error)
+			} else {
+				// 情感倾向由队列工作协程异步分析后写回
+				feedbackjob.Enqueue(c.Request.Context(), feedback.ID)
 			}
 		} else {
 			message.MessageType = model.MessageTypeNormal
+			if isPostback {
+				// 按钮回传：保存原始JSON，消息内容记录按钮标题
+				message.MessageType = model.MessageTypePostback
+				message.Message = postback.Payload
+				if postback.Title != "" {
+					message.Message = postback.Title
+				}
+				message.Payload = msg
+			}
 			if result := db.Create(&message); result.Error != nil {
 				log.Printf("Failed to save chat: %v", result.Error)
 			}
 			// 处理业务逻辑
-			response := chatbotEngine.ProcessMessage(
-				strconv.FormatUint(validCustomerID, 10),
-				string(msg),
-			)
+			var reply chatbot.Reply
+			if isPostback {
+				reply = chatbotEngine.HandlePostback(customerKey, postback.Payload)
+			} else {
+				reply = chatbotEngine.Respond(customerKey, msg)
+			}
 
-			// 发送响应
+			// 发送响应，生成式回复标记为 ai 便于审计
+			sender := "robot"
+			if reply.Generated {
+				sender = "ai"
+			}
 			message := model.Message{
-				CustomerID:  validCustomerID, // 替换原有硬编码 0
-				Message:     response,
-				Sender:      "robot", // 假设用户发送的消息为 "user"
-				CreatedAt:   now,
-				MessageType: model.MessageTypeNormal,
+				CustomerID:     validCustomerID, // 替换原有硬编码 0
+				Message:        reply.PlainText(),
+				Sender:         sender,
+				CreatedAt:      now,
+				MessageType:    model.MessageTypeNormal,
+				ConversationID: conversation.ID,
+				Variant:        variant,
+				Intent:         reply.Intent,
+				Fallback:       reply.Fallback,
+				Trace:          reply.TraceJSON(),
+			}
+			if reply.IsRich() {
+				message.MessageType = model.MessageTypeRich
+				message.Payload = reply.RichJSON()
 			}
 
 			if result := db.Create(&message); result.Error != nil {
 				log.Printf("Failed to save chat: %v", result.Error)
 			}
 
-			err = conn.WriteMessage(websocket.TextMessage, []byte(response))
+			if reply.Handoff {
+				markConversationHandoff(db, conversation)
+			}
 
-			if err != nil {
+			if err := writeReply(conn, reply); err != nil {
 				log.Println(err)
 				return
 			}
+
+			if isPostback {
+				shadow.observe(validCustomerID, customerKey, postback.Payload, true, reply)
+			} else {
+				shadow.observe(validCustomerID, customerKey, msg, false, reply)
+			}
 		}
+		inactivity.reset()
 	}
 
 }
 
+// richFrame 富媒体回复的WebSocket消息格式
+type richFrame struct {
+	Type string                `json:"type"` // 固定为 rich
+	Text string                `json:"text,omitempty"`
+	Rich []chatbot.RichContent `json:"rich"`
+}
+
+// wsWriteWait 单次写入的超时，客户端不再读取时避免推送长期阻塞定时消息投递和沉默跟进
+const wsWriteWait = 10 * time.Second
+
+// wsConn 串行化连接写入，回复与定时消息可能同时推送；每次写入前设置写超时
+type wsConn struct {
+	*websocket.Conn
+	mu sync.Mutex
+}
+
+func (c *wsConn) WriteMessage(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.Conn.SetWriteDeadline(time.Now().Add(wsWriteWait)); err != nil {
+		return err
+	}
+	return c.Conn.WriteMessage(messageType, data)
+}
+
+func (c *wsConn) WriteJSON(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.Conn.SetWriteDeadline(time.Now().Add(wsWriteWait)); err != nil {
+		return err
+	}
+	return c.Conn.WriteJSON(v)
+}
+
+// writeReply 推送机器人回复：纯文本回复保持文本帧，富媒体回复以JSON发送
+func writeReply(conn *wsConn, reply chatbot.Reply) error {
+	if !reply.IsRich() {
+		return conn.WriteMessage(websocket.TextMessage, []byte(reply.Text))
+	}
+	return conn.WriteJSON(richFrame{Type: "rich", Text: reply.Text, Rich: reply.Rich})
+}
+
 func validateSession(c *gin.Context) (uint64, error) {
 	// 新增授权验证
 	customerID, ok := c.Get("customer_id")
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"code": service.ErrCodeUnauthorized, "message": service.GetErrorMessage(service.ErrCodeUnauthorized)})
+		c.JSON(http.StatusUnauthorized, gin.H{"code": service.ErrCodeUnauthorized, "message": service.GetLocalizedErrorMessage(service.ErrCodeUnauthorized, i18n.FromRequest(c.Request))})
 		return 0, fmt.Errorf("未授权访问")
 	}
 
@@ -161,7 +315,7 @@ func validateSession(c *gin.Context) (uint64, error) {
 	validCustomerID, err := strconv.ParseUint(fmt.Sprintf("%v", customerID), 10, 64)
 	if err != nil {
 		// 类型转换验证失败，返回无效用户ID
-		c.JSON(http.StatusBadRequest, gin.H{"code": service.ErrCodeUserNotFound, "message": service.GetErrorMessage(service.ErrCodeUserNotFound)})
+		c.JSON(http.StatusBadRequest, gin.H{"code": service.ErrCodeUserNotFound, "message": service.GetLocalizedErrorMessage(service.ErrCodeUserNotFound, i18n.FromRequest(c.Request))})
 		return 0, fmt.Errorf("无效用户ID")
 	}
 
@@ -171,18 +325,31 @@ func validateSession(c *gin.Context) (uint64, error) {
 	// 检查customer表中是否存在该用户ID
 	var customerCount int64
 	if err := db.Model(&model.Customer{}).Where("id = ?", validCustomerID).Count(&customerCount).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": service.GetErrorMessage(service.ErrCodeInternalServer)})
+		c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": service.GetLocalizedErrorMessage(service.ErrCodeInternalServer, i18n.FromRequest(c.Request))})
 		return validCustomerID, fmt.Errorf("数据库错误")
 	}
 
 	if customerCount == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"code": service.ErrCodeUserNotFound, "message": service.GetErrorMessage(service.ErrCodeUserNotFound)})
+		c.JSON(http.StatusBadRequest, gin.H{"code": service.ErrCodeUserNotFound, "message": service.GetLocalizedErrorMessage(service.ErrCodeUserNotFound, i18n.FromRequest(c.Request))})
 		return validCustomerID, fmt.Errorf("无效用户ID")
 	}
 
 	return validCustomerID, nil
 }
 
+// customerLocale 查询客户的语言偏好
+func customerLocale(db *gorm.DB, customerID uint64) string {
+	var locales []string
+	if err := db.Model(&model.Customer{}).Where("id = ?", customerID).Pluck("locale", &locales).Error; err != nil {
+		log.Printf("查询客户语言偏好失败: %v", err)
+		return ""
+	}
+	if len(locales) == 0 {
+		return ""
+	}
+	return locales[0]
+}
+
 // 修改后的关键词检测函数
 func containsFeedbackKeywords(message string) bool {
 	lowerMsg := strings.ToLower(message)
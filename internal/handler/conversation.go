@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"gochat/internal/model"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// startConversation 连接建立时创建会话记录
+func startConversation(db *gorm.DB, customerID uint64, botID string, variants string) *model.Conversation {
+	conversation := &model.Conversation{
+		CustomerID: customerID,
+		BotID:      botID,
+		Status:     model.ConversationStatusOpen,
+		Variants:   variants,
+	}
+	if result := db.Create(conversation); result.Error != nil {
+		log.Printf("Failed to save conversation: %v", result.Error)
+	}
+	return conversation
+}
+
+// markConversationHandoff 会话转接人工
+func markConversationHandoff(db *gorm.DB, conversation *model.Conversation) {
+	if conversation.ID == 0 || conversation.Status == model.ConversationStatusHandoff {
+		return
+	}
+	conversation.Status = model.ConversationStatusHandoff
+	if result := db.Model(conversation).Update("status", conversation.Status); result.Error != nil {
+		log.Printf("Failed to update conversation: %v", result.Error)
+	}
+}
+
+// endConversation 连接断开时结束会话，已转人工的会话保留转接状态，因沉默结束的会话记录为 timeout
+func endConversation(db *gorm.DB, conversation *model.Conversation) {
+	if conversation.ID == 0 {
+		return
+	}
+	now := time.Now().Local()
+	updates := map[string]interface{}{"ended_at": now}
+	switch conversation.Status {
+	case model.ConversationStatusOpen:
+		updates["status"] = model.ConversationStatusClosed
+	case model.ConversationStatusTimeout:
+		updates["status"] = model.ConversationStatusTimeout
+	}
+	if result := db.Model(conversation).Updates(updates); result.Error != nil {
+		log.Printf("Failed to update conversation: %v", result.Error)
+	}
+}
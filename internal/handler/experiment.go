@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"gochat/internal/model"
+	"gochat/internal/service"
+	"gochat/internal/service/chatbot"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// VariantReport 实验版本的转化指标
+type VariantReport struct {
+	Variant        string  `json:"variant"`
+	Conversations  int64   `json:"conversations"`
+	BotMessages    int64   `json:"bot_messages"` // 机器人回复数，含生成式回复
+	Fallbacks      int64   `json:"fallbacks"`
+	FallbackRate   float64 `json:"fallback_rate"` // 兜底回复占机器人回复的比例
+	Handoffs       int64   `json:"handoffs"`
+	HandoffRate    float64 `json:"handoff_rate"` // 转人工会话占比
+	Feedbacks      int64   `json:"feedbacks"`
+	Analyzed       int64   `json:"analyzed_feedbacks"` // 已完成情感分析的反馈数
+	AvgSentiment   float64 `json:"avg_sentiment"`      // 已分析反馈的情感均值，-1~1；待分析的反馈情感为0，不计入
+	sentimentTotal int64
+}
+
+// ExperimentReportResponse 实验报告
+type ExperimentReportResponse struct {
+	Group    string          `json:"group"`
+	Variants []VariantReport `json:"variants"`
+}
+
+// GetExperimentReport 按版本对比实验分组的兜底率、转人工率和反馈情感
+func GetExperimentReport(c *gin.Context) {
+	db := c.MustGet("DB").(*gorm.DB)
+	group := c.Param("group")
+
+	var conversations []model.Conversation
+	if err := db.Model(&model.Conversation{}).
+		Select("id", "status", "variants").
+		Where("variants LIKE ?", "%"+group+"=%").
+		Find(&conversations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": err.Error()})
+		return
+	}
+
+	reports := make(map[string]*VariantReport)
+	variantOf := make(map[uint]string)
+	ids := make([]uint, 0, len(conversations))
+	for _, conversation := range conversations {
+		variant, ok := chatbot.ParseVariants(conversation.Variants)[group]
+		if !ok {
+			continue
+		}
+		report, exists := reports[variant]
+		if !exists {
+			report = &VariantReport{Variant: variant}
+			reports[variant] = report
+		}
+		report.Conversations++
+		if conversation.Status == model.ConversationStatusHandoff {
+			report.Handoffs++
+		}
+		variantOf[conversation.ID] = variant
+		ids = append(ids, conversation.ID)
+	}
+
+	if len(ids) > 0 {
+		var messageStats []struct {
+			ConversationID uint
+			Total          int64
+			Fallbacks      int64
+		}
+		if err := db.Model(&model.Message{}).
+			Select("conversation_id, COUNT(*) AS total, SUM(CASE WHEN fallback THEN 1 ELSE 0 END) AS fallbacks").
+			Where("sender IN ? AND conversation_id IN ?", []string{"robot", "ai"}, ids).
+			Group("conversation_id").
+			Scan(&messageStats).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": err.Error()})
+			return
+		}
+		for _, stat := range messageStats {
+			report := reports[variantOf[stat.ConversationID]]
+			report.BotMessages += stat.Total
+			report.Fallbacks += stat.Fallbacks
+		}
+
+		var feedbackStats []struct {
+			ConversationID uint
+			Total          int64
+			Analyzed       int64
+			Sentiment      int64
+		}
+		if err := db.Model(&model.Feedback{}).
+			Select("conversation_id, COUNT(*) AS total, COUNT(analyzed_at) AS analyzed, "+
+				"SUM(CASE WHEN analyzed_at IS NOT NULL THEN sentiment ELSE 0 END) AS sentiment").
+			Where("conversation_id IN ?", ids).
+			Group("conversation_id").
+			Scan(&feedbackStats).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": err.Error()})
+			return
+		}
+		for _, stat := range feedbackStats {
+			report := reports[variantOf[stat.ConversationID]]
+			report.Feedbacks += stat.Total
+			report.Analyzed += stat.Analyzed
+			report.sentimentTotal += stat.Sentiment
+		}
+	}
+
+	response := ExperimentReportResponse{Group: group, Variants: []VariantReport{}}
+	for _, report := range reports {
+		if report.BotMessages > 0 {
+			report.FallbackRate = float64(report.Fallbacks) / float64(report.BotMessages)
+		}
+		if report.Conversations > 0 {
+			report.HandoffRate = float64(report.Handoffs) / float64(report.Conversations)
+		}
+		if report.Analyzed > 0 {
+			report.AvgSentiment = float64(report.sentimentTotal) / float64(report.Analyzed)
+		}
+		response.Variants = append(response.Variants, *report)
+	}
+	sort.Slice(response.Variants, func(i, j int) bool {
+		return response.Variants[i].Variant < response.Variants[j].Variant
+	})
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"gochat/internal/service"
+	"gochat/internal/service/chatbot"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ExplainRequest 试运行请求
+type ExplainRequest struct {
+	Bot        string            `json:"bot"`         // 机器人ID，为空时使用默认机器人
+	CustomerID uint64            `json:"customer_id"` // 客户在线时基于其当前上下文，不会修改该上下文
+	Message    string            `json:"message" binding:"required"`
+	Postback   bool              `json:"postback"` // message 为按钮回传内容
+	State      string            `json:"state"`    // 指定当前状态
+	Slots      map[string]string `json:"slots"`    // 合并到上下文槽位
+	Locale     string            `json:"locale"`
+}
+
+// ExplainBot 在试运行模式下处理任意输入，返回回复及决策记录
+func ExplainBot(c *gin.Context) {
+	db := c.MustGet("DB").(*gorm.DB)
+
+	var req ExplainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": service.ErrCodeInvalidRequest, "message": err.Error()})
+		return
+	}
+
+	var engine *chatbot.ChatBotEngine
+	if registry := chatbot.DefaultRegistry; registry != nil {
+		if req.Bot == "" {
+			req.Bot = chatbot.DefaultBotID
+		}
+		if _, ok := registry.Rules(req.Bot); !ok {
+			c.JSON(http.StatusNotFound, gin.H{"code": service.ErrCodeNotFound, "message": "机器人不存在: " + req.Bot})
+			return
+		}
+		engine = registry.Engine(db, req.Bot)
+	} else {
+		engine = chatBotEngine(c, db, req.CustomerID)
+	}
+
+	explain := chatbot.ExplainRequest{
+		Message:  req.Message,
+		Postback: req.Postback,
+		State:    req.State,
+		Slots:    req.Slots,
+		Locale:   req.Locale,
+	}
+	if req.CustomerID != 0 {
+		explain.CustomerID = strconv.FormatUint(req.CustomerID, 10)
+	}
+	reply, err := engine.Explain(explain)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": service.ErrCodeInvalidRequest, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"reply": reply.PlainText(),
+			"rich":  reply.Rich,
+			"trace": reply.Trace,
+		},
+	})
+}
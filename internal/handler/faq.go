@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"errors"
+	"gochat/internal/model"
+	"gochat/internal/service"
+	"gochat/internal/service/faq"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// FAQRequest 新增或修改常见问题的请求
+type FAQRequest struct {
+	Question string   `json:"question" binding:"required"`
+	Answer   string   `json:"answer" binding:"required"`
+	Tags     []string `json:"tags"`
+	Enabled  *bool    `json:"enabled"` // 未传时默认启用
+}
+
+// FAQSearchResult 检索调试结果
+type FAQSearchResult struct {
+	model.FAQ
+	Score float64 `json:"score"`
+}
+
+func (r FAQRequest) apply(item *model.FAQ) {
+	item.Question = strings.TrimSpace(r.Question)
+	item.Answer = strings.TrimSpace(r.Answer)
+	item.Tags = strings.Join(r.Tags, ",")
+	item.Enabled = r.Enabled == nil || *r.Enabled
+}
+
+// reloadFAQIndex 修改后重建检索索引，失败时保留旧索引
+func reloadFAQIndex(db *gorm.DB) {
+	if err := faq.Default.Reload(db); err != nil {
+		log.Printf("常见问题索引重建失败: %v", err)
+	}
+}
+
+// ListFAQs 分页查询常见问题，支持按标签过滤
+func ListFAQs(c *gin.Context) {
+	db := c.MustGet("DB").(*gorm.DB)
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	query := db.Model(&model.FAQ{})
+	if tag := c.Query("tag"); tag != "" {
+		query = query.Where("FIND_IN_SET(?, tags)", tag)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": err.Error()})
+		return
+	}
+	var faqs []model.FAQ
+	if err := query.Order("id DESC").Limit(limit).Offset((page - 1) * limit).Find(&faqs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": faqs,
+		"pagination": PaginationMeta{
+			Total:       int(total),
+			CurrentPage: page,
+			PageSize:    limit,
+			TotalPages:  (int(total) + limit - 1) / limit,
+		},
+	})
+}
+
+// GetFAQ 查询单个常见问题
+func GetFAQ(c *gin.Context) {
+	db := c.MustGet("DB").(*gorm.DB)
+	var item model.FAQ
+	if !findFAQ(c, db, &item) {
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+// CreateFAQ 新增常见问题
+func CreateFAQ(c *gin.Context) {
+	db := c.MustGet("DB").(*gorm.DB)
+	var req FAQRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": service.ErrCodeInvalidRequest, "message": err.Error()})
+		return
+	}
+
+	var item model.FAQ
+	req.apply(&item)
+	if err := db.Create(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": err.Error()})
+		return
+	}
+	reloadFAQIndex(db)
+	c.JSON(http.StatusCreated, item)
+}
+
+// UpdateFAQ 修改常见问题
+func UpdateFAQ(c *gin.Context) {
+	db := c.MustGet("DB").(*gorm.DB)
+	var req FAQRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": service.ErrCodeInvalidRequest, "message": err.Error()})
+		return
+	}
+
+	var item model.FAQ
+	if !findFAQ(c, db, &item) {
+		return
+	}
+	req.apply(&item)
+	if err := db.Save(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": err.Error()})
+		return
+	}
+	reloadFAQIndex(db)
+	c.JSON(http.StatusOK, item)
+}
+
+// DeleteFAQ 删除常见问题
+func DeleteFAQ(c *gin.Context) {
+	db := c.MustGet("DB").(*gorm.DB)
+	var item model.FAQ
+	if !findFAQ(c, db, &item) {
+		return
+	}
+	if err := db.Delete(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": err.Error()})
+		return
+	}
+	reloadFAQIndex(db)
+	c.JSON(http.StatusOK, gin.H{"code": service.ErrCodeSuccess, "message": service.GetErrorMessage(service.ErrCodeSuccess)})
+}
+
+// SearchFAQs 按当前索引检索，返回得分，用于调整匹配阈值
+func SearchFAQs(c *gin.Context) {
+	q := c.Query("q")
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": service.ErrCodeInvalidRequest, "message": "缺少检索内容 q"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if err != nil || limit < 1 {
+		limit = 5
+	}
+
+	results := make([]FAQSearchResult, 0, limit)
+	for _, result := range faq.Default.Search(q, limit) {
+		results = append(results, FAQSearchResult{FAQ: result.FAQ, Score: result.Score})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": results})
+}
+
+// findFAQ 按路径参数 id 查找常见问题，未找到时写入错误响应并返回 false
+func findFAQ(c *gin.Context, db *gorm.DB, item *model.FAQ) bool {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": service.ErrCodeInvalidRequest, "message": service.GetErrorMessage(service.ErrCodeInvalidRequest)})
+		return false
+	}
+	if err := db.First(item, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"code": service.ErrCodeNotFound, "message": service.GetErrorMessage(service.ErrCodeNotFound)})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": err.Error()})
+		}
+		return false
+	}
+	return true
+}
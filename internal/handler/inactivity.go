@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"gochat/internal/service/chatbot"
+	"log"
+	"sync"
+	"time"
+)
+
+// inactivityMonitor 客户沉默达到当前状态配置的时长时推送跟进消息，跟进次数用完后结束会话
+type inactivityMonitor struct {
+	engine      *chatbot.ChatBotEngine
+	customerKey string
+	send        func(reply chatbot.Reply) error // 推送并保存跟进消息
+	end         func()                          // 结束会话，断开连接
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	stopped bool
+	ended   bool
+}
+
+func newInactivityMonitor(engine *chatbot.ChatBotEngine, customerKey string, send func(chatbot.Reply) error, end func()) *inactivityMonitor {
+	return &inactivityMonitor{engine: engine, customerKey: customerKey, send: send, end: end}
+}
+
+// reset 按客户当前状态重新计时，每次回复后调用
+func (m *inactivityMonitor) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopped {
+		return
+	}
+	delay, ok := m.engine.InactivityDelay(m.customerKey)
+	if !ok {
+		if m.timer != nil {
+			m.timer.Stop()
+		}
+		return
+	}
+	if m.timer == nil {
+		m.timer = time.AfterFunc(delay, m.fire)
+	} else {
+		m.timer.Reset(delay)
+	}
+}
+
+// fire 计时到期：客户期间发过消息时引擎不跟进，只重新计时
+func (m *inactivityMonitor) fire() {
+	reply, ok := m.engine.Nudge(m.customerKey)
+	if ok {
+		if err := m.send(reply); err != nil {
+			log.Println(err)
+			return
+		}
+		if reply.EndConversation {
+			m.mu.Lock()
+			m.ended = true
+			m.mu.Unlock()
+			m.stop()
+			m.end()
+			return
+		}
+	}
+	m.reset()
+}
+
+// stop 连接关闭时停止计时
+func (m *inactivityMonitor) stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopped = true
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+}
+
+// endedByInactivity 会话是否因客户沉默被结束
+func (m *inactivityMonitor) endedByInactivity() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ended
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"gochat/internal/model"
+	"gochat/internal/service"
+	"gochat/internal/service/chatbot"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListMemory 查看当前客户未过期的长期记忆
+func ListMemory(c *gin.Context) {
+	customerID, err := validateSession(c)
+	if err != nil {
+		return
+	}
+	db := c.MustGet("DB").(*gorm.DB)
+
+	var items []model.CustomerMemory
+	if err := db.Where("customer_id = ? AND (expires_at IS NULL OR expires_at > ?)", customerID, time.Now()).
+		Order("`key`").Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// ClearMemory 清除当前客户的长期记忆，指定 key 时只清除该项；在线会话中的记忆同步删除
+func ClearMemory(c *gin.Context) {
+	customerID, err := validateSession(c)
+	if err != nil {
+		return
+	}
+	db := c.MustGet("DB").(*gorm.DB)
+
+	query := db.Where("customer_id = ?", customerID)
+	var keys []string
+	if key := c.Param("key"); key != "" {
+		query = query.Where("`key` = ?", key)
+		keys = append(keys, key)
+	}
+	result := query.Delete(&model.CustomerMemory{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": result.Error.Error()})
+		return
+	}
+
+	if chatbot.DefaultRegistry != nil {
+		chatbot.DefaultRegistry.ForgetMemory(strconv.FormatUint(customerID, 10), keys...)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"deleted": result.RowsAffected}})
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"encoding/json"
 	"gochat/internal/model"
 	"net/http"
 	"strconv"
@@ -11,9 +12,11 @@ import (
 )
 
 type MessageResponse struct {
-	CustomerID uint64 `json:"customer_id"`
-	Message    string `json:"message"`
-	Sender     string `json:"sender"`
+	CustomerID  uint64          `json:"customer_id"`
+	Message     string          `json:"message"`
+	Sender      string          `json:"sender"`
+	MessageType int             `json:"message_type"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
 
 	CreatedAt time.Time `json:"timestamp"`
 }
@@ -87,12 +90,17 @@ func GetMessageList(c *gin.Context) {
 	// 修改返回数据结构部分
 	var responseData []MessageResponse
 	for _, msg := range messages {
-		responseData = append(responseData, MessageResponse{
-			CustomerID: msg.CustomerID,
-			Message:    msg.Message,
-			Sender:     msg.Sender,
-			CreatedAt:  msg.CreatedAt, // 保持时间字段自动转换
-		})
+		item := MessageResponse{
+			CustomerID:  msg.CustomerID,
+			Message:     msg.Message,
+			Sender:      msg.Sender,
+			MessageType: msg.MessageType,
+			CreatedAt:   msg.CreatedAt, // 保持时间字段自动转换
+		}
+		if msg.Payload != "" {
+			item.Payload = json.RawMessage(msg.Payload)
+		}
+		responseData = append(responseData, item)
 	}
 
 	// 更新分页响应结构
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+	"gochat/internal/model"
+	"gochat/internal/service"
+	"gochat/internal/service/reminder"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// reminderScheduler 返回定时消息调度器，未配置时使用请求的数据库连接
+func reminderScheduler(c *gin.Context) reminder.Scheduler {
+	if reminder.Default != nil {
+		return reminder.Default
+	}
+	return reminder.NewStore(c.MustGet("DB").(*gorm.DB))
+}
+
+// ListReminders 查看当前客户的定时消息，可按 status 过滤，如 ?status=pending&status=inbox
+func ListReminders(c *gin.Context) {
+	customerID, err := validateSession(c)
+	if err != nil {
+		return
+	}
+	items, err := reminderScheduler(c).List(customerID, c.QueryArray("status")...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// CancelReminder 取消当前客户尚未投递的定时消息
+func CancelReminder(c *gin.Context) {
+	customerID, err := validateSession(c)
+	if err != nil {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": service.ErrCodeInvalidRequest, "message": "无效的定时消息ID"})
+		return
+	}
+
+	err = reminderScheduler(c).Cancel(customerID, uint(id))
+	switch {
+	case errors.Is(err, reminder.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": service.ErrCodeNotFound, "message": err.Error()})
+		return
+	case errors.Is(err, reminder.ErrNotCancellable):
+		c.JSON(http.StatusConflict, gin.H{"code": service.ErrCodeConflict, "message": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"id": id, "status": model.ScheduledCancelled}})
+}
+
+// saveScheduledMessage 已推送的定时消息记录为机器人消息
+func saveScheduledMessage(db *gorm.DB, item model.ScheduledMessage, conversationID uint, variant string) {
+	message := model.Message{
+		CustomerID:     item.CustomerID,
+		Message:        item.Message,
+		Sender:         "robot",
+		MessageType:    model.MessageTypeNormal,
+		CreatedAt:      time.Now().Local(),
+		ConversationID: conversationID,
+		Variant:        variant,
+	}
+	if result := db.Create(&message); result.Error != nil {
+		log.Printf("Failed to save chat: %v", result.Error)
+	}
+}
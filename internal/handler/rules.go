@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"errors"
+	"gochat/internal/service"
+	"gochat/internal/service/chatbot"
+	"gochat/internal/service/rulestore"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RuleUploadRequest 上传规则版本的请求
+type RuleUploadRequest struct {
+	Content string `json:"content" binding:"required"` // YAML 规则内容
+	Author  string `json:"author" binding:"required"`
+	Comment string `json:"comment"`
+}
+
+// RuleValidateRequest 校验规则的请求
+type RuleValidateRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// ListRuleVersions 查询机器人的规则版本
+func ListRuleVersions(c *gin.Context) {
+	store := rulestore.NewStore(c.MustGet("DB").(*gorm.DB))
+	versions, err := store.List(c.Param("bot"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": versions})
+}
+
+// GetRuleVersion 查询单个规则版本及其内容
+func GetRuleVersion(c *gin.Context) {
+	version, ok := ruleVersionParam(c, "version")
+	if !ok {
+		return
+	}
+	store := rulestore.NewStore(c.MustGet("DB").(*gorm.DB))
+	item, err := store.Get(c.Param("bot"), version)
+	if err != nil {
+		writeRuleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+// UploadRuleVersion 校验并保存新的规则版本，需激活后生效
+func UploadRuleVersion(c *gin.Context) {
+	var req RuleUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": service.ErrCodeInvalidRequest, "message": err.Error()})
+		return
+	}
+	store := rulestore.NewStore(c.MustGet("DB").(*gorm.DB))
+	item, err := store.Upload(c.Param("bot"), req.Content, req.Author, req.Comment)
+	if err != nil {
+		writeRuleError(c, err)
+		return
+	}
+	item.Content = ""
+	c.JSON(http.StatusCreated, item)
+}
+
+// ValidateRules 仅校验规则内容，不保存
+func ValidateRules(c *gin.Context) {
+	var req RuleValidateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": service.ErrCodeInvalidRequest, "message": err.Error()})
+		return
+	}
+	if _, err := rulestore.Validate(req.Content); err != nil {
+		writeRuleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": service.ErrCodeSuccess, "message": service.GetErrorMessage(service.ErrCodeSuccess)})
+}
+
+// DiffRuleVersions 比较两个规则版本，参数 from、to 为版本号
+func DiffRuleVersions(c *gin.Context) {
+	from, ok := ruleVersionQuery(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := ruleVersionQuery(c, "to")
+	if !ok {
+		return
+	}
+	store := rulestore.NewStore(c.MustGet("DB").(*gorm.DB))
+	diff, err := store.Diff(c.Param("bot"), from, to)
+	if err != nil {
+		writeRuleError(c, err)
+		return
+	}
+	c.String(http.StatusOK, diff)
+}
+
+// ActivateRuleVersion 激活指定版本，本节点立即生效，其他节点在下次同步时生效
+func ActivateRuleVersion(c *gin.Context) {
+	version, ok := ruleVersionParam(c, "version")
+	if !ok {
+		return
+	}
+	store := rulestore.NewStore(c.MustGet("DB").(*gorm.DB))
+	item, err := store.Activate(c.Param("bot"), version)
+	if err != nil {
+		writeRuleError(c, err)
+		return
+	}
+	applyRuleVersion(item.BotID, item.Content)
+	item.Content = ""
+	c.JSON(http.StatusOK, item)
+}
+
+// RollbackRules 回滚到上一个生效的版本
+func RollbackRules(c *gin.Context) {
+	store := rulestore.NewStore(c.MustGet("DB").(*gorm.DB))
+	item, err := store.Rollback(c.Param("bot"))
+	if err != nil {
+		writeRuleError(c, err)
+		return
+	}
+	applyRuleVersion(item.BotID, item.Content)
+	item.Content = ""
+	c.JSON(http.StatusOK, item)
+}
+
+// GetRulesGraph 导出对话状态图，format 为 dot（默认）或 mermaid；
+// 指定 version 时导出该版本，否则导出本节点正在使用的规则
+func GetRulesGraph(c *gin.Context) {
+	botID := c.Param("bot")
+	var rules chatbot.ChatBotRules
+	if c.Query("version") != "" {
+		version, ok := ruleVersionQuery(c, "version")
+		if !ok {
+			return
+		}
+		store := rulestore.NewStore(c.MustGet("DB").(*gorm.DB))
+		item, err := store.Get(botID, version)
+		if err != nil {
+			writeRuleError(c, err)
+			return
+		}
+		if rules, err = rulestore.Validate(item.Content); err != nil {
+			writeRuleError(c, err)
+			return
+		}
+	} else {
+		var ok bool
+		if chatbot.DefaultRegistry != nil {
+			rules, ok = chatbot.DefaultRegistry.Rules(botID)
+		}
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"code": service.ErrCodeNotFound, "message": "机器人不存在: " + botID})
+			return
+		}
+	}
+
+	graph, err := chatbot.NewFlowGraph(rules).Render(c.DefaultQuery("format", chatbot.GraphFormatDOT))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": service.ErrCodeInvalidRequest, "message": err.Error()})
+		return
+	}
+	c.String(http.StatusOK, graph)
+}
+
+// applyRuleVersion 将已激活的版本应用到本节点
+func applyRuleVersion(botID, content string) {
+	if chatbot.DefaultRegistry == nil {
+		return
+	}
+	rules, err := rulestore.Validate(content)
+	if err != nil {
+		log.Printf("机器人 %s 规则应用失败: %v", botID, err)
+		return
+	}
+	chatbot.DefaultRegistry.Register(botID, rules)
+}
+
+func ruleVersionParam(c *gin.Context, name string) (int, bool) {
+	version, err := strconv.Atoi(c.Param(name))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": service.ErrCodeInvalidRequest, "message": "无效的版本号"})
+		return 0, false
+	}
+	return version, true
+}
+
+func ruleVersionQuery(c *gin.Context, name string) (int, bool) {
+	version, err := strconv.Atoi(c.Query(name))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": service.ErrCodeInvalidRequest, "message": "无效的版本号: " + name})
+		return 0, false
+	}
+	return version, true
+}
+
+// writeRuleError 按错误类型返回 400/404/500
+func writeRuleError(c *gin.Context, err error) {
+	var validationErr *rulestore.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{"code": service.ErrCodeInvalidRequest, "message": err.Error()})
+	case errors.Is(err, rulestore.ErrVersionNotFound), errors.Is(err, rulestore.ErrNoPreviousVersion):
+		c.JSON(http.StatusNotFound, gin.H{"code": service.ErrCodeNotFound, "message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": err.Error()})
+	}
+}
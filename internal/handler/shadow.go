@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"gochat/internal/model"
+	"gochat/internal/service"
+	"gochat/internal/service/chatbot"
+	"gochat/internal/service/rulestore"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// shadowSession 连接内的影子评估：消息在候选规则上再处理一次，只记录差异，不回复用户
+type shadowSession struct {
+	db             *gorm.DB
+	engine         *chatbot.ChatBotEngine
+	botID          string
+	version        int
+	conversationID uint
+}
+
+// newShadowSession 机器人正在影子评估时创建会话，否则返回 nil
+func newShadowSession(db *gorm.DB, botID, customerKey, locale string, conversationID uint) *shadowSession {
+	if chatbot.DefaultRegistry == nil {
+		return nil
+	}
+	engine, version, ok := chatbot.DefaultRegistry.ShadowEngine(db, botID)
+	if !ok {
+		return nil
+	}
+	engine.OpenSession(customerKey)
+	engine.SetLocale(customerKey, locale)
+	return &shadowSession{db: db, engine: engine, botID: botID, version: version, conversationID: conversationID}
+}
+
+// close 连接断开时释放影子引擎中的客户上下文
+func (s *shadowSession) close(customerKey string) {
+	if s == nil {
+		return
+	}
+	s.engine.CloseSession(customerKey)
+}
+
+// observe 在候选规则上处理同一条消息并记录比较结果
+func (s *shadowSession) observe(customerID uint64, customerKey, input string, isPostback bool, production chatbot.Reply) {
+	if s == nil {
+		return
+	}
+	var candidate chatbot.Reply
+	if isPostback {
+		candidate = s.engine.HandlePostback(customerKey, input)
+	} else {
+		candidate = s.engine.Respond(customerKey, input)
+	}
+
+	comparison := chatbot.CompareShadow(production, candidate)
+	result := model.ShadowResult{
+		BotID:             s.botID,
+		CandidateVersion:  s.version,
+		ConversationID:    s.conversationID,
+		CustomerID:        customerID,
+		Message:           input,
+		Intent:            production.Intent,
+		CandidateIntent:   candidate.Intent,
+		Response:          production.PlainText(),
+		CandidateResponse: candidate.PlainText(),
+		Fallback:          production.Fallback,
+		CandidateFallback: candidate.Fallback,
+		Handoff:           production.Handoff,
+		CandidateHandoff:  candidate.Handoff,
+		IntentChanged:     comparison.IntentChanged,
+		ResponseChanged:   comparison.ResponseChanged,
+	}
+	if result := s.db.Create(&result); result.Error != nil {
+		log.Printf("Failed to save shadow result: %v", result.Error)
+	}
+}
+
+// StartShadow 开始影子评估指定版本，本节点立即生效，其他节点在下次同步时生效
+func StartShadow(c *gin.Context) {
+	version, ok := ruleVersionParam(c, "version")
+	if !ok {
+		return
+	}
+	store := rulestore.NewStore(c.MustGet("DB").(*gorm.DB))
+	item, err := store.StartShadow(c.Param("bot"), version)
+	if err != nil {
+		writeRuleError(c, err)
+		return
+	}
+	if chatbot.DefaultRegistry != nil {
+		if rules, err := rulestore.Validate(item.Content); err == nil {
+			chatbot.DefaultRegistry.SetCandidate(item.BotID, item.Version, rules)
+		}
+	}
+	item.Content = ""
+	c.JSON(http.StatusOK, item)
+}
+
+// StopShadow 停止影子评估
+func StopShadow(c *gin.Context) {
+	botID := c.Param("bot")
+	store := rulestore.NewStore(c.MustGet("DB").(*gorm.DB))
+	if err := store.StopShadow(botID); err != nil {
+		writeRuleError(c, err)
+		return
+	}
+	if chatbot.DefaultRegistry != nil {
+		chatbot.DefaultRegistry.ClearCandidate(botID)
+	}
+	c.JSON(http.StatusOK, gin.H{"code": service.ErrCodeSuccess, "message": service.GetErrorMessage(service.ErrCodeSuccess)})
+}
+
+// IntentTransition 生产意图到候选意图的变化
+type IntentTransition struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count int64  `json:"count"`
+}
+
+// ShadowReport 候选规则相对生产规则的行为变化汇总
+type ShadowReport struct {
+	BotID                 string               `json:"bot_id"`
+	CandidateVersion      int                  `json:"candidate_version"`
+	Messages              int64                `json:"messages"`
+	IntentChanged         int64                `json:"intent_changed"`
+	IntentChangeRate      float64              `json:"intent_change_rate"`
+	ResponseChanged       int64                `json:"response_changed"`
+	ResponseChangeRate    float64              `json:"response_change_rate"`
+	FallbackRate          float64              `json:"fallback_rate"`
+	CandidateFallbackRate float64              `json:"candidate_fallback_rate"`
+	HandoffRate           float64              `json:"handoff_rate"`
+	CandidateHandoffRate  float64              `json:"candidate_handoff_rate"`
+	IntentTransitions     []IntentTransition   `json:"intent_transitions"` // 意图变化最多的组合
+	Samples               []model.ShadowResult `json:"samples"`            // 最近的差异样本
+}
+
+// GetShadowReport 汇总候选版本的影子评估结果，参数 version 默认为最近评估的版本
+func GetShadowReport(c *gin.Context) {
+	db := c.MustGet("DB").(*gorm.DB)
+	botID := c.Param("bot")
+
+	version, err := strconv.Atoi(c.Query("version"))
+	if err != nil || version < 1 {
+		var latest []int
+		if err := db.Model(&model.ShadowResult{}).Where("bot_id = ?", botID).
+			Order("id DESC").Limit(1).Pluck("candidate_version", &latest).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": err.Error()})
+			return
+		}
+		if len(latest) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"code": service.ErrCodeNotFound, "message": "没有影子评估记录"})
+			return
+		}
+		version = latest[0]
+	}
+
+	query := func() *gorm.DB {
+		return db.Model(&model.ShadowResult{}).Where("bot_id = ? AND candidate_version = ?", botID, version)
+	}
+
+	var totals struct {
+		Messages          int64
+		IntentChanged     int64
+		ResponseChanged   int64
+		Fallback          int64
+		CandidateFallback int64
+		Handoff           int64
+		CandidateHandoff  int64
+	}
+	if err := query().Select(
+		"COUNT(*) AS messages, " +
+			"COALESCE(SUM(intent_changed), 0) AS intent_changed, " +
+			"COALESCE(SUM(response_changed), 0) AS response_changed, " +
+			"COALESCE(SUM(fallback), 0) AS fallback, " +
+			"COALESCE(SUM(candidate_fallback), 0) AS candidate_fallback, " +
+			"COALESCE(SUM(handoff), 0) AS handoff, " +
+			"COALESCE(SUM(candidate_handoff), 0) AS candidate_handoff",
+	).Scan(&totals).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": err.Error()})
+		return
+	}
+
+	report := ShadowReport{
+		BotID:                 botID,
+		CandidateVersion:      version,
+		Messages:              totals.Messages,
+		IntentChanged:         totals.IntentChanged,
+		IntentChangeRate:      rate(totals.IntentChanged, totals.Messages),
+		ResponseChanged:       totals.ResponseChanged,
+		ResponseChangeRate:    rate(totals.ResponseChanged, totals.Messages),
+		FallbackRate:          rate(totals.Fallback, totals.Messages),
+		CandidateFallbackRate: rate(totals.CandidateFallback, totals.Messages),
+		HandoffRate:           rate(totals.Handoff, totals.Messages),
+		CandidateHandoffRate:  rate(totals.CandidateHandoff, totals.Messages),
+		IntentTransitions:     []IntentTransition{},
+		Samples:               []model.ShadowResult{},
+	}
+
+	if err := query().Select("intent AS `from`, candidate_intent AS `to`, COUNT(*) AS count").
+		Where("intent_changed = ?", true).
+		Group("intent, candidate_intent").Order("count DESC").Limit(20).
+		Scan(&report.IntentTransitions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": err.Error()})
+		return
+	}
+	if err := query().Where("intent_changed = ? OR response_changed = ?", true, true).
+		Order("id DESC").Limit(10).Find(&report.Samples).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": service.ErrCodeInternalServer, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// rate 计算占比，分母为0时返回0
+func rate(count, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) / float64(total)
+}
@@ -1,36 +1,130 @@
 package middleware
 
 import (
+	"fmt"
 	"gochat/internal/service"
+	"gochat/internal/service/i18n"
 	"log"
+	"net/url"
+	"strings"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
 )
 
+// RoleAdmin 后台管理员的令牌声明，如 "1?role=admin"
+const RoleAdmin = "admin"
+
+// adminKey 后台管理令牌的加密密钥，对应 config.yaml 的 auth.admin_key；
+// 客户令牌使用代码中的默认密钥，任何人都能生成，因此管理员令牌必须使用单独配置的密钥
+var adminKey atomic.Pointer[[]byte]
+
+// SetAdminKey 设置后台管理令牌的密钥，须为 16、24 或 32 字节；为空时拒绝所有后台管理请求
+func SetAdminKey(key string) error {
+	switch len(key) {
+	case 0:
+		adminKey.Store(nil)
+		return nil
+	case 16, 24, 32:
+		k := []byte(key)
+		adminKey.Store(&k)
+		return nil
+	default:
+		return fmt.Errorf("auth.admin_key 须为 16、24 或 32 字节，当前为 %d 字节", len(key))
+	}
+}
+
+// AdminToken 用后台管理密钥生成管理员令牌，未配置密钥时返回错误
+func AdminToken(customerID string) (string, error) {
+	key := adminKey.Load()
+	if key == nil {
+		return "", fmt.Errorf("未配置 auth.admin_key")
+	}
+	return service.EncryptString(customerID+"?role="+RoleAdmin, *key)
+}
+
 func JWTAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// tokenString := c.GetHeader("Authorization")
+		if authenticate(c) {
+			c.Next()
+		}
+	}
+}
+
+// AdminAuthMiddleware 后台管理接口认证：令牌须由 auth.admin_key 加密并声明 role=admin；
+// 未携带或无法解密的令牌返回 401，客户令牌、未声明管理员或未配置密钥时返回 403
+func AdminAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
 		tokenString := c.Query("token")
 		if tokenString == "" {
-			c.AbortWithStatusJSON(401, gin.H{"code": service.ErrCodeUnauthorized, "message": service.GetErrorMessage(service.ErrCodeUnauthorized)})
+			abortWithError(c, 401, service.ErrCodeUnauthorized)
+			return
+		}
+		key := adminKey.Load()
+		if key == nil {
+			log.Printf("未配置 auth.admin_key，拒绝后台管理请求 %s", c.Request.URL.Path)
+			abortWithError(c, 403, service.ErrCodeForbidden)
 			return
 		}
 
-		// 这里添加实际的 JWT 解析逻辑
-		customerID, err := parseToken(tokenString)
-		strEncrypt, _ := service.EncryptString("1")
-		log.Printf("debug %v", strEncrypt)
-		log.Printf("customerID: %v, err: %v", customerID, err)
+		customerID, claims, err := parseToken(tokenString, *key)
 		if err != nil {
-			c.AbortWithStatusJSON(401, gin.H{"code": service.ErrCodeInvalidToken, "message": service.GetErrorMessage(service.ErrCodeInvalidToken)})
+			if _, _, err := parseToken(tokenString); err == nil {
+				// 客户令牌不能访问后台管理接口
+				abortWithError(c, 403, service.ErrCodeForbidden)
+				return
+			}
+			abortWithError(c, 401, service.ErrCodeInvalidToken)
+			return
+		}
+		if claims.Get("role") != RoleAdmin {
+			abortWithError(c, 403, service.ErrCodeForbidden)
 			return
 		}
-
 		c.Set("customer_id", customerID)
+		c.Set("token_claims", claims)
 		c.Next()
 	}
 }
 
-func parseToken(tokenString string) (string, error) {
-	return service.DecryptString(tokenString)
+func abortWithError(c *gin.Context, status, code int) {
+	c.AbortWithStatusJSON(status, gin.H{"code": code, "message": service.GetLocalizedErrorMessage(code, i18n.FromRequest(c.Request))})
+}
+
+// authenticate 解析令牌并写入 customer_id 和 token_claims，失败时中止请求并返回 false
+func authenticate(c *gin.Context) bool {
+	// tokenString := c.GetHeader("Authorization")
+	tokenString := c.Query("token")
+	if tokenString == "" {
+		c.AbortWithStatusJSON(401, gin.H{"code": service.ErrCodeUnauthorized, "message": service.GetLocalizedErrorMessage(service.ErrCodeUnauthorized, i18n.FromRequest(c.Request))})
+		return false
+	}
+
+	// 这里添加实际的 JWT 解析逻辑
+	customerID, claims, err := parseToken(tokenString)
+	strEncrypt, _ := service.EncryptString("1")
+	log.Printf("debug %v", strEncrypt)
+	log.Printf("customerID: %v, err: %v", customerID, err)
+	if err != nil {
+		c.AbortWithStatusJSON(401, gin.H{"code": service.ErrCodeInvalidToken, "message": service.GetLocalizedErrorMessage(service.ErrCodeInvalidToken, i18n.FromRequest(c.Request))})
+		return false
+	}
+
+	c.Set("customer_id", customerID)
+	c.Set("token_claims", claims)
+	return true
+}
+
+// parseToken 解密令牌，令牌内容为客户ID，可附带查询串格式的声明，如 "1?bot=sales"；未指定 key 时使用客户令牌的密钥
+func parseToken(tokenString string, key ...[]byte) (string, url.Values, error) {
+	plaintext, err := service.DecryptString(tokenString, key...)
+	if err != nil {
+		return "", nil, err
+	}
+	customerID, rawClaims, _ := strings.Cut(plaintext, "?")
+	claims, err := url.ParseQuery(rawClaims)
+	if err != nil {
+		return "", nil, err
+	}
+	return customerID, claims, nil
 }
@@ -4,6 +4,7 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"gochat/internal/middleware"
@@ -38,4 +39,69 @@ func TestAuthMiddleware(t *testing.T) {
 		router.ServeHTTP(w, req)
 		assert.Equal(t, http.StatusOK, w.Code)
 	})
+
+	t.Run("令牌声明", func(t *testing.T) {
+		claimsRouter := gin.New()
+		claimsRouter.Use(middleware.JWTAuthMiddleware())
+		claimsRouter.GET("/protected", func(c *gin.Context) {
+			claims := c.MustGet("token_claims").(url.Values)
+			c.String(http.StatusOK, c.GetString("customer_id")+":"+claims.Get("bot"))
+		})
+
+		token, _ := service.EncryptString("1?bot=sales")
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/protected?token="+url.QueryEscape(token), nil)
+		claimsRouter.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "1:sales", w.Body.String())
+	})
+}
+
+func TestAdminAuthMiddleware(t *testing.T) {
+	router := gin.New()
+	router.Use(middleware.AdminAuthMiddleware())
+	router.GET("/admin", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("customer_id"))
+	})
+
+	adminKey := []byte("fedcba9876543210fedcba9876543210")
+	request := func(token string) *httptest.ResponseRecorder {
+		path := "/admin"
+		if token != "" {
+			path += "?token=" + url.QueryEscape(token)
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	adminToken, _ := service.EncryptString("1?role=admin", adminKey)
+
+	// 未配置密钥时拒绝所有请求
+	assert.NoError(t, middleware.SetAdminKey(""))
+	assert.Equal(t, http.StatusForbidden, request(adminToken).Code)
+	assert.Error(t, middleware.SetAdminKey("short"))
+
+	assert.NoError(t, middleware.SetAdminKey(string(adminKey)))
+	defer middleware.SetAdminKey("")
+	assert.Equal(t, http.StatusUnauthorized, request("").Code)
+	assert.Equal(t, http.StatusUnauthorized, request("invalid").Code)
+
+	// 客户令牌的密钥公开，用它生成的管理员声明无效
+	customerToken, _ := service.EncryptString("1")
+	forged, _ := service.EncryptString("1?role=admin")
+	assert.Equal(t, http.StatusForbidden, request(customerToken).Code)
+	assert.Equal(t, http.StatusForbidden, request(forged).Code)
+
+	agentToken, _ := service.EncryptString("1?role=agent", adminKey)
+	assert.Equal(t, http.StatusForbidden, request(agentToken).Code)
+	w := request(adminToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Body.String())
+
+	token, err := middleware.AdminToken("2")
+	assert.NoError(t, err)
+	w = request(token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Body.String())
 }
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+)
+
+// 会话状态
+const (
+	ConversationStatusOpen    = "open"
+	ConversationStatusClosed  = "closed"
+	ConversationStatusHandoff = "handoff" // 已转接人工
+	ConversationStatusTimeout = "timeout" // 客户多次沉默后由机器人结束
+)
+
+// Conversation 一次WebSocket连接对应一个会话
+type Conversation struct {
+	ID         uint       `gorm:"primary_key" json:"id"`
+	CustomerID uint64     `gorm:"index;not null" json:"customer_id"`
+	BotID      string     `gorm:"size:64;not null;default:''" json:"bot_id"` // 服务该会话的机器人
+	Status     string     `gorm:"size:16;not null;default:'open'" json:"status"`
+	Variants   string     `gorm:"size:255;not null;default:''" json:"variants"` // A/B 实验版本，如 welcome_message=control
+	EndedAt    *time.Time `json:"ended_at"`
+
+	CreatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName 自定义表名
+func (Conversation) TableName() string {
+	return "conversations"
+}
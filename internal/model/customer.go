@@ -5,9 +5,12 @@ import (
 )
 
 type Customer struct {
-	ID           uint64    `gorm:"primaryKey;column:customer_id;autoIncrement:true" json:"customer_id"`
+	ID           uint64    `gorm:"primaryKey;column:id;autoIncrement:true" json:"customer_id"`
 	CustomerName string    `gorm:"type:varchar(128);uniqueIndex;not null" json:"customer_name"`
 	Password     string    `gorm:"type:varchar(255);not null" json:"-"`
+	Locale       string    `gorm:"type:varchar(16);not null;default:''" json:"locale"` // 语言偏好，如 zh-CN、en-US
+	Level        int       `gorm:"type:tinyint;not null;default:0" json:"level"`       // 客户等级，用于个性化分群
+	BotID        string    `gorm:"type:varchar(64);not null;default:''" json:"bot_id"` // 专属机器人，如 sales
 	CreatedAt    time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt    time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" json:"updated_at"`
 }
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+)
+
+// CustomerMemory 客户长期记忆：跨会话保留的槽位值，如常用城市
+type CustomerMemory struct {
+	ID         uint       `gorm:"primary_key" json:"-"`
+	CustomerID uint64     `gorm:"not null;uniqueIndex:uk_customer_key" json:"-"`
+	Key        string     `gorm:"size:64;not null;uniqueIndex:uk_customer_key" json:"key"`
+	Value      string     `gorm:"size:1024;not null" json:"value"`
+	BotID      string     `gorm:"size:64;not null;default:''" json:"bot_id"`   // 写入记忆的机器人
+	ExpiresAt  *time.Time `gorm:"type:timestamp NULL;index" json:"expires_at"` // 为空表示不过期
+
+	CreatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName 自定义表名
+func (CustomerMemory) TableName() string {
+	return "customer_memories"
+}
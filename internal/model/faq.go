@@ -0,0 +1,34 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// FAQ 常见问题，未识别意图时按问题检索答案
+type FAQ struct {
+	ID       uint   `gorm:"primary_key" json:"id"`
+	Question string `gorm:"size:255;not null" json:"question"`
+	Answer   string `gorm:"type:text;not null" json:"answer"`
+	Tags     string `gorm:"size:255;not null;default:''" json:"tags"` // 逗号分隔，参与检索
+	Enabled  bool   `gorm:"not null;default:true" json:"enabled"`
+
+	CreatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName 自定义表名
+func (FAQ) TableName() string {
+	return "faqs"
+}
+
+// TagList 返回去除空白后的标签列表
+func (f FAQ) TagList() []string {
+	var tags []string
+	for _, tag := range strings.Split(f.Tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -5,11 +5,14 @@ import (
 )
 
 type Feedback struct {
-	ID         uint   `gorm:"primary_key" json:"id"`
-	CustomerID uint64 `gorm:"index"`
-	Score      uint   // requested, completed
-	Comment    string `gorm:"type:text"`
-	Sentiment  int    `gorm:"type:tinyint;default:0" json:"sentiment"`
+	ID             uint   `gorm:"primary_key" json:"id"`
+	CustomerID     uint64 `gorm:"index"`
+	ConversationID uint   `gorm:"index;not null;default:0" json:"conversation_id"`
+	Score          uint   // requested, completed
+	Comment        string `gorm:"type:text"`
+	Sentiment      int    `gorm:"type:tinyint;default:0" json:"sentiment"`
+	// AnalyzedAt 情感分析完成时间，为空表示尚未分析
+	AnalyzedAt *time.Time `gorm:"type:timestamp NULL;index" json:"analyzed_at"`
 
 	CreatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" json:"updated_at"`
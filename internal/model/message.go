@@ -10,6 +10,13 @@ type Message struct {
 	Message     string `gorm:"type:text;not null" json:"message" comment:"消息内容"`
 	Sender      string `gorm:"size:32;not null" json:"sender" comment:"发送者标识"`
 	MessageType int    `gorm:"not null" json:"message_type" comment:"消息类型"`
+	Payload     string `gorm:"type:text" json:"payload,omitempty" comment:"富媒体/按钮回传的JSON内容"`
+
+	ConversationID uint   `gorm:"index;not null;default:0" json:"conversation_id" comment:"会话ID"`
+	Variant        string `gorm:"size:255;not null;default:''" json:"variant" comment:"A/B实验版本"`
+	Intent         string `gorm:"size:64;not null;default:''" json:"intent" comment:"机器人回复对应的意图"`
+	Fallback       bool   `gorm:"not null;default:false" json:"fallback" comment:"是否为兜底回复"`
+	Trace          string `gorm:"type:text" json:"trace,omitempty" comment:"机器人回复的决策记录JSON"`
 
 	CreatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP" json:"timestamp"`
 	UpdatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" json:"updated_at"`
@@ -18,6 +25,8 @@ type Message struct {
 const (
 	MessageTypeNormal = iota
 	MessageTypeFeedback
+	MessageTypeRich     // 机器人富媒体消息，Payload 为卡片/按钮JSON
+	MessageTypePostback // 用户点击按钮回传，Payload 为回传JSON
 )
 
 // TableName 自定义表名
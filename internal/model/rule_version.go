@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+)
+
+// RuleVersion 机器人规则的不可变版本，内容创建后不再修改，通过激活切换生效版本
+type RuleVersion struct {
+	ID          uint       `gorm:"primary_key" json:"id"`
+	BotID       string     `gorm:"size:64;not null;uniqueIndex:idx_bot_version" json:"bot_id"`
+	Version     int        `gorm:"not null;uniqueIndex:idx_bot_version" json:"version"`
+	Content     string     `gorm:"type:mediumtext;not null" json:"content,omitempty"` // YAML 规则内容
+	Checksum    string     `gorm:"size:64;not null" json:"checksum"`                  // 内容的 SHA-256
+	Author      string     `gorm:"size:64;not null" json:"author"`
+	Comment     string     `gorm:"size:255;not null;default:''" json:"comment"`
+	Active      bool       `gorm:"not null;default:false" json:"active"`
+	Shadow      bool       `gorm:"not null;default:false" json:"shadow"` // 是否正在影子评估
+	ActivatedAt *time.Time `json:"activated_at"`                         // 最近一次激活时间，用于回滚到上一个生效版本
+
+	CreatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// TableName 自定义表名
+func (RuleVersion) TableName() string {
+	return "rule_versions"
+}
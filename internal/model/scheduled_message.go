@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+)
+
+// 定时消息状态
+const (
+	ScheduledPending   = "pending"   // 等待到期
+	ScheduledSending   = "sending"   // 已被某个节点领取，正在投递
+	ScheduledInbox     = "inbox"     // 到期时客户不在线，存入离线收件箱，客户上线后投递
+	ScheduledDelivered = "delivered" // 已推送给客户
+	ScheduledCancelled = "cancelled" // 已取消
+	ScheduledFailed    = "failed"    // 多次投递未完成，不再重试
+)
+
+// ScheduledMessage 定时发送给客户的消息，如“明天9点提醒我”
+type ScheduledMessage struct {
+	ID          uint       `gorm:"primary_key" json:"id"`
+	CustomerID  uint64     `gorm:"not null;index:idx_customer_status" json:"-"`
+	BotID       string     `gorm:"size:64;not null;default:''" json:"bot_id"`
+	Message     string     `gorm:"type:text;not null" json:"message"`
+	DueAt       time.Time  `gorm:"type:timestamp;not null;index:idx_status_due" json:"due_at"`
+	Status      string     `gorm:"size:16;not null;default:'pending';index:idx_status_due;index:idx_customer_status" json:"status"`
+	Attempts    int        `gorm:"not null;default:0" json:"-"`           // 被领取的次数
+	LockedBy    string     `gorm:"size:128;not null;default:''" json:"-"` // 领取任务的节点
+	LockedUntil *time.Time `gorm:"type:timestamp NULL" json:"-"`          // 领取租约到期时间，节点崩溃后由其他节点重新领取
+	DeliveredAt *time.Time `gorm:"type:timestamp NULL" json:"delivered_at"`
+
+	CreatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName 自定义表名
+func (ScheduledMessage) TableName() string {
+	return "scheduled_messages"
+}
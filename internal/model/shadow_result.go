@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+)
+
+// ShadowResult 影子评估记录：同一条消息在生产规则与候选规则下的处理结果
+type ShadowResult struct {
+	ID               uint   `gorm:"primary_key" json:"id"`
+	BotID            string `gorm:"size:64;not null;index:idx_bot_candidate" json:"bot_id"`
+	CandidateVersion int    `gorm:"not null;index:idx_bot_candidate" json:"candidate_version"`
+	ConversationID   uint   `gorm:"index;not null;default:0" json:"conversation_id"`
+	CustomerID       uint64 `gorm:"not null" json:"customer_id"`
+	Message          string `gorm:"type:text;not null" json:"message"`
+
+	Intent            string `gorm:"size:64;not null;default:''" json:"intent"`
+	CandidateIntent   string `gorm:"size:64;not null;default:''" json:"candidate_intent"`
+	Response          string `gorm:"type:text" json:"response"`
+	CandidateResponse string `gorm:"type:text" json:"candidate_response"`
+	Fallback          bool   `gorm:"not null;default:false" json:"fallback"`
+	CandidateFallback bool   `gorm:"not null;default:false" json:"candidate_fallback"`
+	Handoff           bool   `gorm:"not null;default:false" json:"handoff"`
+	CandidateHandoff  bool   `gorm:"not null;default:false" json:"candidate_handoff"`
+
+	IntentChanged   bool `gorm:"not null;default:false" json:"intent_changed"`
+	ResponseChanged bool `gorm:"not null;default:false" json:"response_changed"`
+
+	CreatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// TableName 自定义表名
+func (ShadowResult) TableName() string {
+	return "shadow_results"
+}
@@ -0,0 +1,36 @@
+package router
+
+import (
+	"gochat/internal/handler"
+	"gochat/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func initAdminRouter(r *gin.Engine) {
+	// 后台管理接口，需要声明 role=admin 的令牌
+	api := r.Group("/admin/", middleware.AdminAuthMiddleware())
+	{
+		api.GET("/faq", handler.ListFAQs)
+		api.GET("/faq/search", handler.SearchFAQs)
+		api.GET("/faq/:id", handler.GetFAQ)
+		api.POST("/faq", handler.CreateFAQ)
+		api.PUT("/faq/:id", handler.UpdateFAQ)
+		api.DELETE("/faq/:id", handler.DeleteFAQ)
+
+		// 规则版本管理
+		api.POST("/rules/validate", handler.ValidateRules)
+		api.GET("/rules/:bot/versions", handler.ListRuleVersions)
+		api.POST("/rules/:bot/versions", handler.UploadRuleVersion)
+		api.GET("/rules/:bot/versions/:version", handler.GetRuleVersion)
+		api.POST("/rules/:bot/versions/:version/activate", handler.ActivateRuleVersion)
+		api.GET("/rules/:bot/diff", handler.DiffRuleVersions)
+		api.POST("/rules/:bot/rollback", handler.RollbackRules)
+		api.GET("/rules/:bot/graph", handler.GetRulesGraph)
+
+		// 候选规则影子评估
+		api.POST("/rules/:bot/versions/:version/shadow", handler.StartShadow)
+		api.DELETE("/rules/:bot/shadow", handler.StopShadow)
+		api.GET("/rules/:bot/shadow/report", handler.GetShadowReport)
+	}
+}
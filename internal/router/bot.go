@@ -0,0 +1,17 @@
+package router
+
+import (
+	"gochat/internal/handler"
+	"gochat/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func initBotRouter(r *gin.Engine) {
+	// 机器人运营相关接口，试运行可读取任意客户的上下文，与后台管理接口相同需要由 auth.admin_key 签发的管理员令牌
+	api := r.Group("/bot/", middleware.AdminAuthMiddleware())
+	{
+		api.GET("/experiments/:group/report", handler.GetExperimentReport)
+		api.POST("/explain", handler.ExplainBot)
+	}
+}
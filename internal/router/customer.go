@@ -0,0 +1,20 @@
+package router
+
+import (
+	"gochat/internal/handler"
+	"gochat/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func initCustomerRouter(r *gin.Engine) {
+	// 客户自助接口，使用与 /ws 相同的令牌认证
+	api := r.Group("/customer/", middleware.JWTAuthMiddleware())
+	{
+		api.GET("/memory", handler.ListMemory)
+		api.DELETE("/memory", handler.ClearMemory)
+		api.DELETE("/memory/:key", handler.ClearMemory)
+		api.GET("/reminders", handler.ListReminders)
+		api.DELETE("/reminders/:id", handler.CancelReminder)
+	}
+}
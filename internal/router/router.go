@@ -12,6 +12,9 @@ func InitRouter(r *gin.Engine) {
 	// 如果有其他路由分组，请将ws路由放在最前面
 	initChatRouter(r)
 	initMessageRouter(r)
+	initBotRouter(r)
+	initCustomerRouter(r)
+	initAdminRouter(r)
 
 	// 添加健康检查路由
 	r.GET("/healthcheck", handler.HealthCheckHandler)
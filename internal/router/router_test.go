@@ -0,0 +1,61 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"gochat/internal/middleware"
+	"gochat/internal/router"
+	"gochat/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// adminRoutes 需要管理员令牌的接口，认证失败时不会执行处理函数
+var adminRoutes = []struct {
+	method string
+	path   string
+}{
+	{"GET", "/admin/faq"},
+	{"POST", "/admin/faq"},
+	{"DELETE", "/admin/faq/1"},
+	{"POST", "/admin/rules/validate"},
+	{"POST", "/admin/rules/default/versions"},
+	{"POST", "/admin/rules/default/versions/1/activate"},
+	{"POST", "/admin/rules/default/rollback"},
+	{"GET", "/admin/rules/default/graph"},
+	{"POST", "/admin/rules/default/versions/1/shadow"},
+	{"DELETE", "/admin/rules/default/shadow"},
+	{"GET", "/admin/rules/default/shadow/report"},
+	{"GET", "/bot/experiments/welcome_message/report"},
+	{"POST", "/bot/explain"},
+}
+
+func TestAdminRoutesRequireAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	router.InitRouter(r)
+
+	assert.NoError(t, middleware.SetAdminKey("fedcba9876543210"))
+	defer middleware.SetAdminKey("")
+	customerToken, _ := service.EncryptString("1")
+	forgedToken, _ := service.EncryptString("1?role=admin")
+	for _, route := range adminRoutes {
+		// 未携带令牌
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(route.method, route.path, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "%s %s", route.method, route.path)
+
+		// 普通客户令牌，以及用公开的客户令牌密钥伪造的管理员声明
+		for _, token := range []string{customerToken, forgedToken} {
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest(route.method, route.path+"?token="+url.QueryEscape(token), nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusForbidden, w.Code, "%s %s", route.method, route.path)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package chatbot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ActionContext 动作执行时可访问的会话信息
+type ActionContext struct {
+	BotID        string
+	BotName      string // 规则中的 metadata.bot_name
+	CustomerID   string
+	Customer     map[string]interface{} // 客户属性，与条件中的 user.* 相同
+	Slots        map[string]string      // 当前槽位，修改应通过 ActionResult.Slots 返回
+	Memory       map[string]string      // 客户长期记忆，配置的槽位变化后自动写入
+	Conversation *ConversationContext   // 会话上下文，内置的 back、reset 动作直接修改状态
+	DB           *gorm.DB               // 引擎未连接数据库时为 nil
+	Locale       string
+	Now          time.Time
+
+	engine *ChatBotEngine
+}
+
+// Render 替换 ${slot.xxx}、${user.xxx}、${memory.xxx}、${bot_name}、${timestamp} 占位符
+func (a *ActionContext) Render(tpl string) string {
+	return renderTemplate(tpl, map[string]interface{}{
+		"bot_name":  a.BotName,
+		"slot":      a.Slots,
+		"user":      a.Customer,
+		"memory":    a.Memory,
+		"timestamp": a.Now.Format(time.RFC3339),
+	})
+}
+
+// ActionResult 动作执行结果，按动作顺序合并到回复和会话上下文
+type ActionResult struct {
+	Text      string            // 非空时替换回复文本
+	Rich      []RichContent     // 追加到回复
+	Handoff   bool              // 转接人工
+	Slots     map[string]string // 写入会话槽位，值为空时删除该槽位
+	NextState string            // 覆盖转移的 next_state，设置后不再进入子对话
+}
+
+// ActionHandler 处理一种动作类型，返回错误时本轮回复兜底，错误码写入 error.code
+type ActionHandler interface {
+	Handle(actx *ActionContext, action Action) (ActionResult, error)
+}
+
+// ActionHandlerFunc 函数形式的 ActionHandler
+type ActionHandlerFunc func(actx *ActionContext, action Action) (ActionResult, error)
+
+// Handle 调用 f(actx, action)
+func (f ActionHandlerFunc) Handle(actx *ActionContext, action Action) (ActionResult, error) {
+	return f(actx, action)
+}
+
+// ActionError 携带错误码的动作错误，错误码供升级规则的 error.code 判断
+type ActionError struct {
+	Code int
+	Err  error
+}
+
+func (e *ActionError) Error() string {
+	return fmt.Sprintf("动作执行失败(%d): %v", e.Code, e.Err)
+}
+
+func (e *ActionError) Unwrap() error {
+	return e.Err
+}
+
+// actionErrorCode 未携带错误码的错误视为 500
+func actionErrorCode(err error) int {
+	var actionErr *ActionError
+	if errors.As(err, &actionErr) {
+		return actionErr.Code
+	}
+	return http.StatusInternalServerError
+}
+
+var actionHandlers = map[string]ActionHandler{}
+
+// RegisterActionHandler 注册动作类型，需在加载规则前完成；同名注册会覆盖内置动作
+func RegisterActionHandler(actionType string, handler ActionHandler) {
+	actionHandlers[actionType] = handler
+}
+
+// newActionContext 基于会话上下文构造动作执行上下文
+func (e *ChatBotEngine) newActionContext(ctx *ConversationContext) *ActionContext {
+	return &ActionContext{
+		BotID:        e.botID,
+		BotName:      e.rules.Metadata.BotName,
+		CustomerID:   ctx.CustomerID,
+		Customer:     ctx.User,
+		Slots:        ctx.Slots,
+		Memory:       ctx.Memory,
+		Conversation: ctx,
+		DB:           e.db,
+		Locale:       ctx.Locale,
+		Now:          e.now(),
+		engine:       e,
+	}
+}
+
+// 补充动作执行逻辑，返回的 bool 表示动作是否已切换状态
+func (e *ChatBotEngine) executeActions(actions []Action, ctx *ConversationContext) (Reply, bool) {
+	var (
+		reply        Reply
+		stateChanged bool
+	)
+	if len(actions) == 0 {
+		return reply, false
+	}
+	actx := e.newActionContext(ctx)
+	for _, action := range actions {
+		handler, ok := actionHandlers[action.Type]
+		if !ok {
+			log.Printf("动作类型未注册: %s", action.Type)
+			continue
+		}
+		if e.dryRun && !dryRunActions[action.Type] {
+			ctx.trace.action(ActionTrace{Type: action.Type, Skipped: true})
+			continue
+		}
+		result, err := handler.Handle(actx, action)
+		if err != nil {
+			log.Printf("动作 %s 执行失败: %v", action.Type, err)
+			ctx.trace.action(ActionTrace{Type: action.Type, Error: err.Error()})
+			ctx.LastErrorCode = actionErrorCode(err)
+			return e.fallback(ctx), true
+		}
+		ctx.trace.action(ActionTrace{Type: action.Type})
+
+		if result.Text != "" {
+			reply.Text = result.Text
+		}
+		reply.Rich = append(reply.Rich, result.Rich...)
+		reply.Handoff = reply.Handoff || result.Handoff
+		for key, value := range result.Slots {
+			if ctx.Slots == nil {
+				ctx.Slots = make(map[string]string)
+			}
+			if value == "" {
+				delete(ctx.Slots, key)
+			} else {
+				ctx.Slots[key] = value
+			}
+		}
+		if result.NextState != "" {
+			if e.rules.findState(result.NextState) == nil {
+				log.Printf("动作 %s 指定的状态不存在: %s", action.Type, result.NextState)
+			} else {
+				moveToState(ctx, result.NextState)
+				stateChanged = true
+			}
+		}
+		// 动作可能替换了槽位或客户属性，后续动作读取最新值
+		actx.Slots, actx.Customer = ctx.Slots, ctx.User
+	}
+	return reply, stateChanged
+}
@@ -0,0 +1,252 @@
+package chatbot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"gochat/internal/service/i18n"
+)
+
+// 内置动作类型，back、reset 见 dialogue.go
+const (
+	ActionResponse   = "response"    // 文本回复，可使用 A/B 实验分组
+	ActionRich       = "rich"        // 富媒体模板
+	ActionHandoff    = "handoff"     // 转接人工
+	ActionSetContext = "set_context" // 写入槽位
+	ActionCallAPI    = "call_api"    // 请求外部接口，响应写入槽位
+)
+
+// dryRunActions 试运行时执行的动作：只修改回复和上下文，不访问外部系统
+var dryRunActions = map[string]bool{
+	ActionResponse:   true,
+	ActionRich:       true,
+	ActionHandoff:    true,
+	ActionSetContext: true,
+	ActionBack:       true,
+	ActionReset:      true,
+}
+
+// noPreviousStep back 动作没有可返回的状态时的回复
+var noPreviousStep = map[string]string{
+	i18n.LocaleZhCN: "已经是第一步了，没有可以返回的上一步。",
+	i18n.LocaleEnUS: "You're already at the first step, there is nothing to go back to.",
+}
+
+// maxAPIResponseSize call_api 读取的响应体上限
+const maxAPIResponseSize = 64 << 10
+
+// CallAPIConfig call_api 动作的访问限制，对应 config.yaml 的 chatbot.call_api；
+// 规则可通过管理接口上传，接口地址必须在服务端配置的白名单内
+type CallAPIConfig struct {
+	// Allowed 允许请求的主机（api.example.com、api.example.com:8443）或基础地址（https://api.example.com/v1），为空时禁止所有请求
+	Allowed []string `mapstructure:"allowed"`
+	// AllowPrivate 允许连接回环、内网和链路本地地址，默认禁止，避免规则访问内部服务
+	AllowPrivate bool `mapstructure:"allow_private"`
+}
+
+var (
+	errEndpointNotAllowed = errors.New("接口地址不在 call_api 白名单内")
+	errPrivateAddress     = errors.New("禁止访问内网或回环地址")
+)
+
+var callAPIConfig atomic.Pointer[CallAPIConfig]
+
+// SetCallAPIConfig 设置 call_api 的访问限制，未设置时禁止所有请求；已建立的连接关闭后按新限制重新连接
+func SetCallAPIConfig(cfg CallAPIConfig) {
+	callAPIConfig.Store(&cfg)
+	apiClient.CloseIdleConnections()
+}
+
+func currentCallAPIConfig() CallAPIConfig {
+	if cfg := callAPIConfig.Load(); cfg != nil {
+		return *cfg
+	}
+	return CallAPIConfig{}
+}
+
+// allows 检查地址的协议和主机（或基础地址前缀）是否在白名单内
+func (c CallAPIConfig) allows(u *url.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	for _, entry := range c.Allowed {
+		if !strings.Contains(entry, "://") {
+			if strings.EqualFold(entry, u.Host) || strings.EqualFold(entry, u.Hostname()) {
+				return true
+			}
+			continue
+		}
+		base, err := url.Parse(entry)
+		if err != nil || base.Scheme != u.Scheme || !strings.EqualFold(base.Host, u.Host) {
+			continue
+		}
+		prefix := strings.TrimSuffix(base.Path, "/")
+		if u.Path == prefix || strings.HasPrefix(u.Path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// publicIP 是否为公网地址
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// apiClient call_api 使用的HTTP客户端：建立连接时按解析后的地址拒绝内网和回环地址，重定向目标同样需要在白名单内
+var apiClient = &http.Client{
+	Timeout: 5 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 3 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				if currentCallAPIConfig().AllowPrivate {
+					return nil
+				}
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+					return fmt.Errorf("%w: %s", errPrivateAddress, host)
+				}
+				return nil
+			},
+		}).DialContext,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return errors.New("重定向次数过多")
+		}
+		if !currentCallAPIConfig().allows(req.URL) {
+			return fmt.Errorf("%w: %s", errEndpointNotAllowed, req.URL.Redacted())
+		}
+		return nil
+	},
+}
+
+func init() {
+	RegisterActionHandler(ActionResponse, ActionHandlerFunc(respondAction))
+	RegisterActionHandler(ActionRich, ActionHandlerFunc(richAction))
+	RegisterActionHandler(ActionHandoff, ActionHandlerFunc(handoffAction))
+	RegisterActionHandler(ActionSetContext, ActionHandlerFunc(setContextAction))
+	RegisterActionHandler(ActionBack, ActionHandlerFunc(backAction))
+	RegisterActionHandler(ActionReset, ActionHandlerFunc(resetAction))
+	RegisterActionHandler(ActionCallAPI, ActionHandlerFunc(callAPIAction))
+}
+
+func respondAction(actx *ActionContext, action Action) (ActionResult, error) {
+	text := i18n.Pick(action.I18n, actx.Locale, action.Content)
+	if action.VariantGroup != "" {
+		if content, ok := actx.engine.variantContent(action.VariantGroup, actx.Conversation); ok {
+			text = content
+		}
+	}
+	return ActionResult{Text: actx.Render(text)}, nil
+}
+
+func richAction(actx *ActionContext, action Action) (ActionResult, error) {
+	var result ActionResult
+	if action.Content != "" {
+		result.Text = i18n.Pick(action.I18n, actx.Locale, action.Content)
+	}
+	if rich, ok := actx.engine.rules.findRichTemplate(action.Template); ok {
+		result.Rich = append(result.Rich, rich)
+	} else {
+		log.Printf("富媒体模板不存在: %s", action.Template)
+	}
+	return result, nil
+}
+
+func handoffAction(actx *ActionContext, action Action) (ActionResult, error) {
+	return ActionResult{Handoff: true}, nil
+}
+
+// setContextAction 写入 key 槽位，值取 value，兼容旧配置的 params.value
+func setContextAction(actx *ActionContext, action Action) (ActionResult, error) {
+	if action.Key == "" {
+		log.Printf("set_context 缺少 key: %v", action)
+		return ActionResult{}, nil
+	}
+	value := action.Value
+	if raw, ok := action.Params["value"]; value == "" && ok {
+		value = fmt.Sprint(raw)
+	}
+	return ActionResult{Slots: map[string]string{action.Key: actx.Render(value)}}, nil
+}
+
+// backAction 返回上一步，content 为返回后的回复；没有上一步时回复 noPreviousStep
+func backAction(actx *ActionContext, action Action) (ActionResult, error) {
+	if !goBack(actx.Conversation) {
+		return ActionResult{Text: i18n.Pick(noPreviousStep, actx.Locale, noPreviousStep[i18n.DefaultLocale])}, nil
+	}
+	var result ActionResult
+	if action.Content != "" {
+		result.Text = i18n.Pick(action.I18n, actx.Locale, action.Content)
+	}
+	result.NextState = actx.Conversation.CurrentState
+	return result, nil
+}
+
+func resetAction(actx *ActionContext, action Action) (ActionResult, error) {
+	resetDialogue(actx.Conversation)
+	return ActionResult{NextState: actx.Conversation.CurrentState}, nil
+}
+
+// callAPIAction 以 GET 请求 endpoint，params 渲染后作为查询参数，响应体写入 result_key 槽位；
+// 地址不在白名单内或解析为内网地址时错误码为 403，请求失败的错误码为 503，非 2xx 响应使用其状态码
+func callAPIAction(actx *ActionContext, action Action) (ActionResult, error) {
+	if action.Endpoint == "" {
+		return ActionResult{}, fmt.Errorf("call_api 缺少 endpoint")
+	}
+	if u, err := url.Parse(action.Endpoint); err != nil || !currentCallAPIConfig().allows(u) {
+		return ActionResult{}, &ActionError{Code: http.StatusForbidden, Err: fmt.Errorf("%w: %s", errEndpointNotAllowed, action.Endpoint)}
+	}
+	endpoint := action.Endpoint
+	if len(action.Params) > 0 {
+		query := url.Values{}
+		for key, value := range action.Params {
+			query.Set(key, actx.Render(fmt.Sprint(value)))
+		}
+		separator := "?"
+		if strings.Contains(endpoint, "?") {
+			separator = "&"
+		}
+		endpoint += separator + query.Encode()
+	}
+
+	resp, err := apiClient.Get(endpoint)
+	if errors.Is(err, errPrivateAddress) || errors.Is(err, errEndpointNotAllowed) {
+		return ActionResult{}, &ActionError{Code: http.StatusForbidden, Err: err}
+	}
+	if err != nil {
+		return ActionResult{}, &ActionError{Code: http.StatusServiceUnavailable, Err: err}
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxAPIResponseSize))
+	if err != nil {
+		return ActionResult{}, &ActionError{Code: http.StatusServiceUnavailable, Err: err}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return ActionResult{}, &ActionError{Code: resp.StatusCode, Err: fmt.Errorf("%s 返回 %s", action.Endpoint, resp.Status)}
+	}
+
+	var result ActionResult
+	if action.ResultKey != "" {
+		result.Slots = map[string]string{action.ResultKey: string(body)}
+	}
+	return result, nil
+}
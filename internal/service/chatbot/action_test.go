@@ -0,0 +1,176 @@
+package chatbot_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gochat/internal/service/chatbot"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const actionRules = `
+intent_detection:
+  regex_patterns:
+    - intent: "order_query"
+      patterns: ["订单"]
+    - intent: "refund"
+      patterns: ["退款"]
+context_management:
+  entity_extraction:
+    extractors: ["order_number"]
+dialogue_flow:
+  global_transitions:
+    - intent: "refund"
+      actions:
+        - type: "call_api"
+          endpoint: "%s"
+          params:
+            order: "${slot.order_number}"
+  states:
+    - name: "welcome"
+      transitions:
+        - intent: "order_query"
+          next_state: "welcome"
+          actions:
+            - type: "response"
+              content: "正在查询"
+            - type: "test_order_lookup"
+    - name: "order_detail"
+error_handling:
+  default_fallback: "抱歉，我没有理解。"
+  escalation_rules:
+    - condition: "${error.code == 503}"
+      action: "redirect_to_human"
+  escalation_message: "正在为您转接人工客服。"
+`
+
+func TestCustomActionHandler(t *testing.T) {
+	var lookups []string
+	chatbot.RegisterActionHandler("test_order_lookup", chatbot.ActionHandlerFunc(
+		func(actx *chatbot.ActionContext, action chatbot.Action) (chatbot.ActionResult, error) {
+			lookups = append(lookups, actx.CustomerID+":"+actx.Slots["order_number"])
+			return chatbot.ActionResult{
+				Text:      actx.Render("订单 ${slot.order_number} 已发货"),
+				Slots:     map[string]string{"order_status": "shipped"},
+				NextState: "order_detail",
+			}, nil
+		}))
+
+	var refunds []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refunds = append(refunds, r.URL.Query().Get("order"))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	chatbot.SetCallAPIConfig(chatbot.CallAPIConfig{Allowed: []string{server.URL}, AllowPrivate: true})
+	defer chatbot.SetCallAPIConfig(chatbot.CallAPIConfig{})
+
+	rules, err := chatbot.ParseChatBotRules([]byte(fmt.Sprintf(actionRules, server.URL)))
+	assert.NoError(t, err)
+	assert.NoError(t, rules.Validate())
+
+	engine := chatbot.NewChatBotEngineWithRules(nil, rules)
+	engine.SetCustomerLoader(nil)
+
+	reply := engine.Respond("4001", "查一下订单 SO20240101001")
+	assert.Equal(t, "订单 SO20240101001 已发货", reply.Text)
+	assert.Equal(t, []string{"4001:SO20240101001"}, lookups)
+	ctx := engine.GetContext("4001")
+	assert.Equal(t, "order_detail", ctx.CurrentState)
+	assert.Equal(t, "shipped", ctx.Slots["order_status"])
+
+	// 接口返回 503 时兜底，并按 error.code 升级转人工
+	reply = engine.Respond("4001", "我要退款")
+	assert.Equal(t, []string{"SO20240101001"}, refunds)
+	assert.True(t, reply.Escalated)
+	assert.True(t, reply.Handoff)
+	assert.Equal(t, 0, engine.GetContext("4001").LastErrorCode)
+
+	// 之后普通的兜底不再按上一轮的错误码升级
+	reply = engine.Respond("4001", "今天星期几")
+	assert.True(t, reply.Fallback)
+	assert.False(t, reply.Escalated)
+	assert.False(t, reply.Handoff)
+}
+
+func TestCallAPIRestrictions(t *testing.T) {
+	var hits []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits = append(hits, r.URL.Path)
+		if r.URL.Path == "/api/redirect" {
+			http.Redirect(w, r, "http://localhost:1/internal", http.StatusFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	defer chatbot.SetCallAPIConfig(chatbot.CallAPIConfig{})
+
+	call := func(endpoint string) (int, string) {
+		rules, err := chatbot.ParseChatBotRules([]byte(`
+intent_detection:
+  regex_patterns:
+    - intent: "refund"
+      patterns: ["退款"]
+dialogue_flow:
+  states:
+    - name: "welcome"
+      transitions:
+        - intent: "refund"
+          actions:
+            - type: "call_api"
+              endpoint: "` + endpoint + `"
+              result_key: "refund"
+error_handling:
+  default_fallback: "抱歉，我没有理解。"
+`))
+		assert.NoError(t, err)
+		engine := chatbot.NewChatBotEngineWithRules(nil, rules)
+		engine.SetCustomerLoader(nil)
+		engine.Respond("4101", "我要退款")
+		ctx := engine.GetContext("4101")
+		return ctx.LastErrorCode, ctx.Slots["refund"]
+	}
+
+	// 未配置白名单时禁止所有请求
+	code, _ := call(server.URL + "/api/refund")
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// 基础地址按路径前缀匹配
+	chatbot.SetCallAPIConfig(chatbot.CallAPIConfig{Allowed: []string{server.URL + "/api"}, AllowPrivate: true})
+	code, body := call(server.URL + "/api/refund")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "ok", body)
+	code, _ = call(server.URL + "/apix")
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = call("file:///etc/passwd")
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// 重定向目标不在白名单内
+	code, _ = call(server.URL + "/api/redirect")
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// 主机在白名单内，但解析为回环地址时拒绝连接
+	chatbot.SetCallAPIConfig(chatbot.CallAPIConfig{Allowed: []string{"127.0.0.1"}})
+	code, _ = call(server.URL + "/api/refund")
+	assert.Equal(t, http.StatusForbidden, code)
+
+	assert.Equal(t, []string{"/api/refund", "/api/redirect"}, hits)
+}
+
+func TestUnknownActionTypeRejected(t *testing.T) {
+	rules, err := chatbot.ParseChatBotRules([]byte(`
+dialogue_flow:
+  states:
+    - name: "welcome"
+      transitions:
+        - intent: "greeting"
+          actions:
+            - type: "no_such_action"
+`))
+	assert.NoError(t, err)
+	assert.ErrorContains(t, rules.Validate(), "动作类型 no_such_action 未注册")
+}
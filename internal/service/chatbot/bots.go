@@ -0,0 +1,179 @@
+package chatbot
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// DefaultBotID 未配置多机器人时使用的机器人ID
+const DefaultBotID = "default"
+
+// 机器人路由来源
+const (
+	BotSourceQuery    = "query"    // 连接参数，如 /ws?bot=sales
+	BotSourceToken    = "token"    // 令牌声明
+	BotSourceCustomer = "customer" // 客户属性 customers.bot_id
+)
+
+// BotConfig 单个机器人的规则文件配置
+type BotConfig struct {
+	ID    string `mapstructure:"id"`
+	Rules string `mapstructure:"rules"` // 规则文件路径
+}
+
+// RoutingPolicy 按来源顺序为连接选择机器人，均未命中或机器人不存在时使用默认机器人
+type RoutingPolicy struct {
+	Order      []string `mapstructure:"order"`       // 来源顺序，默认 query → token → customer
+	QueryParam string   `mapstructure:"query_param"` // 连接参数名，默认 bot
+	TokenClaim string   `mapstructure:"token_claim"` // 令牌声明名，默认 bot
+}
+
+// BotsConfig 多机器人配置，对应 config.yaml 的 chatbot 节点
+type BotsConfig struct {
+	DefaultBot string        `mapstructure:"default_bot"`
+	Bots       []BotConfig   `mapstructure:"bots"`
+	Routing    RoutingPolicy `mapstructure:"routing"`
+}
+
+// BotRegistry 并存加载的多套规则，每个机器人共享一个引擎；规则可在运行时替换，
+// 新连接使用按新规则编译的引擎，已建立的连接继续使用原引擎，客户上下文在两者间共享
+type BotRegistry struct {
+	mu         sync.RWMutex
+	rules      map[string]ChatBotRules
+	engines    map[string]*ChatBotEngine  // 按当前规则编译的引擎，首次使用时创建
+	contexts   map[string]*contextStore   // 客户上下文，规则替换后保留
+	candidates map[string]shadowCandidate // 影子评估中的候选规则
+	defaultID  string
+	routing    RoutingPolicy
+}
+
+// DefaultRegistry 服务启动时加载的机器人，为 nil 时使用单一规则文件
+var DefaultRegistry *BotRegistry
+
+// LoadBotRegistry 加载所有机器人的规则文件，任一文件无效时返回错误
+func LoadBotRegistry(config BotsConfig) (*BotRegistry, error) {
+	registry := NewBotRegistry(config.DefaultBot, config.Routing)
+	for _, bot := range config.Bots {
+		if bot.ID == "" {
+			return nil, fmt.Errorf("机器人ID不能为空: %s", bot.Rules)
+		}
+		rules, err := LoadChatBotRulesFromFile(bot.Rules)
+		if err != nil {
+			return nil, fmt.Errorf("机器人 %s 规则加载失败: %w", bot.ID, err)
+		}
+		registry.Register(bot.ID, rules)
+	}
+	if _, ok := registry.Rules(registry.defaultID); !ok {
+		return nil, fmt.Errorf("默认机器人 %s 未配置", registry.defaultID)
+	}
+	return registry, nil
+}
+
+// NewBotRegistry 创建空的机器人注册表
+func NewBotRegistry(defaultID string, routing RoutingPolicy) *BotRegistry {
+	if defaultID == "" {
+		defaultID = DefaultBotID
+	}
+	if len(routing.Order) == 0 {
+		routing.Order = []string{BotSourceQuery, BotSourceToken, BotSourceCustomer}
+	}
+	if routing.QueryParam == "" {
+		routing.QueryParam = "bot"
+	}
+	if routing.TokenClaim == "" {
+		routing.TokenClaim = "bot"
+	}
+	return &BotRegistry{
+		rules:      make(map[string]ChatBotRules),
+		engines:    make(map[string]*ChatBotEngine),
+		contexts:   make(map[string]*contextStore),
+		candidates: make(map[string]shadowCandidate),
+		defaultID:  defaultID,
+		routing:    routing,
+	}
+}
+
+// Register 注册或替换机器人的规则，之后获取的引擎使用新规则
+func (r *BotRegistry) Register(id string, rules ChatBotRules) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules[id] = rules
+	delete(r.engines, id)
+}
+
+// Rules 返回机器人当前的规则
+func (r *BotRegistry) Rules(id string) (ChatBotRules, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rules, ok := r.rules[id]
+	return rules, ok
+}
+
+// IDs 返回所有机器人ID
+func (r *BotRegistry) IDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]string, 0, len(r.rules))
+	for id := range r.rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Routing 返回路由策略
+func (r *BotRegistry) Routing() RoutingPolicy {
+	return r.routing
+}
+
+// Select 按路由顺序选择第一个已注册的机器人，candidates 的 key 为来源
+func (r *BotRegistry) Select(candidates map[string]string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, source := range r.routing.Order {
+		if id := candidates[source]; id != "" {
+			if _, ok := r.rules[id]; ok {
+				return id
+			}
+		}
+	}
+	return r.defaultID
+}
+
+// Engine 返回机器人共享的引擎，机器人不存在时使用默认机器人；
+// 引擎在首次获取时按当前规则编译，db 应为进程内同一个连接池
+func (r *BotRegistry) Engine(db *gorm.DB, botID string) *ChatBotEngine {
+	r.mu.RLock()
+	if _, ok := r.rules[botID]; !ok {
+		botID = r.defaultID
+	}
+	engine, ok := r.engines[botID]
+	r.mu.RUnlock()
+	if ok {
+		return engine
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if engine, ok := r.engines[botID]; ok {
+		return engine
+	}
+	contexts, ok := r.contexts[botID]
+	if !ok {
+		contexts = newContextStore()
+		r.contexts[botID] = contexts
+	}
+	engine = NewChatBotEngineWithRules(db, r.rules[botID])
+	engine.botID = botID
+	engine.contexts = contexts
+	r.engines[botID] = engine
+	return engine
+}
+
+// BotID 返回引擎所属的机器人ID
+func (e *ChatBotEngine) BotID() string {
+	return e.botID
+}
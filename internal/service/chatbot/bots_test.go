@@ -0,0 +1,43 @@
+package chatbot_test
+
+import (
+	"testing"
+	"time"
+
+	"gochat/internal/service/chatbot"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBotRegistry(t *testing.T) {
+	registry, err := chatbot.LoadBotRegistry(chatbot.BotsConfig{
+		Bots: []chatbot.BotConfig{
+			{ID: "default", Rules: "config/chatbot_rules.yml"},
+			{ID: "sales", Rules: "../../../config/bots/sales.yml"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"default", "sales"}, registry.IDs())
+
+	// 按 query → token → customer 的顺序选择已注册的机器人
+	assert.Equal(t, "sales", registry.Select(map[string]string{chatbot.BotSourceQuery: "sales", chatbot.BotSourceCustomer: "default"}))
+	assert.Equal(t, "sales", registry.Select(map[string]string{chatbot.BotSourceQuery: "unknown", chatbot.BotSourceToken: "sales"}))
+	assert.Equal(t, "default", registry.Select(map[string]string{chatbot.BotSourceQuery: "billing"}))
+	assert.Equal(t, "default", registry.Select(nil))
+
+	sales := registry.Engine(nil, "sales")
+	sales.SetClock(func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) })
+	assert.Equal(t, "sales", sales.BotID())
+	reply := sales.Respond("5001", "这款多少钱")
+	assert.Equal(t, "price_query", reply.Intent)
+	assert.Contains(t, reply.Text, "销售顾问")
+
+	// 未注册的机器人使用默认规则
+	assert.Equal(t, "default", registry.Engine(nil, "billing").BotID())
+
+	_, err = chatbot.LoadBotRegistry(chatbot.BotsConfig{
+		DefaultBot: "billing",
+		Bots:       []chatbot.BotConfig{{ID: "sales", Rules: "../../../config/bots/sales.yml"}},
+	})
+	assert.ErrorContains(t, err, "billing")
+}
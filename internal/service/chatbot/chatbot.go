@@ -3,10 +3,15 @@ package chatbot
 import (
 	"fmt"
 	"log"
-	"regexp"
-	"strings"
+	"os"
 	"time"
 
+	"gochat/internal/service/event"
+	"gochat/internal/service/faq"
+	"gochat/internal/service/i18n"
+	"gochat/internal/service/llm"
+	"gochat/internal/service/reminder"
+
 	"github.com/spf13/viper"
 	"gorm.io/gorm"
 )
@@ -14,11 +19,15 @@ import (
 // 新增配置文件对应结构体
 type ChatBotRules struct {
 	Metadata struct { // 新增元数据字段
-		BotName string `mapstructure:"bot_name"`
-		Version string `mapstructure:"version"`
+		BotName     string `mapstructure:"bot_name"`
+		Version     string `mapstructure:"version"`
+		DefaultLang string `mapstructure:"default_lang"`
 	} `mapstructure:"metadata"`
 
 	IntentDetection struct {
+		Normalization NormalizationConfig `mapstructure:"normalization"` // 意图识别前的文本归一化
+		Clarification ClarificationConfig `mapstructure:"clarification"` // 候选意图接近或置信度不足时追问
+
 		RegexPatterns []struct {
 			Intent        string   `mapstructure:"intent"`
 			Patterns      []string `mapstructure:"patterns"`
@@ -30,19 +39,87 @@ type ChatBotRules struct {
 			Path      string  `mapstructure:"path"`
 			Threshold float64 `mapstructure:"threshold"`
 		} `mapstructure:"ml_model"` // 添加字段标签
+
+		KeywordMatching struct {
+			Threshold float64       `mapstructure:"threshold"`
+			Keywords  []KeywordRule `mapstructure:"keywords"`
+		} `mapstructure:"keyword_matching"`
+
+		// 按钮回传的 payload 与意图的映射，命中后跳过意图识别
+		Postbacks []struct {
+			Payload string `mapstructure:"payload"`
+			Intent  string `mapstructure:"intent"`
+		} `mapstructure:"postbacks"`
 	} `mapstructure:"intent_detection"` // 添加字段标签
 
+	ContextManagement struct {
+		EntityExtraction EntityConfig `mapstructure:"entity_extraction"` // 意图识别前抽取实体并填充槽位
+		Memory           MemoryConfig `mapstructure:"memory"`            // 跨会话保留的客户长期记忆
+	} `mapstructure:"context_management"`
+
 	DialogueFlow struct {
+		// 全局转移：在任意状态下生效，当前状态未定义同名意图时使用
+		GlobalTransitions []Transition `mapstructure:"global_transitions"`
+
+		// 客户沉默跟进的默认规则，状态配置了 inactivity 时使用状态的规则
+		Inactivity InactivityConfig `mapstructure:"inactivity"`
+
 		States []struct {
-			Name         string       `mapstructure:"name"`
-			Transitions  []Transition `mapstructure:"transitions"`
-			EntryActions []Action     `mapstructure:"entry_actions"` // 新增字段
+			Name         string           `mapstructure:"name"`
+			Transitions  []Transition     `mapstructure:"transitions"`
+			EntryActions []Action         `mapstructure:"entry_actions"` // 新增字段
+			Inactivity   InactivityConfig `mapstructure:"inactivity"`    // 在该状态沉默时的跟进规则
 		} `mapstructure:"states"`
 	} `mapstructure:"dialogue_flow"` // 添加字段标签
 
+	ResponseTemplates struct {
+		RichContent []RichContent `mapstructure:"rich_content"`
+	} `mapstructure:"response_templates"`
+
+	Personalization struct {
+		Timezone     string `mapstructure:"timezone"` // 时间规则使用的时区，如 Asia/Shanghai
+		UserSegments []struct {
+			Name             string           `mapstructure:"name"`
+			Condition        string           `mapstructure:"condition"`
+			ResponseModifier ResponseModifier `mapstructure:"response_modifier"`
+		} `mapstructure:"user_segments"`
+		TimeBasedRules []struct {
+			TimeRange        string           `mapstructure:"time_range"`
+			ResponseSuffix   string           `mapstructure:"response_suffix"`
+			ResponseModifier ResponseModifier `mapstructure:"response_modifier"`
+		} `mapstructure:"time_based_rules"`
+	} `mapstructure:"personalization"`
+
+	Experimental struct {
+		VariantGroups []VariantGroup `mapstructure:"variant_groups"`
+	} `mapstructure:"experimental"`
+
 	ErrorHandling struct {
-		DefaultFallback string `mapstructure:"default_fallback"`
+		DefaultFallback     string            `mapstructure:"default_fallback"`
+		DefaultFallbackI18n map[string]string `mapstructure:"default_fallback_i18n"` // 多语言兜底回复，key为语言
+
+		FallbackTemplates []FallbackTemplate `mapstructure:"fallback_templates"` // 连续兜底时轮换的话术
+		RephraseAfter     int                `mapstructure:"rephrase_after"`     // 连续兜底超过该次数后换用 fallback_templates
+
+		EscalationRules       []EscalationRule  `mapstructure:"escalation_rules"`
+		EscalationMessage     string            `mapstructure:"escalation_message"`
+		EscalationMessageI18n map[string]string `mapstructure:"escalation_message_i18n"`
+
+		RetryPolicy struct {
+			MaxAttempts int `mapstructure:"max_attempts"` // 连续兜底超过该次数后升级转人工
+		} `mapstructure:"retry_policy"`
 	} `mapstructure:"error_handling"`
+
+	FAQ FAQConfig `mapstructure:"faq"` // 未识别意图时检索常见问题
+
+	GenerativeFallback GenerativeFallbackConfig `mapstructure:"generative_fallback"` // 常见问题也未命中时调用生成式模型
+}
+
+// KeywordRule 关键词匹配规则
+type KeywordRule struct {
+	Intent      string   `mapstructure:"intent"`
+	Words       []string `mapstructure:"words"`
+	MaxDistance int      `mapstructure:"max_distance"` // 英文单词允许的最大编辑距离
 }
 
 type State struct {
@@ -54,14 +131,23 @@ type Transition struct {
 	Intent    string   `mapstructure:"intent"`
 	NextState string   `mapstructure:"next_state"` // 新增此字段
 	Actions   []Action `mapstructure:"actions"`
+	Push      bool     `mapstructure:"push"`      // 进入子对话，结束后返回当前状态
+	Condition string   `mapstructure:"condition"` // 可选条件，如 "${slot.city}"，可使用 user.*、slot.*、memory.*；同一意图按顺序取第一个满足条件的转移
 }
 
 type Action struct {
-	Type    string                 `mapstructure:"type"`
-	Content string                 `mapstructure:"content"`
-	Key     string                 `mapstructure:"key"`
-	Value   string                 `mapstructure:"value"`
-	Params  map[string]interface{} `mapstructure:"params"`
+	Type     string                 `mapstructure:"type"`
+	Content  string                 `mapstructure:"content"`
+	Key      string                 `mapstructure:"key"`
+	Value    string                 `mapstructure:"value"`
+	Params   map[string]interface{} `mapstructure:"params"`
+	I18n     map[string]string      `mapstructure:"i18n"`     // 多语言回复，key为语言，如 en-US
+	Template string                 `mapstructure:"template"` // rich 动作引用的富媒体模板名
+
+	VariantGroup string `mapstructure:"variant_group"` // response 动作使用 A/B 实验分组中的版本内容
+
+	Endpoint  string `mapstructure:"endpoint"`   // call_api 请求的接口地址
+	ResultKey string `mapstructure:"result_key"` // call_api 响应写入的槽位
 }
 
 // 新增查找状态的辅助方法
@@ -77,61 +163,151 @@ func (r *ChatBotRules) findState(name string) *State {
 	return nil
 }
 
+// ChatBotEngine 对话引擎，编译后的规则只读，同一机器人的所有连接共享；Set* 方法需在处理消息前调用
 type ChatBotEngine struct {
-	db         *gorm.DB
-	rules      ChatBotRules
-	contextMap map[string]ConversationContext // key: customerID
+	botID       string
+	db          *gorm.DB
+	rules       ChatBotRules
+	stages      []classifierStage
+	normalizers []Normalizer
+	extractors  []EntityExtractor
+	contexts    *contextStore // key: customerID，同一机器人的各个规则版本共享
+
+	loadCustomer CustomerLoader
+	location     *time.Location
+	now          func() time.Time
+	bus          *event.Bus
+	faqIndex     *faq.Index
+	generator    llm.ResponseGenerator
+	loadHistory  HistoryLoader
+	memory       MemoryStore
+	scheduler    reminder.Scheduler
+	dryRun       bool // 试运行，跳过有副作用的动作
+}
+
+// classifierStage 意图识别阶段：置信度达到阈值时采用该分类器的结果
+type classifierStage struct {
+	classifier IntentClassifier
+	threshold  float64
+	scored     bool // 置信度可比较，候选接近时追问；正则按规则顺序取首个命中
 }
 
 type ConversationContext struct {
+	CustomerID   string
 	CurrentState string
 	Slots        map[string]string
+	Locale       string                 // 会话语言，为空时根据首条消息检测
+	User         map[string]interface{} // 客户属性，会话创建时加载
+	Memory       map[string]string      // 客户长期记忆，会话创建时加载，跨会话保留
+	Variants     map[string]string      // A/B 实验分组 → 版本
 	LastActive   time.Time
+
+	Entities []Entity // 本轮消息抽取的实体
+
+	StateStack []string // 子对话入口状态栈
+	History    []string // 状态历史，用于返回上一步
+
+	Clarification []string // 待客户选择的候选意图，下一条消息优先按选项解析
+
+	FallbackCount int       // 连续兜底次数，成功匹配后清零
+	Nudges        int       // 连续沉默跟进次数，客户发送消息后清零
+	LastNudge     time.Time // 最近一次沉默跟进的时间
+	LastErrorCode int       // 本轮动作执行的错误码，供升级规则判断；每轮开始和升级后清零
+
+	trace *Trace // 处理中的消息的决策记录
 }
 
-// 初始化聊天机器人
-// 在ChatBotEngine结构体下补充缺失的方法
-func (e *ChatBotEngine) getOrCreateContext(customerID string) ConversationContext {
-	if ctx, exists := e.contextMap[customerID]; exists {
-		return ctx
-	}
-	return ConversationContext{
+// newContext 创建客户的初始上下文
+func (e *ChatBotEngine) newContext(customerID string) ConversationContext {
+	ctx := ConversationContext{
+		CustomerID:   customerID,
 		CurrentState: "welcome",
 		Slots:        make(map[string]string),
-		LastActive:   time.Now(),
+		User:         map[string]interface{}{},
+		Variants:     e.assignVariants(customerID),
+		LastActive:   e.now(),
+	}
+	if e.loadCustomer != nil {
+		ctx.User = e.loadCustomer(customerID)
 	}
+	e.restoreMemory(&ctx)
+	return ctx
 }
 
-func (e *ChatBotEngine) saveContext(customerID string, ctx ConversationContext) {
-	e.contextMap[customerID] = ctx
+// withContext 在客户锁内处理上下文，处理结束后的修改对后续消息可见
+func (e *ChatBotEngine) withContext(customerID string, fn func(ctx *ConversationContext)) {
+	e.contexts.update(customerID, func() ConversationContext { return e.newContext(customerID) }, fn)
 }
 
-// 补充动作执行逻辑
-func (e *ChatBotEngine) executeActions(actions []Action, ctx *ConversationContext) string {
-	var response string
-	for _, action := range actions {
-		switch action.Type {
-		case "response":
-			response = action.Content // 简单实现，实际需要模板渲染
-		case "set_context":
+// OpenSession 客户建立连接；引擎在连接间共享，连接期间保留客户的上下文
+func (e *ChatBotEngine) OpenSession(customerID string) {
+	e.contexts.retain(customerID)
+}
 
-			if action.Key != "" {
-				ctx.Slots[action.Key] = fmt.Sprintf("%v", action.Params["value"])
-			} else {
-				log.Printf("debug executeActions: %s, %v", "a", action)
-			}
-		}
-	}
-	return response
+// CloseSession 客户断开连接，该客户的连接均已关闭时释放上下文
+func (e *ChatBotEngine) CloseSession(customerID string) {
+	e.contexts.release(customerID)
 }
 
 // 修改初始化方法加载配置
 func NewChatBotEngine(db *gorm.DB) *ChatBotEngine {
-	return &ChatBotEngine{
-		db:         db,
-		rules:      LoadChatBotRules(), // 加载配置
-		contextMap: make(map[string]ConversationContext),
+	return NewChatBotEngineWithRules(db, LoadChatBotRules()) // 加载配置
+}
+
+// NewChatBotEngineWithRules 使用已加载的规则初始化聊天机器人
+func NewChatBotEngineWithRules(db *gorm.DB, rules ChatBotRules) *ChatBotEngine {
+	engine := &ChatBotEngine{
+		botID:       DefaultBotID,
+		db:          db,
+		rules:       rules,
+		stages:      newClassifierStages(rules),
+		normalizers: loadNormalizers(rules.IntentDetection.Normalization),
+		extractors:  loadEntityExtractors(rules.ContextManagement.EntityExtraction),
+		contexts:    newContextStore(),
+		location:    loadLocation(rules.Personalization.Timezone),
+		now:         time.Now,
+		bus:         event.Default,
+		faqIndex:    faq.Default,
+		generator:   llm.Default,
+		scheduler:   reminder.Default,
+	}
+	if db != nil {
+		engine.loadCustomer = newDBCustomerLoader(db)
+		engine.loadHistory = newDBHistoryLoader(db)
+		engine.memory = newDBMemoryStore(db)
+	}
+	return engine
+}
+
+// newClassifierStages 按 统计模型 → 正则 → 关键词 的顺序组装意图识别阶段
+func newClassifierStages(rules ChatBotRules) []classifierStage {
+	var stages []classifierStage
+
+	mlModel := rules.IntentDetection.MLModel
+	if mlModel.Path != "" {
+		model, err := LoadNaiveBayesClassifier(mlModel.Path)
+		if err == nil {
+			stages = append(stages, classifierStage{classifier: model, threshold: mlModel.Threshold, scored: true})
+		} else if !os.IsNotExist(err) {
+			log.Printf("意图模型加载失败，仅使用规则匹配: %v", err)
+		}
+	}
+
+	regexClassifier, err := NewRegexClassifier(rules)
+	if err != nil {
+		log.Printf("正则规则编译失败: %v", err)
+	} else {
+		stages = append(stages, classifierStage{classifier: regexClassifier})
 	}
+
+	if len(rules.IntentDetection.KeywordMatching.Keywords) > 0 {
+		stages = append(stages, classifierStage{
+			classifier: NewKeywordClassifier(rules),
+			threshold:  rules.IntentDetection.KeywordMatching.Threshold,
+			scored:     true,
+		})
+	}
+	return stages
 }
 
 // 在LoadChatBotRules函数中添加调试日志
@@ -149,75 +325,199 @@ func LoadChatBotRules() ChatBotRules {
 	if err := viper.Unmarshal(&rules); err != nil {
 		log.Fatalf("配置解析失败: %v", err)
 	}
+	if err := rules.Validate(); err != nil {
+		log.Fatalf("配置校验失败: %v", err)
+	}
 
 	return rules
 }
 
+// LoadChatBotRulesFromFile 从指定文件加载并校验规则，不影响全局viper配置
+func LoadChatBotRulesFromFile(path string) (ChatBotRules, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+
+	var rules ChatBotRules
+	if err := v.ReadInConfig(); err != nil {
+		return rules, fmt.Errorf("配置文件加载失败: %w", err)
+	}
+	if err := v.Unmarshal(&rules); err != nil {
+		return rules, fmt.Errorf("配置解析失败: %w", err)
+	}
+	if err := rules.Validate(); err != nil {
+		return rules, fmt.Errorf("配置校验失败: %w", err)
+	}
+	return rules, nil
+}
+
+// GetContext 返回客户上下文的副本
 func (e *ChatBotEngine) GetContext(customerID string) ConversationContext {
-	return e.getOrCreateContext(customerID)
+	var snapshot ConversationContext
+	e.withContext(customerID, func(ctx *ConversationContext) {
+		snapshot = ctx.clone()
+	})
+	return snapshot
 }
 
-// 核心消息处理逻辑
+// SetLocale 设置会话语言（来自客户偏好或Accept-Language），不支持的语言将被忽略
+func (e *ChatBotEngine) SetLocale(customerID string, locale string) {
+	if locale = i18n.Normalize(locale); locale == "" {
+		return
+	}
+	e.withContext(customerID, func(ctx *ConversationContext) {
+		ctx.Locale = locale
+	})
+}
+
+// 核心消息处理逻辑，返回回复的纯文本
 func (e *ChatBotEngine) ProcessMessage(customerID string, message string) string {
-	ctx := e.getOrCreateContext(customerID)
-	defer e.saveContext(customerID, ctx)
+	return e.Respond(customerID, message).PlainText()
+}
+
+// Respond 处理用户消息，返回包含富媒体内容的完整回复；同一客户的消息串行处理
+func (e *ChatBotEngine) Respond(customerID string, message string) (reply Reply) {
+	e.withContext(customerID, func(ctx *ConversationContext) {
+		reply = e.process(customerID, message, false, ctx)
+	})
+	return reply
+}
 
-	// 1. 意图识别
-	intent := e.detectIntent(message, ctx)
-	// 2. 状态转移
-	response := e.handleStateTransition(intent, &ctx)
+func (e *ChatBotEngine) respond(customerID string, message string, ctx *ConversationContext) Reply {
+	// 未指定语言时根据首条消息检测
+	if ctx.Locale == "" {
+		ctx.Locale = i18n.Resolve(i18n.Detect(message), e.rules.Metadata.DefaultLang, i18n.DefaultLocale)
+	}
+
+	// 上一轮追问了意图时，回答选项后按选中的意图继续转移
+	if intent, ok := e.resolveClarification(message, ctx); ok {
+		return e.reply(intent, ctx)
+	}
+
+	// 1. 实体抽取与槽位填充
+	e.fillSlots(message, ctx)
+	// 2. 意图识别，候选意图接近时追问
+	intent, candidates := e.detectIntent(message, *ctx)
+	if intent != "unknown" && len(candidates) > 0 {
+		return e.clarify(candidates, ctx)
+	}
+	// 未识别的意图依次尝试常见问题检索、低置信度候选追问和生成式回复
+	if intent == "unknown" {
+		if answer, ok := e.answerFAQ(message, ctx); ok {
+			ctx.trace.source(TraceSourceFAQ)
+			return e.finishReply(answer, ctx)
+		}
+		if len(candidates) > 0 {
+			return e.clarify(candidates, ctx)
+		}
+		if generated, ok := e.generateReply(customerID, message, ctx); ok {
+			ctx.trace.source(TraceSourceGenerated)
+			return e.finishReply(generated, ctx)
+		}
+	}
+	// 3. 状态转移
+	return e.reply(intent, ctx)
+}
+
+// HandlePostback 处理按钮回传，payload 直接映射为意图，不经过意图识别
+func (e *ChatBotEngine) HandlePostback(customerID string, payload string) (reply Reply) {
+	e.withContext(customerID, func(ctx *ConversationContext) {
+		reply = e.process(customerID, payload, true, ctx)
+	})
+	return reply
+}
+
+func (e *ChatBotEngine) handlePostback(payload string, ctx *ConversationContext) Reply {
+	if ctx.Locale == "" {
+		ctx.Locale = i18n.Resolve(e.rules.Metadata.DefaultLang, i18n.DefaultLocale)
+	}
+
+	if intent, ok := e.intentForClarifyPayload(payload, ctx); ok {
+		return e.reply(intent, ctx)
+	}
+	if answer, ok := e.faqForPayload(payload); ok {
+		ctx.trace.source(TraceSourceFAQ)
+		return e.finishReply(answer, ctx)
+	}
+	return e.reply(e.rules.intentForPayload(payload), ctx)
+}
+
+// reply 根据意图执行状态转移，处理连续兜底并更新上下文
+func (e *ChatBotEngine) reply(intent string, ctx *ConversationContext) Reply {
+	reply := e.handleStateTransition(intent, ctx)
+	reply.Intent = intent
+	return e.finishReply(reply, ctx)
+}
+
+// finishReply 处理连续兜底计数、更新活跃时间并个性化回复
+func (e *ChatBotEngine) finishReply(reply Reply, ctx *ConversationContext) Reply {
+	if reply.Fallback {
+		reply = e.handleFallback(reply, ctx)
+	} else {
+		ctx.FallbackCount = 0
+	}
 
 	// 3. 上下文更新
-	ctx.LastActive = time.Now()
-	return response
-}
-
-// 意图识别实现
-func (e *ChatBotEngine) detectIntent(msg string, ctx ConversationContext) string {
-	msg = strings.ToLower(msg)
-	// 优先匹配正则规则
-	for _, rule := range e.rules.IntentDetection.RegexPatterns {
-		for _, pattern := range rule.Patterns {
-			re := regexp.MustCompile(pattern)
-			if re.MatchString(msg) {
-				return rule.Intent
-			}
+	ctx.LastActive = e.now()
+	return e.personalize(reply, ctx)
+}
+
+// 意图识别实现，分类器使用归一化后的文本；返回的候选意图非空时需要向客户追问
+func (e *ChatBotEngine) detectIntent(msg string, ctx ConversationContext) (string, []string) {
+	msg = e.Normalize(msg)
+	if ctx.trace != nil {
+		ctx.trace.Normalized = msg
+	}
+	// 依次尝试各分类器，首个置信度达到阈值的结果胜出
+	var uncertain []IntentScore
+	for _, stage := range e.stages {
+		scores := stage.classifier.Classify(msg)
+		accepted := len(scores) > 0 && scores[0].Score >= stage.threshold
+		ctx.trace.stage(stage, scores, accepted)
+		if !accepted {
+			uncertain = append(uncertain, scores...)
+			continue
+		}
+		if stage.scored {
+			return scores[0].Intent, e.ambiguousIntents(scores)
 		}
+		return scores[0].Intent, nil
 	}
 
-	return "unknown"
+	return "unknown", e.uncertainIntents(uncertain)
 }
 
 // 状态机处理
 // 修复空指针问题和状态转移逻辑
-func (e *ChatBotEngine) handleStateTransition(intent string, ctx *ConversationContext) string {
+func (e *ChatBotEngine) handleStateTransition(intent string, ctx *ConversationContext) Reply {
 	// 确保获取当前状态
-	currentState := e.rules.findState(ctx.CurrentState)
+	currentState := e.resolveState(ctx)
 	if currentState == nil {
-		// 默认回退到欢迎状态
-		currentState = e.rules.findState("welcome")
-		if currentState == nil {
-			return e.rules.ErrorHandling.DefaultFallback
-		}
-		ctx.CurrentState = currentState.Name // 更新上下文状态
+		return e.fallback(ctx)
 	}
 
-	// 增加空指针保护
-	if currentState.Transitions == nil {
-		return e.rules.ErrorHandling.DefaultFallback
+	// 优化状态转移匹配逻辑：当前状态 → 全局转移
+	if transition, ok := e.matchTransition(currentState, intent, ctx); ok {
+		return e.applyTransition(transition, ctx)
 	}
 
-	// 优化状态转移匹配逻辑
-	for _, transition := range currentState.Transitions {
-		if transition.Intent == intent {
-			response := e.executeActions(transition.Actions, ctx)
-			if nextState := e.rules.findState(transition.NextState); nextState != nil {
-				ctx.CurrentState = nextState.Name // 更新到下一个状态
-			}
-			return response
+	// 子对话中无法处理时，逐层返回入口状态再尝试匹配；均未命中则保持原状态
+	state, stack := ctx.CurrentState, ctx.StateStack
+	for len(ctx.StateStack) > 0 {
+		goBack(ctx)
+		if transition, ok := e.matchTransition(e.resolveState(ctx), intent, ctx); ok {
+			return e.applyTransition(transition, ctx)
 		}
 	}
-	return e.rules.ErrorHandling.DefaultFallback
+	ctx.CurrentState, ctx.StateStack = state, stack
+	return e.fallback(ctx)
+}
+
+// fallback 返回当前会话语言的兜底回复
+func (e *ChatBotEngine) fallback(ctx *ConversationContext) Reply {
+	return Reply{
+		Text:     i18n.Pick(e.rules.ErrorHandling.DefaultFallbackI18n, ctx.Locale, e.rules.ErrorHandling.DefaultFallback),
+		Fallback: true,
+	}
 }
 
 /*
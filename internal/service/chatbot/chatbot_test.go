@@ -36,7 +36,7 @@ func TestGreetingIntent(t *testing.T) {
 
 	// 首次对话
 	resp := engine.ProcessMessage("1001", "hello")
-	assert.Equal(t, "您好，我是${bot_name}，请问需要什么帮助？", resp)
+	assert.Equal(t, "您好，我是智能助手，请问需要什么帮助？", resp)
 
 	// 验证上下文状态
 	ctx := engine.GetContext("1001")
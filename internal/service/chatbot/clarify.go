@@ -0,0 +1,167 @@
+package chatbot
+
+import (
+	"strconv"
+	"strings"
+
+	"gochat/internal/service/i18n"
+)
+
+// 意图澄清相关的意图与按钮回传前缀
+const (
+	IntentClarification  = "clarification" // 候选意图接近或置信度不足，追问客户
+	ClarifyPayloadPrefix = "CLARIFY:"      // 候选按钮回传 CLARIFY:<intent>
+	ClarifyNone          = "none"          // CLARIFY:none 表示都不是
+)
+
+// ClarificationConfig 意图澄清配置，对应 intent_detection.clarification
+type ClarificationConfig struct {
+	Enabled    bool              `mapstructure:"enabled"`
+	Margin     float64           `mapstructure:"margin"`      // 与最高分相差不超过该值的其他意图视为接近，如 0.1；为 0 时仅得分相同视为接近
+	MinScore   float64           `mapstructure:"min_score"`   // 各分类器均未达到阈值时，得分不低于该值的意图作为候选；为 0 时不追问
+	MaxOptions int               `mapstructure:"max_options"` // 候选数上限，默认 3
+	Prompt     string            `mapstructure:"prompt"`
+	PromptI18n map[string]string `mapstructure:"prompt_i18n"`
+
+	// 选项标题：意图 → 标题，未配置时使用意图名
+	Labels     map[string]string            `mapstructure:"labels"`
+	LabelsI18n map[string]map[string]string `mapstructure:"labels_i18n"` // 意图 → 语言 → 标题
+	NoneLabel  string                       `mapstructure:"none_label"`
+	NoneI18n   map[string]string            `mapstructure:"none_label_i18n"`
+}
+
+// ambiguousIntents 采用的阶段中与最高分接近的意图多于一个时返回候选
+func (e *ChatBotEngine) ambiguousIntents(scores []IntentScore) []string {
+	cfg := e.rules.IntentDetection.Clarification
+	if !cfg.Enabled {
+		return nil
+	}
+	candidates := e.clarificationCandidates(scores, scores[0].Score-cfg.Margin)
+	if len(candidates) < 2 {
+		return nil
+	}
+	return candidates
+}
+
+// uncertainIntents 各阶段均未达到阈值时，返回得分不低于 min_score 的候选
+func (e *ChatBotEngine) uncertainIntents(scores []IntentScore) []string {
+	cfg := e.rules.IntentDetection.Clarification
+	if !cfg.Enabled || cfg.MinScore <= 0 {
+		return nil
+	}
+	return e.clarificationCandidates(sortScores(scores), cfg.MinScore)
+}
+
+// clarificationCandidates 按得分降序返回不低于 minScore 的意图，同一意图只保留一次
+func (e *ChatBotEngine) clarificationCandidates(scores []IntentScore, minScore float64) []string {
+	maxOptions := e.rules.IntentDetection.Clarification.MaxOptions
+	if maxOptions <= 0 {
+		maxOptions = 3
+	}
+	var candidates []string
+	seen := make(map[string]bool)
+	for _, score := range scores {
+		if score.Score < minScore || len(candidates) == maxOptions {
+			break
+		}
+		if !seen[score.Intent] {
+			seen[score.Intent] = true
+			candidates = append(candidates, score.Intent)
+		}
+	}
+	return candidates
+}
+
+// clarify 追问客户想要的意图，状态保持不变，客户回答后按选中的意图继续转移
+func (e *ChatBotEngine) clarify(candidates []string, ctx *ConversationContext) Reply {
+	cfg := e.rules.IntentDetection.Clarification
+	ctx.Clarification = candidates
+	if ctx.trace != nil {
+		ctx.trace.Clarification = candidates
+	}
+
+	buttons := make([]Button, 0, len(candidates)+1)
+	for _, intent := range candidates {
+		buttons = append(buttons, Button{Title: e.clarificationLabel(intent, ctx.Locale), Payload: ClarifyPayloadPrefix + intent})
+	}
+	buttons = append(buttons, Button{Title: e.clarificationNoneLabel(ctx.Locale), Payload: ClarifyPayloadPrefix + ClarifyNone})
+
+	prompt := cfg.Prompt
+	if prompt == "" {
+		prompt = "您是想咨询哪一项？"
+	}
+	return e.finishReply(Reply{
+		Text:   i18n.Pick(cfg.PromptI18n, ctx.Locale, prompt),
+		Rich:   []RichContent{{Type: RichTypeQuickReply, Buttons: buttons}},
+		Intent: IntentClarification,
+	}, ctx)
+}
+
+// resolveClarification 按客户对追问的回答确定意图：选项序号、选项标题或“都不是”；
+// 回答不是选项时放弃追问，消息按正常流程识别
+func (e *ChatBotEngine) resolveClarification(message string, ctx *ConversationContext) (string, bool) {
+	candidates := ctx.Clarification
+	if len(candidates) == 0 {
+		return "", false
+	}
+	ctx.Clarification = nil
+
+	answer := e.Normalize(message)
+	intent, ok := "", false
+	if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(candidates) {
+		intent, ok = candidates[n-1], true
+	} else if answer == e.Normalize(e.clarificationNoneLabel(ctx.Locale)) {
+		intent, ok = "unknown", true
+	} else {
+		for _, candidate := range candidates {
+			if answer == e.Normalize(e.clarificationLabel(candidate, ctx.Locale)) {
+				intent, ok = candidate, true
+				break
+			}
+		}
+	}
+	if ok && ctx.trace != nil {
+		ctx.trace.Clarification = candidates
+		ctx.trace.Classifier, ctx.trace.Pattern = IntentClarification, message
+	}
+	return intent, ok
+}
+
+// intentForClarifyPayload 处理候选按钮回传，payload 不是 CLARIFY:<intent> 时返回 false；
+// 回传的意图不在待选候选中（过期按钮或伪造的回传）时按“都不是”处理，候选在回传后即失效
+func (e *ChatBotEngine) intentForClarifyPayload(payload string, ctx *ConversationContext) (string, bool) {
+	if !strings.HasPrefix(strings.ToUpper(payload), ClarifyPayloadPrefix) {
+		return "", false
+	}
+	candidates := ctx.Clarification
+	ctx.Clarification = nil
+	if ctx.trace != nil {
+		ctx.trace.Clarification = candidates
+		ctx.trace.Classifier = IntentClarification
+	}
+	intent := payload[len(ClarifyPayloadPrefix):]
+	for _, candidate := range candidates {
+		if intent == candidate {
+			return intent, true
+		}
+	}
+	return "unknown", true
+}
+
+func (e *ChatBotEngine) clarificationLabel(intent, locale string) string {
+	cfg := e.rules.IntentDetection.Clarification
+	label := cfg.Labels[intent]
+	if label == "" {
+		label = intent
+	}
+	return i18n.Pick(cfg.LabelsI18n[intent], locale, label)
+}
+
+func (e *ChatBotEngine) clarificationNoneLabel(locale string) string {
+	cfg := e.rules.IntentDetection.Clarification
+	label := cfg.NoneLabel
+	if label == "" {
+		label = "都不是"
+	}
+	return i18n.Pick(cfg.NoneI18n, locale, label)
+}
@@ -0,0 +1,147 @@
+package chatbot_test
+
+import (
+	"testing"
+
+	"gochat/internal/service/chatbot"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const clarifyRules = `
+intent_detection:
+  clarification:
+    enabled: true
+    margin: 0.1
+    min_score: 0.5
+    labels:
+      order_query: "查询订单"
+      return_goods: "申请退货"
+  keyword_matching:
+    threshold: 0.9
+    keywords:
+      - intent: "order_query"
+        words: ["订单", "物流"]
+      - intent: "return_goods"
+        words: ["退货"]
+      - intent: "weather_query"
+        words: ["forecast"]
+        max_distance: 2
+context_management:
+  entity_extraction:
+    extractors: ["order_number"]
+dialogue_flow:
+  states:
+    - name: "welcome"
+      transitions:
+        - intent: "order_query"
+          next_state: "order_detail"
+          actions:
+            - type: "response"
+              content: "正在为您查询订单"
+        - intent: "return_goods"
+          next_state: "return_goods"
+          actions:
+            - type: "response"
+              content: "请说明退货原因"
+        - intent: "weather_query"
+          actions:
+            - type: "response"
+              content: "请问哪个城市？"
+    - name: "order_detail"
+    - name: "return_goods"
+error_handling:
+  default_fallback: "抱歉，我没有理解。"
+`
+
+func newClarifyEngine(t *testing.T) *chatbot.ChatBotEngine {
+	rules, err := chatbot.ParseChatBotRules([]byte(clarifyRules))
+	assert.NoError(t, err)
+	assert.NoError(t, rules.Validate())
+	engine := chatbot.NewChatBotEngineWithRules(nil, rules)
+	engine.SetCustomerLoader(nil)
+	engine.SetFAQIndex(nil)
+	engine.SetResponseGenerator(nil)
+	return engine
+}
+
+func TestClarifyCloseIntents(t *testing.T) {
+	engine := newClarifyEngine(t)
+
+	reply := engine.Respond("7001", "订单 SO20240101001 要退货")
+	assert.Equal(t, chatbot.IntentClarification, reply.Intent)
+	assert.Equal(t, "您是想咨询哪一项？", reply.Text)
+	if assert.Len(t, reply.Rich, 1) {
+		assert.Equal(t, []chatbot.Button{
+			{Title: "查询订单", Payload: "CLARIFY:order_query"},
+			{Title: "申请退货", Payload: "CLARIFY:return_goods"},
+			{Title: "都不是", Payload: "CLARIFY:none"},
+		}, reply.Rich[0].Buttons)
+	}
+	assert.Equal(t, []string{"order_query", "return_goods"}, reply.Trace.Clarification)
+	ctx := engine.GetContext("7001")
+	assert.Equal(t, "welcome", ctx.CurrentState)
+	assert.Equal(t, []string{"order_query", "return_goods"}, ctx.Clarification)
+
+	// 回复序号后按选中的意图继续原来的转移，原消息中的实体已填充槽位
+	reply = engine.Respond("7001", "2")
+	assert.Equal(t, "请说明退货原因", reply.Text)
+	assert.Equal(t, "return_goods", reply.Intent)
+	assert.Equal(t, chatbot.IntentClarification, reply.Trace.Classifier)
+	ctx = engine.GetContext("7001")
+	assert.Equal(t, "return_goods", ctx.CurrentState)
+	assert.Equal(t, "SO20240101001", ctx.Slots["order_number"])
+	assert.Empty(t, ctx.Clarification)
+}
+
+func TestClarifyAnswers(t *testing.T) {
+	engine := newClarifyEngine(t)
+
+	// 点击按钮
+	engine.Respond("7002", "订单退货")
+	reply := engine.HandlePostback("7002", "CLARIFY:order_query")
+	assert.Equal(t, "正在为您查询订单", reply.Text)
+	assert.Equal(t, "order_detail", engine.GetContext("7002").CurrentState)
+	assert.Empty(t, engine.GetContext("7002").Clarification)
+
+	// 候选已用过，再次点击旧按钮不再生效
+	reply = engine.HandlePostback("7002", "CLARIFY:return_goods")
+	assert.True(t, reply.Fallback)
+	assert.Equal(t, "order_detail", engine.GetContext("7002").CurrentState)
+
+	// 回传不在候选中的意图：兜底回复，候选清除
+	engine.Respond("7008", "订单退货")
+	reply = engine.HandlePostback("7008", "CLARIFY:weather_query")
+	assert.True(t, reply.Fallback)
+	assert.Equal(t, "welcome", engine.GetContext("7008").CurrentState)
+	assert.Empty(t, engine.GetContext("7008").Clarification)
+
+	// 回复选项名
+	engine.Respond("7003", "订单退货")
+	assert.Equal(t, "请说明退货原因", engine.Respond("7003", "申请退货").Text)
+
+	// 都不是：兜底回复，状态不变
+	engine.Respond("7004", "订单退货")
+	reply = engine.Respond("7004", "都不是")
+	assert.True(t, reply.Fallback)
+	assert.Equal(t, "welcome", engine.GetContext("7004").CurrentState)
+
+	// 回答不是选项时放弃追问，按新消息处理
+	engine.Respond("7005", "订单退货")
+	reply = engine.Respond("7005", "查下物流")
+	assert.Equal(t, "正在为您查询订单", reply.Text)
+	assert.Empty(t, engine.GetContext("7005").Clarification)
+}
+
+func TestClarifyLowConfidence(t *testing.T) {
+	engine := newClarifyEngine(t)
+
+	// forcst 与 forecast 编辑距离为2，得分 0.75 低于阈值 0.9，但不低于 min_score
+	reply := engine.Respond("7006", "forcst")
+	assert.Equal(t, chatbot.IntentClarification, reply.Intent)
+	assert.Equal(t, []string{"weather_query"}, engine.GetContext("7006").Clarification)
+	assert.Equal(t, "请问哪个城市？", engine.Respond("7006", "1").Text)
+
+	// 没有候选时仍为兜底回复
+	assert.True(t, engine.Respond("7007", "随便说点什么").Fallback)
+}
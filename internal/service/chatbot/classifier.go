@@ -0,0 +1,139 @@
+package chatbot
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gochat/internal/service/textproc"
+)
+
+// IntentScore 意图候选及其置信度
+type IntentScore struct {
+	Intent  string  `json:"intent"`
+	Score   float64 `json:"score"`
+	Pattern string  `json:"pattern,omitempty"` // 命中的正则或关键词，统计模型为空
+}
+
+// IntentClassifier 意图分类器接口，返回按置信度降序排列的候选意图
+type IntentClassifier interface {
+	Name() string
+	Classify(text string) []IntentScore
+}
+
+func sortScores(scores []IntentScore) []IntentScore {
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Score > scores[j].Score
+	})
+	return scores
+}
+
+// RegexClassifier 正则意图匹配，按规则顺序返回命中的意图，置信度固定为1
+type RegexClassifier struct {
+	rules []regexIntent
+}
+
+type regexIntent struct {
+	intent   string
+	patterns []*regexp.Regexp
+}
+
+// NewRegexClassifier 根据规则中的正则配置创建分类器
+func NewRegexClassifier(rules ChatBotRules) (*RegexClassifier, error) {
+	c := &RegexClassifier{}
+	for _, rule := range rules.IntentDetection.RegexPatterns {
+		item := regexIntent{intent: rule.Intent}
+		for _, pattern := range rule.Patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("意图 %s 的正则无效 %q: %w", rule.Intent, pattern, err)
+			}
+			item.patterns = append(item.patterns, re)
+		}
+		c.rules = append(c.rules, item)
+	}
+	return c, nil
+}
+
+func (c *RegexClassifier) Name() string { return "regex" }
+
+func (c *RegexClassifier) Classify(text string) []IntentScore {
+	text = strings.ToLower(text)
+	var scores []IntentScore
+	for _, rule := range c.rules {
+		for _, re := range rule.patterns {
+			if re.MatchString(text) {
+				scores = append(scores, IntentScore{Intent: rule.intent, Score: 1, Pattern: re.String()})
+				break
+			}
+		}
+	}
+	return scores
+}
+
+// KeywordClassifier 关键词/模糊匹配：中文按子串匹配，英文单词允许一定的编辑距离
+type KeywordClassifier struct {
+	rules []keywordIntent
+}
+
+type keywordIntent struct {
+	intent      string
+	words       []string
+	maxDistance int
+}
+
+// NewKeywordClassifier 根据规则中的关键词配置创建分类器
+func NewKeywordClassifier(rules ChatBotRules) *KeywordClassifier {
+	c := &KeywordClassifier{}
+	for _, rule := range rules.IntentDetection.KeywordMatching.Keywords {
+		item := keywordIntent{intent: rule.Intent, maxDistance: rule.MaxDistance}
+		for _, word := range rule.Words {
+			item.words = append(item.words, strings.ToLower(word))
+		}
+		c.rules = append(c.rules, item)
+	}
+	return c
+}
+
+func (c *KeywordClassifier) Name() string { return "keyword" }
+
+func (c *KeywordClassifier) Classify(text string) []IntentScore {
+	text = strings.ToLower(text)
+	tokens := textproc.Tokenize(text)
+
+	var scores []IntentScore
+	for _, rule := range c.rules {
+		best := IntentScore{Intent: rule.intent}
+		for _, word := range rule.words {
+			if score := matchKeyword(text, tokens, word, rule.maxDistance); score > best.Score {
+				best.Score = score
+				best.Pattern = word
+			}
+		}
+		if best.Score > 0 {
+			scores = append(scores, best)
+		}
+	}
+	return sortScores(scores)
+}
+
+// matchKeyword 完全包含得分为1，模糊命中按编辑距离折算得分
+func matchKeyword(text string, tokens []string, word string, maxDistance int) float64 {
+	if strings.Contains(text, word) {
+		return 1
+	}
+	if maxDistance <= 0 {
+		return 0
+	}
+	length := float64(len([]rune(word)))
+	var best float64
+	for _, token := range tokens {
+		if d := textproc.Levenshtein(token, word); d <= maxDistance {
+			if score := 1 - float64(d)/length; score > best {
+				best = score
+			}
+		}
+	}
+	return best
+}
@@ -0,0 +1,177 @@
+package chatbot
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gochat/internal/service/textproc"
+)
+
+// LabeledUtterance 带意图标注的训练语料
+type LabeledUtterance struct {
+	Intent string
+	Text   string
+}
+
+// NaiveBayesClassifier 多项式朴素贝叶斯意图分类器，可训练并保存为JSON模型文件
+type NaiveBayesClassifier struct {
+	Version     int                       `json:"version"`
+	Intents     []string                  `json:"intents"`
+	Priors      map[string]float64        `json:"priors"`       // log P(intent)
+	TokenCounts map[string]map[string]int `json:"token_counts"` // intent -> token -> 次数
+	TotalTokens map[string]int            `json:"total_tokens"` // intent -> token总数
+	VocabSize   int                       `json:"vocab_size"`
+}
+
+// LoadLabeledUtterances 从目录加载训练语料，每个 <intent>.txt 文件一行一条，# 开头为注释
+func LoadLabeledUtterances(dir string) ([]LabeledUtterance, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var samples []LabeledUtterance
+	for _, file := range files {
+		intent := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			samples = append(samples, LabeledUtterance{Intent: intent, Text: line})
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("读取语料失败 %s: %w", file, err)
+		}
+	}
+	return samples, nil
+}
+
+// TrainNaiveBayes 使用标注语料训练分类器
+func TrainNaiveBayes(samples []LabeledUtterance) (*NaiveBayesClassifier, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("训练语料为空")
+	}
+
+	m := &NaiveBayesClassifier{
+		Version:     1,
+		Priors:      make(map[string]float64),
+		TokenCounts: make(map[string]map[string]int),
+		TotalTokens: make(map[string]int),
+	}
+	docCounts := make(map[string]int)
+	vocab := make(map[string]struct{})
+
+	for _, sample := range samples {
+		if _, ok := m.TokenCounts[sample.Intent]; !ok {
+			m.TokenCounts[sample.Intent] = make(map[string]int)
+			m.Intents = append(m.Intents, sample.Intent)
+		}
+		docCounts[sample.Intent]++
+		for _, token := range textproc.Tokenize(sample.Text) {
+			m.TokenCounts[sample.Intent][token]++
+			m.TotalTokens[sample.Intent]++
+			vocab[token] = struct{}{}
+		}
+	}
+	sort.Strings(m.Intents)
+
+	for _, intent := range m.Intents {
+		m.Priors[intent] = math.Log(float64(docCounts[intent]) / float64(len(samples)))
+	}
+	m.VocabSize = len(vocab)
+	return m, nil
+}
+
+// LoadNaiveBayesClassifier 从模型文件加载分类器
+func LoadNaiveBayesClassifier(path string) (*NaiveBayesClassifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m NaiveBayesClassifier
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("模型文件解析失败 %s: %w", path, err)
+	}
+	if len(m.Intents) == 0 {
+		return nil, fmt.Errorf("模型文件 %s 不包含任何意图", path)
+	}
+	return &m, nil
+}
+
+// Save 将模型保存为JSON文件
+func (m *NaiveBayesClassifier) Save(path string) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (m *NaiveBayesClassifier) Name() string { return "naive_bayes" }
+
+// Classify 计算各意图的后验概率，并按已知词覆盖率折算为置信度
+func (m *NaiveBayesClassifier) Classify(text string) []IntentScore {
+	tokens := textproc.Tokenize(text)
+	known := 0
+	for _, token := range tokens {
+		if m.knownToken(token) {
+			known++
+		}
+	}
+	if known == 0 {
+		return nil
+	}
+	coverage := float64(known) / float64(len(tokens))
+
+	logProbs := make([]float64, len(m.Intents))
+	maxLog := math.Inf(-1)
+	for i, intent := range m.Intents {
+		lp := m.Priors[intent]
+		denominator := float64(m.TotalTokens[intent] + m.VocabSize + 1)
+		for _, token := range tokens {
+			lp += math.Log(float64(m.TokenCounts[intent][token]+1) / denominator)
+		}
+		logProbs[i] = lp
+		maxLog = math.Max(maxLog, lp)
+	}
+
+	// softmax 归一化为概率
+	var sum float64
+	for i := range logProbs {
+		logProbs[i] = math.Exp(logProbs[i] - maxLog)
+		sum += logProbs[i]
+	}
+	scores := make([]IntentScore, len(m.Intents))
+	for i, intent := range m.Intents {
+		scores[i] = IntentScore{Intent: intent, Score: logProbs[i] / sum * coverage}
+	}
+	return sortScores(scores)
+}
+
+func (m *NaiveBayesClassifier) knownToken(token string) bool {
+	for _, counts := range m.TokenCounts {
+		if counts[token] > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package chatbot_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gochat/internal/service/chatbot"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNaiveBayesClassifier(t *testing.T) {
+	samples := []chatbot.LabeledUtterance{
+		{Intent: "greeting", Text: "你好"},
+		{Intent: "greeting", Text: "hello there"},
+		{Intent: "greeting", Text: "早上好"},
+		{Intent: "weather_query", Text: "今天天气怎么样"},
+		{Intent: "weather_query", Text: "明天会下雨吗"},
+		{Intent: "weather_query", Text: "weather forecast"},
+	}
+
+	model, err := chatbot.TrainNaiveBayes(samples)
+	assert.NoError(t, err)
+
+	t.Run("识别已知意图", func(t *testing.T) {
+		scores := model.Classify("北京明天天气")
+		assert.NotEmpty(t, scores)
+		assert.Equal(t, "weather_query", scores[0].Intent)
+		assert.Greater(t, scores[0].Score, scores[1].Score)
+	})
+
+	t.Run("未登录词不给出结果", func(t *testing.T) {
+		assert.Empty(t, model.Classify("xyz"))
+	})
+
+	t.Run("保存并重新加载", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "model.json")
+		assert.NoError(t, model.Save(path))
+
+		loaded, err := chatbot.LoadNaiveBayesClassifier(path)
+		assert.NoError(t, err)
+		assert.Equal(t, model.Classify("hello"), loaded.Classify("hello"))
+	})
+}
+
+func TestKeywordClassifier(t *testing.T) {
+	var rules chatbot.ChatBotRules
+	rules.IntentDetection.KeywordMatching.Keywords = []chatbot.KeywordRule{
+		{Intent: "weather_query", Words: []string{"weather", "天气预报"}, MaxDistance: 1},
+	}
+
+	classifier := chatbot.NewKeywordClassifier(rules)
+
+	scores := classifier.Classify("看看天气预报")
+	assert.Equal(t, []chatbot.IntentScore{{Intent: "weather_query", Score: 1, Pattern: "天气预报"}}, scores)
+
+	// 拼写错误按编辑距离折算
+	scores = classifier.Classify("how is the wether")
+	assert.Len(t, scores, 1)
+	assert.InDelta(t, 1-1.0/7, scores[0].Score, 1e-9)
+
+	assert.Empty(t, classifier.Classify("hello"))
+}
@@ -0,0 +1,163 @@
+package chatbot
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 条件表达式比较运算符，按长度优先匹配
+var conditionOperators = []string{">=", "<=", "==", "!=", ">", "<"}
+
+var placeholderPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// evalCondition 计算 "${user.level >= 3}" 形式的条件表达式
+// 支持单个比较运算，左右两侧可以是变量路径、数字、带引号的字符串或 true/false；
+// 仅有变量路径时按真值判断
+func evalCondition(expr string, vars map[string]interface{}) (bool, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "${") && strings.HasSuffix(expr, "}") {
+		expr = strings.TrimSpace(expr[2 : len(expr)-1])
+	}
+	if expr == "" {
+		return false, fmt.Errorf("条件表达式为空")
+	}
+
+	for _, op := range conditionOperators {
+		idx := strings.Index(expr, op)
+		if idx < 0 {
+			continue
+		}
+		left := resolveOperand(strings.TrimSpace(expr[:idx]), vars)
+		right := resolveOperand(strings.TrimSpace(expr[idx+len(op):]), vars)
+		return compareValues(left, right, op)
+	}
+	return truthy(resolveOperand(expr, vars)), nil
+}
+
+// resolveOperand 解析操作数：字面量直接返回，否则按变量路径查找
+func resolveOperand(token string, vars map[string]interface{}) interface{} {
+	if len(token) >= 2 && (token[0] == '"' || token[0] == '\'') && token[len(token)-1] == token[0] {
+		return token[1 : len(token)-1]
+	}
+	if n, err := strconv.ParseFloat(token, 64); err == nil {
+		return n
+	}
+	switch token {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	value, _ := lookupVar(vars, token)
+	return value
+}
+
+// lookupVar 按 "a.b.c" 路径查找变量
+func lookupVar(vars map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = vars
+	for _, key := range strings.Split(path, ".") {
+		switch m := current.(type) {
+		case map[string]interface{}:
+			v, ok := m[key]
+			if !ok {
+				return nil, false
+			}
+			current = v
+		case map[string]string:
+			v, ok := m[key]
+			if !ok {
+				return nil, false
+			}
+			current = v
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func compareValues(left, right interface{}, op string) (bool, error) {
+	ln, lok := toNumber(left)
+	rn, rok := toNumber(right)
+	if lok && rok {
+		switch op {
+		case "==":
+			return ln == rn, nil
+		case "!=":
+			return ln != rn, nil
+		case ">=":
+			return ln >= rn, nil
+		case "<=":
+			return ln <= rn, nil
+		case ">":
+			return ln > rn, nil
+		case "<":
+			return ln < rn, nil
+		}
+	}
+
+	ls, rs := fmt.Sprint(left), fmt.Sprint(right)
+	switch op {
+	case "==":
+		return left != nil && ls == rs, nil
+	case "!=":
+		return left == nil || ls != rs, nil
+	}
+	// 变量缺失或非数字时，大小比较视为不成立
+	if left == nil || right == nil {
+		return false, nil
+	}
+	return false, fmt.Errorf("无法比较 %v %s %v", left, op, right)
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func truthy(v interface{}) bool {
+	switch b := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return b
+	case string:
+		return b != "" && b != "false" && b != "0"
+	}
+	if n, ok := toNumber(v); ok {
+		return n != 0
+	}
+	return true
+}
+
+// renderTemplate 替换模板中的 ${path} 占位符，未定义的变量保持原样
+func renderTemplate(tpl string, vars map[string]interface{}) string {
+	if !strings.Contains(tpl, "${") {
+		return tpl
+	}
+	return placeholderPattern.ReplaceAllStringFunc(tpl, func(placeholder string) string {
+		path := strings.TrimSpace(placeholder[2 : len(placeholder)-1])
+		if value, ok := lookupVar(vars, path); ok && value != nil {
+			return fmt.Sprint(value)
+		}
+		return placeholder
+	})
+}
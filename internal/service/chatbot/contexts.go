@@ -0,0 +1,185 @@
+package chatbot
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// contextShards 会话上下文分片数，降低大量并发会话时的锁竞争
+const contextShards = 64
+
+// contextStore 按客户分片保存会话上下文；同一客户的消息在客户锁内串行处理，不同客户互不阻塞
+type contextStore struct {
+	shards [contextShards]contextShard
+}
+
+type contextShard struct {
+	mu      sync.Mutex
+	entries map[string]*contextEntry
+}
+
+// contextEntry 单个客户的上下文
+type contextEntry struct {
+	mu    sync.Mutex // 保护 ctx 与 ready
+	ctx   ConversationContext
+	ready bool
+
+	// 以下字段由分片锁保护
+	refs  int  // 打开的连接数
+	users int  // 正在处理的操作数
+	evict bool // 连接均已关闭，操作结束后删除
+}
+
+func newContextStore() *contextStore {
+	store := &contextStore{}
+	for i := range store.shards {
+		store.shards[i].entries = make(map[string]*contextEntry)
+	}
+	return store
+}
+
+func (s *contextStore) shard(customerID string) *contextShard {
+	h := fnv.New32a()
+	h.Write([]byte(customerID))
+	return &s.shards[h.Sum32()%contextShards]
+}
+
+// update 在客户锁内读取并修改上下文，上下文不存在时使用 create 创建
+func (s *contextStore) update(customerID string, create func() ConversationContext, fn func(ctx *ConversationContext)) {
+	shard := s.shard(customerID)
+	shard.mu.Lock()
+	entry, ok := shard.entries[customerID]
+	if !ok {
+		entry = &contextEntry{}
+		shard.entries[customerID] = entry
+	}
+	entry.users++
+	shard.mu.Unlock()
+
+	entry.mu.Lock()
+	if !entry.ready {
+		entry.ctx, entry.ready = create(), true
+	}
+	fn(&entry.ctx)
+	entry.mu.Unlock()
+
+	shard.mu.Lock()
+	entry.users--
+	if entry.evict && entry.users == 0 {
+		delete(shard.entries, customerID)
+	}
+	shard.mu.Unlock()
+}
+
+// snapshot 返回已存在的上下文副本，不创建新上下文
+func (s *contextStore) snapshot(customerID string) (ConversationContext, bool) {
+	shard := s.shard(customerID)
+	shard.mu.Lock()
+	entry, ok := shard.entries[customerID]
+	shard.mu.Unlock()
+	if !ok {
+		return ConversationContext{}, false
+	}
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if !entry.ready {
+		return ConversationContext{}, false
+	}
+	return entry.ctx.clone(), true
+}
+
+// modify 在客户锁内修改已存在的上下文，上下文不存在时不创建
+func (s *contextStore) modify(customerID string, fn func(ctx *ConversationContext)) {
+	shard := s.shard(customerID)
+	shard.mu.Lock()
+	entry, ok := shard.entries[customerID]
+	if ok {
+		entry.users++
+	}
+	shard.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	entry.mu.Lock()
+	if entry.ready {
+		fn(&entry.ctx)
+	}
+	entry.mu.Unlock()
+
+	shard.mu.Lock()
+	entry.users--
+	if entry.evict && entry.users == 0 {
+		delete(shard.entries, customerID)
+	}
+	shard.mu.Unlock()
+}
+
+// retain 客户打开连接，连接关闭前上下文不会被删除
+func (s *contextStore) retain(customerID string) {
+	shard := s.shard(customerID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	entry, ok := shard.entries[customerID]
+	if !ok {
+		entry = &contextEntry{}
+		shard.entries[customerID] = entry
+	}
+	entry.refs++
+	entry.evict = false
+}
+
+// release 客户关闭连接，所有连接关闭后删除上下文
+func (s *contextStore) release(customerID string) {
+	shard := s.shard(customerID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	entry, ok := shard.entries[customerID]
+	if !ok {
+		return
+	}
+	if entry.refs--; entry.refs > 0 {
+		return
+	}
+	if entry.users == 0 {
+		delete(shard.entries, customerID)
+		return
+	}
+	entry.evict = true
+}
+
+// len 返回保存的上下文数量
+func (s *contextStore) len() int {
+	n := 0
+	for i := range s.shards {
+		shard := &s.shards[i]
+		shard.mu.Lock()
+		n += len(shard.entries)
+		shard.mu.Unlock()
+	}
+	return n
+}
+
+// clone 深拷贝上下文，返回给调用方的副本不与处理中的消息共享 map 和切片
+func (ctx ConversationContext) clone() ConversationContext {
+	ctx.Slots = cloneMap(ctx.Slots)
+	ctx.User = cloneMap(ctx.User)
+	ctx.Memory = cloneMap(ctx.Memory)
+	ctx.Variants = cloneMap(ctx.Variants)
+	ctx.Entities = append([]Entity(nil), ctx.Entities...)
+	ctx.StateStack = append([]string(nil), ctx.StateStack...)
+	ctx.Clarification = append([]string(nil), ctx.Clarification...)
+	ctx.History = append([]string(nil), ctx.History...)
+	return ctx
+}
+
+func cloneMap[V any](m map[string]V) map[string]V {
+	if m == nil {
+		return nil
+	}
+	cloned := make(map[string]V, len(m))
+	for k, v := range m {
+		cloned[k] = v
+	}
+	return cloned
+}
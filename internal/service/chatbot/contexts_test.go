@@ -0,0 +1,120 @@
+package chatbot_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"gochat/internal/service/chatbot"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSharedEngine(tb testing.TB) (*chatbot.BotRegistry, *chatbot.ChatBotEngine) {
+	rules, err := chatbot.LoadChatBotRulesFromFile("config/chatbot_rules.yml")
+	assert.NoError(tb, err)
+	registry := chatbot.NewBotRegistry("", chatbot.RoutingPolicy{})
+	registry.Register(chatbot.DefaultBotID, rules)
+	engine := registry.Engine(nil, chatbot.DefaultBotID)
+	engine.SetClock(func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) })
+	engine.SetResponseGenerator(nil)
+	return registry, engine
+}
+
+func TestSharedEngineConcurrentSessions(t *testing.T) {
+	registry, engine := newSharedEngine(t)
+	assert.Same(t, engine, registry.Engine(nil, chatbot.DefaultBotID))
+
+	const customers = 200
+	var wg sync.WaitGroup
+	for i := 0; i < customers; i++ {
+		customerID := fmt.Sprintf("c%d", i)
+		// 同一客户的两个连接并发发送消息
+		for conn := 0; conn < 2; conn++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				engine.OpenSession(customerID)
+				engine.Respond(customerID, "你好")
+				engine.Respond(customerID, "明天天气怎么样")
+				_ = engine.GetContext(customerID).Slots["date"]
+			}()
+		}
+	}
+	wg.Wait()
+
+	for i := 0; i < customers; i++ {
+		ctx := engine.GetContext(fmt.Sprintf("c%d", i))
+		assert.Equal(t, "weather_query", ctx.CurrentState)
+		assert.Equal(t, "2024-01-02", ctx.Slots["date"])
+	}
+
+	// 规则替换后新获取的引擎使用新规则，客户上下文保留
+	rules, _ := registry.Rules(chatbot.DefaultBotID)
+	registry.Register(chatbot.DefaultBotID, rules)
+	replaced := registry.Engine(nil, chatbot.DefaultBotID)
+	assert.NotSame(t, engine, replaced)
+	assert.Equal(t, "weather_query", replaced.GetContext("c0").CurrentState)
+
+	// 所有连接关闭后释放上下文
+	engine.CloseSession("c0")
+	assert.Equal(t, "weather_query", engine.GetContext("c0").CurrentState)
+	engine.CloseSession("c0")
+	assert.Equal(t, "welcome", engine.GetContext("c0").CurrentState)
+}
+
+// BenchmarkConnectPerConnectionEngine 旧方式：每个连接编译一次规则
+func BenchmarkConnectPerConnectionEngine(b *testing.B) {
+	rules, err := chatbot.LoadChatBotRulesFromFile("config/chatbot_rules.yml")
+	assert.NoError(b, err)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			engine := chatbot.NewChatBotEngineWithRules(nil, rules)
+			engine.SetLocale("1", "zh-CN")
+		}
+	})
+}
+
+// BenchmarkConnectSharedEngine 共享引擎：连接只需获取引擎并打开会话
+func BenchmarkConnectSharedEngine(b *testing.B) {
+	registry, _ := newSharedEngine(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			customerID := fmt.Sprintf("conn-%p-%d", pb, i)
+			engine := registry.Engine(nil, chatbot.DefaultBotID)
+			engine.OpenSession(customerID)
+			engine.SetLocale(customerID, "zh-CN")
+			engine.CloseSession(customerID)
+			i++
+		}
+	})
+}
+
+// BenchmarkRespondConcurrentSessions 数千个会话并发收发消息
+func BenchmarkRespondConcurrentSessions(b *testing.B) {
+	messages := []string{"你好", "明天北京天气怎么样", "帮助", "返回", "转人工"}
+	for _, sessions := range []int{1000, 5000} {
+		b.Run(fmt.Sprintf("sessions=%d", sessions), func(b *testing.B) {
+			_, engine := newSharedEngine(b)
+			customerIDs := make([]string, sessions)
+			for i := range customerIDs {
+				customerIDs[i] = fmt.Sprintf("s%d", i)
+				engine.OpenSession(customerIDs[i])
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					engine.Respond(customerIDs[i%sessions], messages[i%len(messages)])
+					i++
+				}
+			})
+		})
+	}
+}
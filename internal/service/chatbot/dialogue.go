@@ -0,0 +1,115 @@
+package chatbot
+
+import "log"
+
+// 内置对话控制动作
+const (
+	ActionBack  = "back"  // 返回子对话入口或上一个状态
+	ActionReset = "reset" // 清空上下文，从欢迎状态重新开始
+)
+
+// maxStateHistory 保留的状态历史长度，用于 back 动作
+const maxStateHistory = 20
+
+// resolveState 返回当前状态定义，未定义的状态回退到欢迎状态
+func (e *ChatBotEngine) resolveState(ctx *ConversationContext) *State {
+	if state := e.rules.findState(ctx.CurrentState); state != nil {
+		return state
+	}
+	state := e.rules.findState("welcome")
+	if state != nil {
+		ctx.CurrentState = state.Name // 更新上下文状态
+	}
+	return state
+}
+
+// matchTransition 先匹配当前状态的转移，再匹配全局转移；配置了条件的转移在条件满足时才匹配
+func (e *ChatBotEngine) matchTransition(state *State, intent string, ctx *ConversationContext) (Transition, bool) {
+	vars := map[string]interface{}{
+		"user":   ctx.User,
+		"slot":   ctx.Slots,
+		"memory": ctx.Memory,
+	}
+	matches := func(transition Transition) bool {
+		if transition.Intent != intent {
+			return false
+		}
+		if transition.Condition == "" {
+			return true
+		}
+		ok, err := evalCondition(transition.Condition, vars)
+		if err != nil {
+			log.Printf("转移条件错误 %q: %v", transition.Condition, err)
+		}
+		return ok
+	}
+
+	if state != nil {
+		for _, transition := range state.Transitions {
+			if matches(transition) {
+				return transition, true
+			}
+		}
+	}
+	for _, transition := range e.rules.DialogueFlow.GlobalTransitions {
+		if matches(transition) {
+			return transition, true
+		}
+	}
+	return Transition{}, false
+}
+
+// applyTransition 执行转移动作并进入下一个状态；push 为 true 时记录入口，子对话结束后返回
+func (e *ChatBotEngine) applyTransition(transition Transition, ctx *ConversationContext) Reply {
+	reply, stateChanged := e.executeActions(transition.Actions, ctx)
+	if stateChanged {
+		return reply
+	}
+	if nextState := e.rules.findState(transition.NextState); nextState != nil {
+		if transition.Push && nextState.Name != ctx.CurrentState {
+			ctx.StateStack = append(ctx.StateStack, ctx.CurrentState)
+		}
+		moveToState(ctx, nextState.Name) // 更新到下一个状态
+	}
+	return reply
+}
+
+// moveToState 切换状态并记录历史
+func moveToState(ctx *ConversationContext, state string) {
+	if state == ctx.CurrentState {
+		return
+	}
+	ctx.History = append(ctx.History, ctx.CurrentState)
+	if len(ctx.History) > maxStateHistory {
+		ctx.History = ctx.History[len(ctx.History)-maxStateHistory:]
+	}
+	ctx.CurrentState = state
+}
+
+// goBack 优先返回子对话入口，否则回到上一个状态，没有可返回的状态时返回 false；
+// 进入子对话时入口同时记入了历史，返回入口时一并移除，下一次返回继续回退
+func goBack(ctx *ConversationContext) bool {
+	if n := len(ctx.StateStack); n > 0 {
+		ctx.CurrentState = ctx.StateStack[n-1]
+		ctx.StateStack = ctx.StateStack[:n-1]
+		if m := len(ctx.History); m > 0 && ctx.History[m-1] == ctx.CurrentState {
+			ctx.History = ctx.History[:m-1]
+		}
+		return true
+	}
+	if n := len(ctx.History); n > 0 {
+		ctx.CurrentState = ctx.History[n-1]
+		ctx.History = ctx.History[:n-1]
+		return true
+	}
+	return false
+}
+
+// resetDialogue 清空槽位、状态栈和历史，回到欢迎状态
+func resetDialogue(ctx *ConversationContext) {
+	ctx.CurrentState = "welcome"
+	ctx.Slots = make(map[string]string)
+	ctx.StateStack = nil
+	ctx.History = nil
+	ctx.FallbackCount = 0
+}
@@ -0,0 +1,135 @@
+package chatbot
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// 内置实体类型，同时也是默认填充的槽位名
+const (
+	EntityDate        = "date"
+	EntityTime        = "time"
+	EntityNumber      = "number"
+	EntityAmount      = "amount"
+	EntityPhone       = "phone"
+	EntityEmail       = "email"
+	EntityOrderNumber = "order_number"
+	EntityCity        = "city"
+)
+
+// Entity 从用户消息中抽取的实体
+type Entity struct {
+	Type  string `json:"type"`
+	Value string `json:"value"` // 归一化后的值，如日期为 2006-01-02，时间为 15:04
+	Text  string `json:"text"`  // 原文片段
+	Start int    `json:"start"` // 原文中的字节偏移
+	End   int    `json:"end"`
+}
+
+// EntityExtractor 实体抽取器，now 用于解析“明天”等相对日期
+type EntityExtractor interface {
+	Name() string
+	Extract(text string, now time.Time) []Entity
+}
+
+// EntityConfig 实体抽取配置
+type EntityConfig struct {
+	Extractors         []string          `mapstructure:"extractors"`           // 启用的抽取器，按优先级排列，重叠的片段由靠前的抽取器保留
+	Slots              map[string]string `mapstructure:"slots"`                // 实体类型 → 槽位名，未配置时槽位名与类型相同
+	OrderNumberPattern string            `mapstructure:"order_number_pattern"` // 订单号正则，有分组时取第一个分组
+	Cities             []string          `mapstructure:"cities"`               // 追加到内置城市词典，可写作 "上海:shanghai" 配置别名
+}
+
+// EntityExtractorFactory 根据配置创建抽取器
+type EntityExtractorFactory func(config EntityConfig) (EntityExtractor, error)
+
+var entityExtractorFactories = map[string]EntityExtractorFactory{}
+
+// RegisterEntityExtractor 注册抽取器，规则中按名称启用；同名注册会覆盖内置抽取器
+func RegisterEntityExtractor(name string, factory EntityExtractorFactory) {
+	entityExtractorFactories[name] = factory
+}
+
+// newEntityExtractors 按配置创建抽取器
+func newEntityExtractors(config EntityConfig) ([]EntityExtractor, error) {
+	extractors := make([]EntityExtractor, 0, len(config.Extractors))
+	for _, name := range config.Extractors {
+		factory, ok := entityExtractorFactories[name]
+		if !ok {
+			return nil, fmt.Errorf("实体抽取器 %s 未注册", name)
+		}
+		extractor, err := factory(config)
+		if err != nil {
+			return nil, fmt.Errorf("实体抽取器 %s 创建失败: %w", name, err)
+		}
+		extractors = append(extractors, extractor)
+	}
+	return extractors, nil
+}
+
+// AddEntityExtractor 追加抽取器，优先级低于规则中配置的抽取器
+func (e *ChatBotEngine) AddEntityExtractor(extractor EntityExtractor) {
+	e.extractors = append(e.extractors, extractor)
+}
+
+// ExtractEntities 依次运行抽取器，与已抽取片段重叠的结果被丢弃，按出现位置排序
+func ExtractEntities(extractors []EntityExtractor, text string, now time.Time) []Entity {
+	var entities []Entity
+	for _, extractor := range extractors {
+		for _, entity := range extractor.Extract(text, now) {
+			if !overlaps(entities, entity) {
+				entities = append(entities, entity)
+			}
+		}
+	}
+	sort.SliceStable(entities, func(i, j int) bool {
+		return entities[i].Start < entities[j].Start
+	})
+	return entities
+}
+
+func overlaps(entities []Entity, candidate Entity) bool {
+	for _, entity := range entities {
+		if candidate.Start < entity.End && entity.Start < candidate.End {
+			return true
+		}
+	}
+	return false
+}
+
+// fillSlots 抽取实体并填充槽位，同类型实体取第一个
+func (e *ChatBotEngine) fillSlots(message string, ctx *ConversationContext) {
+	ctx.Entities = nil
+	if len(e.extractors) == 0 {
+		return
+	}
+	ctx.Entities = ExtractEntities(e.extractors, message, e.now().In(e.location))
+
+	filled := make(map[string]bool)
+	for _, entity := range ctx.Entities {
+		slot := entity.Type
+		if mapped, ok := e.rules.ContextManagement.EntityExtraction.Slots[entity.Type]; ok {
+			slot = mapped
+		}
+		if slot == "" || filled[slot] {
+			continue
+		}
+		if ctx.Slots == nil {
+			ctx.Slots = make(map[string]string)
+		}
+		ctx.Slots[slot] = entity.Value
+		filled[slot] = true
+	}
+}
+
+// loadEntityExtractors 创建规则中配置的抽取器，配置无效时不抽取实体
+func loadEntityExtractors(config EntityConfig) []EntityExtractor {
+	extractors, err := newEntityExtractors(config)
+	if err != nil {
+		log.Printf("实体抽取配置无效: %v", err)
+		return nil
+	}
+	return extractors
+}
@@ -0,0 +1,517 @@
+package chatbot
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+func init() {
+	RegisterEntityExtractor(EntityDate, func(EntityConfig) (EntityExtractor, error) { return dateExtractor{}, nil })
+	RegisterEntityExtractor(EntityTime, func(EntityConfig) (EntityExtractor, error) { return timeExtractor{}, nil })
+	RegisterEntityExtractor(EntityNumber, func(EntityConfig) (EntityExtractor, error) { return numberExtractor{}, nil })
+	RegisterEntityExtractor(EntityAmount, func(EntityConfig) (EntityExtractor, error) { return amountExtractor{}, nil })
+	RegisterEntityExtractor(EntityPhone, func(EntityConfig) (EntityExtractor, error) { return phoneExtractor{}, nil })
+	RegisterEntityExtractor(EntityEmail, func(EntityConfig) (EntityExtractor, error) { return emailExtractor{}, nil })
+	RegisterEntityExtractor(EntityOrderNumber, newOrderNumberExtractor)
+	RegisterEntityExtractor(EntityCity, newCityExtractor)
+}
+
+// regexEntities 按正则抽取实体，value 为空时跳过该匹配
+func regexEntities(re *regexp.Regexp, entityType, text string, value func(match []string) string) []Entity {
+	var entities []Entity
+	for _, loc := range re.FindAllStringSubmatchIndex(text, -1) {
+		match := make([]string, len(loc)/2)
+		for i := range match {
+			if loc[2*i] >= 0 {
+				match[i] = text[loc[2*i]:loc[2*i+1]]
+			}
+		}
+		if v := value(match); v != "" {
+			entities = append(entities, Entity{Type: entityType, Value: v, Text: match[0], Start: loc[0], End: loc[1]})
+		}
+	}
+	return entities
+}
+
+// digitBounded 匹配片段前后不是数字，避免从更长的数字串中截取
+func digitBounded(text string, start, end int) bool {
+	if r, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && unicode.IsDigit(r) {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && unicode.IsDigit(r) {
+		return false
+	}
+	return true
+}
+
+// ---------- 日期 ----------
+
+var (
+	relativeDays = map[string]int{
+		"大后天": 3, "后天": 2, "明天": 1, "明日": 1, "今天": 0, "今日": 0, "昨天": -1, "昨日": -1, "前天": -2,
+		"day after tomorrow": 2, "tomorrow": 1, "today": 0, "tonight": 0, "yesterday": -1,
+	}
+	relativeDayPattern = regexp.MustCompile(`(?i)大后天|后天|明天|明日|今天|今日|昨天|昨日|前天|\bday after tomorrow\b|\btomorrow\b|\btoday\b|\btonight\b|\byesterday\b`)
+
+	zhWeekdays = map[string]time.Weekday{
+		"一": time.Monday, "二": time.Tuesday, "三": time.Wednesday, "四": time.Thursday,
+		"五": time.Friday, "六": time.Saturday, "日": time.Sunday, "天": time.Sunday,
+		"1": time.Monday, "2": time.Tuesday, "3": time.Wednesday, "4": time.Thursday,
+		"5": time.Friday, "6": time.Saturday, "7": time.Sunday,
+	}
+	zhWeekdayPattern = regexp.MustCompile(`(这|本|下|上)?(?:周|星期|礼拜)([一二三四五六日天1-7])`)
+
+	enWeekdays = map[string]time.Weekday{
+		"monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday, "thursday": time.Thursday,
+		"friday": time.Friday, "saturday": time.Saturday, "sunday": time.Sunday,
+	}
+	enWeekdayPattern = regexp.MustCompile(`(?i)\b(?:(this|next|last)\s+)?(monday|tuesday|wednesday|thursday|friday|saturday|sunday)\b`)
+
+	isoDatePattern = regexp.MustCompile(`(\d{4})[-/.](\d{1,2})[-/.](\d{1,2})`)
+	zhDatePattern  = regexp.MustCompile(`(?:(\d{4})年)?(\d{1,2})月(\d{1,2})[日号]`)
+
+	enMonths = map[string]time.Month{
+		"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April, "may": time.May, "jun": time.June,
+		"jul": time.July, "aug": time.August, "sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+	}
+	enMonthName       = `(jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|sep(?:t(?:ember)?)?|oct(?:ober)?|nov(?:ember)?|dec(?:ember)?)`
+	enMonthDayPattern = regexp.MustCompile(`(?i)\b` + enMonthName + `\s+(\d{1,2})(?:st|nd|rd|th)?(?:,?\s+(\d{4}))?\b`)
+	enDayMonthPattern = regexp.MustCompile(`(?i)\b(\d{1,2})(?:st|nd|rd|th)?\s+` + enMonthName + `(?:,?\s+(\d{4}))?\b`)
+)
+
+// dateExtractor 中英文相对日期、星期和绝对日期，值为 2006-01-02
+type dateExtractor struct{}
+
+func (dateExtractor) Name() string { return EntityDate }
+
+func (dateExtractor) Extract(text string, now time.Time) []Entity {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	format := func(t time.Time) string { return t.Format("2006-01-02") }
+	date := func(year, month, day string) string {
+		y := today.Year()
+		if year != "" {
+			y, _ = strconv.Atoi(year)
+		}
+		m, _ := strconv.Atoi(month)
+		d, _ := strconv.Atoi(day)
+		t := time.Date(y, time.Month(m), d, 0, 0, 0, 0, today.Location())
+		if m < 1 || m > 12 || t.Day() != d {
+			return "" // 无效日期，如 2月30日
+		}
+		return format(t)
+	}
+
+	var entities []Entity
+	entities = append(entities, regexEntities(isoDatePattern, EntityDate, text, func(m []string) string {
+		return date(m[1], m[2], m[3])
+	})...)
+	entities = append(entities, regexEntities(zhDatePattern, EntityDate, text, func(m []string) string {
+		return date(m[1], m[2], m[3])
+	})...)
+	entities = append(entities, regexEntities(enMonthDayPattern, EntityDate, text, func(m []string) string {
+		return date(m[3], strconv.Itoa(int(enMonths[strings.ToLower(m[1])[:3]])), m[2])
+	})...)
+	entities = append(entities, regexEntities(enDayMonthPattern, EntityDate, text, func(m []string) string {
+		return date(m[3], strconv.Itoa(int(enMonths[strings.ToLower(m[2])[:3]])), m[1])
+	})...)
+	entities = append(entities, regexEntities(relativeDayPattern, EntityDate, text, func(m []string) string {
+		return format(today.AddDate(0, 0, relativeDays[strings.ToLower(m[0])]))
+	})...)
+	entities = append(entities, regexEntities(zhWeekdayPattern, EntityDate, text, func(m []string) string {
+		return format(weekdayDate(today, zhWeekdays[m[2]], m[1]))
+	})...)
+	entities = append(entities, regexEntities(enWeekdayPattern, EntityDate, text, func(m []string) string {
+		modifier := map[string]string{"this": "本", "next": "下", "last": "上"}[strings.ToLower(m[1])]
+		return format(weekdayDate(today, enWeekdays[strings.ToLower(m[2])], modifier))
+	})...)
+	return dedupe(entities)
+}
+
+// weekdayDate 计算星期对应的日期：本周/下周/上周按周一为一周开始；无修饰时取今天起最近的一天
+func weekdayDate(today time.Time, weekday time.Weekday, modifier string) time.Time {
+	offset := func(w time.Weekday) int { return (int(w) + 6) % 7 } // 周一为0
+	switch modifier {
+	case "这", "本":
+		return today.AddDate(0, 0, offset(weekday)-offset(today.Weekday()))
+	case "下":
+		return today.AddDate(0, 0, offset(weekday)-offset(today.Weekday())+7)
+	case "上":
+		return today.AddDate(0, 0, offset(weekday)-offset(today.Weekday())-7)
+	}
+	return today.AddDate(0, 0, (int(weekday)-int(today.Weekday())+7)%7)
+}
+
+// dedupe 同一抽取器内部重叠的结果保留先出现的（更具体的模式排在前面）
+func dedupe(entities []Entity) []Entity {
+	var kept []Entity
+	for _, entity := range entities {
+		if !overlaps(kept, entity) {
+			kept = append(kept, entity)
+		}
+	}
+	return kept
+}
+
+// ---------- 时间 ----------
+
+var (
+	zhHour         = `\d{1,2}|[零一二两三四五六七八九十]{1,3}`
+	zhTimePeriod   = `(凌晨|早上|早晨|上午|中午|下午|傍晚|晚上|今晚)?`
+	zhClockPattern = regexp.MustCompile(zhTimePeriod + `(` + zhHour + `)[点时](?:(半|一刻|三刻)|(` + zhHour + `)分?)?(?:钟|整)?`)
+	colonPattern   = regexp.MustCompile(`(?i)` + zhTimePeriod + `\b(\d{1,2})[:：](\d{2})(?:\s*([ap])\.?m\b\.?|\b)`)
+	enClockPattern = regexp.MustCompile(`(?i)(\bat\s+)?\b(\d{1,2})(?:\s*([ap])\.?m\b\.?|(\s*o'clock)|\b)|\b(noon|midnight)\b`)
+
+	zhQuarters = map[string]int{"半": 30, "一刻": 15, "三刻": 45}
+)
+
+// timeExtractor 中英文时刻，如 明天下午3点半、9:30、tomorrow at 9、7pm，值为 15:04
+type timeExtractor struct{}
+
+func (timeExtractor) Name() string { return EntityTime }
+
+func (timeExtractor) Extract(text string, _ time.Time) []Entity {
+	var entities []Entity
+	entities = append(entities, regexEntities(colonPattern, EntityTime, text, func(m []string) string {
+		hour, _ := strconv.Atoi(m[2])
+		minute, _ := strconv.Atoi(m[3])
+		if m[4] != "" {
+			return formatClock(meridiemHour(hour, strings.ToLower(m[4])), minute)
+		}
+		return formatClock(periodHour(hour, m[1]), minute)
+	})...)
+	entities = append(entities, regexEntities(zhClockPattern, EntityTime, text, func(m []string) string {
+		hour, ok := parseClockNumber(m[2])
+		if !ok || (m[2] == "一" && m[1] == "" && m[3] == "" && m[4] == "") {
+			return "" // “快一点”“好一点”不是时间
+		}
+		minute := zhQuarters[m[3]]
+		if m[4] != "" {
+			if minute, ok = parseClockNumber(m[4]); !ok {
+				return ""
+			}
+		}
+		return formatClock(periodHour(hour, m[1]), minute)
+	})...)
+	entities = append(entities, regexEntities(enClockPattern, EntityTime, text, func(m []string) string {
+		switch {
+		case strings.EqualFold(m[5], "noon"):
+			return "12:00"
+		case m[5] != "":
+			return "00:00"
+		case m[3] != "":
+			hour, _ := strconv.Atoi(m[2])
+			if hour < 1 || hour > 12 {
+				return ""
+			}
+			return formatClock(meridiemHour(hour, strings.ToLower(m[3])), 0)
+		case m[1] != "" || m[4] != "":
+			hour, _ := strconv.Atoi(m[2])
+			return formatClock(hour, 0)
+		}
+		return "" // 单独的数字不是时间
+	})...)
+	return dedupe(entities)
+}
+
+// parseClockNumber 解析阿拉伯数字或中文数字的钟点
+func parseClockNumber(word string) (int, bool) {
+	if n, err := strconv.Atoi(word); err == nil {
+		return n, true
+	}
+	return parseChineseNumber(word)
+}
+
+// periodHour 按“下午”“晚上”等时段换算为24小时制
+func periodHour(hour int, period string) int {
+	switch period {
+	case "下午", "傍晚", "晚上", "今晚":
+		if hour < 12 {
+			return hour + 12
+		}
+	case "中午":
+		if hour < 11 {
+			return hour + 12
+		}
+	case "凌晨":
+		if hour == 12 {
+			return 0
+		}
+	}
+	return hour
+}
+
+// meridiemHour 按 am/pm 换算为24小时制
+func meridiemHour(hour int, meridiem string) int {
+	if meridiem == "p" && hour < 12 {
+		return hour + 12
+	}
+	if meridiem == "a" && hour == 12 {
+		return 0
+	}
+	return hour
+}
+
+// formatClock 超出范围时返回空，如 25点
+func formatClock(hour, minute int) string {
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return ""
+	}
+	return fmt.Sprintf("%02d:%02d", hour, minute)
+}
+
+// ---------- 数字与金额 ----------
+
+var (
+	arabicNumberPattern  = regexp.MustCompile(`-?\d+(?:,\d{3})*(?:\.\d+)?`)
+	chineseNumberPattern = regexp.MustCompile(`[零一二两三四五六七八九十百千万]+`)
+	chineseMeasureWords  = "个件台份张位次天元块只瓶箱人"
+
+	amountPattern = regexp.MustCompile(`(?i)(¥|￥|\$|rmb|cny|usd)\s*(\d+(?:,\d{3})*(?:\.\d+)?)|(\d+(?:,\d{3})*(?:\.\d+)?)\s*(元|块钱|块|美元|rmb|cny|usd|dollars?)`)
+	currencies    = map[string]string{
+		"¥": "CNY", "￥": "CNY", "rmb": "CNY", "cny": "CNY", "元": "CNY", "块": "CNY", "块钱": "CNY",
+		"$": "USD", "usd": "USD", "美元": "USD", "dollar": "USD", "dollars": "USD",
+	}
+)
+
+// numberExtractor 阿拉伯数字和中文数字，值为十进制数字字符串
+type numberExtractor struct{}
+
+func (numberExtractor) Name() string { return EntityNumber }
+
+func (numberExtractor) Extract(text string, _ time.Time) []Entity {
+	entities := regexEntities(arabicNumberPattern, EntityNumber, text, func(m []string) string {
+		return strings.ReplaceAll(m[0], ",", "")
+	})
+	for _, loc := range chineseNumberPattern.FindAllStringIndex(text, -1) {
+		word := text[loc[0]:loc[1]]
+		// 单个中文数字需后接量词，避免“一下”“一起”被误识别
+		if utf8.RuneCountInString(word) == 1 {
+			next, _ := utf8.DecodeRuneInString(text[loc[1]:])
+			if !strings.ContainsRune(chineseMeasureWords, next) {
+				continue
+			}
+		}
+		if value, ok := parseChineseNumber(word); ok {
+			entities = append(entities, Entity{Type: EntityNumber, Value: strconv.Itoa(value), Text: word, Start: loc[0], End: loc[1]})
+		}
+	}
+	sort.SliceStable(entities, func(i, j int) bool { return entities[i].Start < entities[j].Start })
+	return entities
+}
+
+// parseChineseNumber 解析一万以内及“X万”形式的中文数字，如 三百二十五、两万、十二
+func parseChineseNumber(word string) (int, bool) {
+	digits := map[rune]int{'零': 0, '一': 1, '二': 2, '两': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+	units := map[rune]int{'十': 10, '百': 100, '千': 1000}
+
+	var total, section, digit int
+	hasDigit := false
+	for _, r := range word {
+		switch {
+		case r == '万':
+			section = (section + digit) * 10000
+			total += section
+			section, digit = 0, 0
+		case units[r] > 0:
+			if digit == 0 && !hasDigit {
+				digit = 1 // “十二”省略了“一”
+			}
+			section += digit * units[r]
+			digit, hasDigit = 0, false
+		default:
+			d, ok := digits[r]
+			if !ok {
+				return 0, false
+			}
+			digit, hasDigit = d, true
+		}
+	}
+	return total + section + digit, true
+}
+
+// amountExtractor 带货币符号或单位的金额，值如 "99.5 CNY"
+type amountExtractor struct{}
+
+func (amountExtractor) Name() string { return EntityAmount }
+
+func (amountExtractor) Extract(text string, _ time.Time) []Entity {
+	return regexEntities(amountPattern, EntityAmount, text, func(m []string) string {
+		number, unit := m[2], m[1]
+		if number == "" {
+			number, unit = m[3], m[4]
+		}
+		return strings.ReplaceAll(number, ",", "") + " " + currencies[strings.ToLower(unit)]
+	})
+}
+
+// ---------- 联系方式与订单号 ----------
+
+var (
+	mobilePattern   = regexp.MustCompile(`(?:\+?86[- ]?)?(1[3-9]\d)[- ]?(\d{4})[- ]?(\d{4})`)
+	landlinePattern = regexp.MustCompile(`0\d{2,3}-\d{7,8}`)
+	emailPattern    = regexp.MustCompile(`(?i)[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}`)
+
+	defaultOrderNumberPattern = `(?i)(?:订单号?|单号|order\s*(?:no\.?|number|#)?)\s*(?:是|为|:|：|#)?\s*([a-z0-9][a-z0-9\-]{5,31})`
+)
+
+// phoneExtractor 手机号（可带 +86）和带区号的固定电话，手机号值为11位数字
+type phoneExtractor struct{}
+
+func (phoneExtractor) Name() string { return EntityPhone }
+
+func (phoneExtractor) Extract(text string, _ time.Time) []Entity {
+	var entities []Entity
+	for _, entity := range regexEntities(mobilePattern, EntityPhone, text, func(m []string) string {
+		return m[1] + m[2] + m[3]
+	}) {
+		if digitBounded(text, entity.Start, entity.End) {
+			entities = append(entities, entity)
+		}
+	}
+	for _, entity := range regexEntities(landlinePattern, EntityPhone, text, func(m []string) string { return m[0] }) {
+		if digitBounded(text, entity.Start, entity.End) && !overlaps(entities, entity) {
+			entities = append(entities, entity)
+		}
+	}
+	return entities
+}
+
+// emailExtractor 邮箱地址，值为小写
+type emailExtractor struct{}
+
+func (emailExtractor) Name() string { return EntityEmail }
+
+func (emailExtractor) Extract(text string, _ time.Time) []Entity {
+	return regexEntities(emailPattern, EntityEmail, text, func(m []string) string {
+		return strings.ToLower(m[0])
+	})
+}
+
+// orderNumberExtractor 订单号，默认识别“订单号 XXX”“order #XXX”，值为大写
+type orderNumberExtractor struct {
+	pattern *regexp.Regexp
+}
+
+func newOrderNumberExtractor(config EntityConfig) (EntityExtractor, error) {
+	pattern := config.OrderNumberPattern
+	if pattern == "" {
+		pattern = defaultOrderNumberPattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return orderNumberExtractor{pattern: re}, nil
+}
+
+func (orderNumberExtractor) Name() string { return EntityOrderNumber }
+
+func (x orderNumberExtractor) Extract(text string, _ time.Time) []Entity {
+	return regexEntities(x.pattern, EntityOrderNumber, text, func(m []string) string {
+		if len(m) > 1 && m[1] != "" {
+			return strings.ToUpper(m[1])
+		}
+		return strings.ToUpper(m[0])
+	})
+}
+
+// ---------- 城市 ----------
+
+// defaultCities 内置城市词典，冒号后为英文别名
+var defaultCities = []string{
+	"北京:beijing,peking", "上海:shanghai", "广州:guangzhou,canton", "深圳:shenzhen", "天津:tianjin", "重庆:chongqing",
+	"杭州:hangzhou", "南京:nanjing", "苏州:suzhou", "武汉:wuhan", "成都:chengdu", "西安:xi'an,xian",
+	"长沙:changsha", "郑州:zhengzhou", "青岛:qingdao", "大连:dalian", "厦门:xiamen", "福州:fuzhou",
+	"济南:jinan", "沈阳:shenyang", "哈尔滨:harbin", "长春:changchun", "昆明:kunming", "贵阳:guiyang",
+	"南宁:nanning", "合肥:hefei", "南昌:nanchang", "太原:taiyuan", "石家庄:shijiazhuang", "兰州:lanzhou",
+	"乌鲁木齐:urumqi", "拉萨:lhasa", "海口:haikou", "三亚:sanya", "宁波:ningbo", "无锡:wuxi",
+	"香港:hong kong,hongkong", "澳门:macau,macao", "台北:taipei",
+}
+
+// cityExtractor 按城市词典最长匹配，值为中文城市名（不含“市”）
+type cityExtractor struct {
+	names []cityName // 按长度降序
+}
+
+type cityName struct {
+	text  string
+	city  string
+	latin bool
+}
+
+func newCityExtractor(config EntityConfig) (EntityExtractor, error) {
+	var names []cityName
+	for _, entry := range append(append([]string{}, defaultCities...), config.Cities...) {
+		city, aliases, _ := strings.Cut(entry, ":")
+		city = strings.TrimSuffix(strings.TrimSpace(city), "市")
+		if city == "" {
+			continue
+		}
+		names = append(names, cityName{text: city, city: city})
+		for _, alias := range strings.Split(aliases, ",") {
+			if alias = strings.ToLower(strings.TrimSpace(alias)); alias != "" {
+				names = append(names, cityName{text: alias, city: city, latin: true})
+			}
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool { return len(names[i].text) > len(names[j].text) })
+	return cityExtractor{names: names}, nil
+}
+
+func (cityExtractor) Name() string { return EntityCity }
+
+func (x cityExtractor) Extract(text string, _ time.Time) []Entity {
+	lower := asciiLower(text)
+	var entities []Entity
+	for _, name := range x.names {
+		for offset := 0; ; {
+			idx := strings.Index(lower[offset:], name.text)
+			if idx < 0 {
+				break
+			}
+			start := offset + idx
+			end := start + len(name.text)
+			offset = end
+			if name.latin && !wordBounded(lower, start, end) {
+				continue
+			}
+			if strings.HasPrefix(text[end:], "市") {
+				end += len("市")
+			}
+			entity := Entity{Type: EntityCity, Value: name.city, Text: text[start:end], Start: start, End: end}
+			if !overlaps(entities, entity) {
+				entities = append(entities, entity)
+			}
+		}
+	}
+	sort.SliceStable(entities, func(i, j int) bool { return entities[i].Start < entities[j].Start })
+	return entities
+}
+
+// wordBounded 英文别名前后不能紧跟字母，避免 "xianxia" 之类的误匹配
+func wordBounded(text string, start, end int) bool {
+	if r, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && unicode.IsLetter(r) && r < unicode.MaxASCII {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && unicode.IsLetter(r) && r < unicode.MaxASCII {
+		return false
+	}
+	return true
+}
+
+// asciiLower 仅转换 ASCII 字母，保证偏移与原文一致
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,68 @@
+package chatbot_test
+
+import (
+	"testing"
+	"time"
+
+	"gochat/internal/service/chatbot"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractEntities(t *testing.T) {
+	rules, err := chatbot.LoadChatBotRulesFromFile("config/chatbot_rules.yml")
+	assert.NoError(t, err)
+	engine := chatbot.NewChatBotEngineWithRules(nil, rules)
+
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	// 2024-01-01 为周一
+	engine.SetClock(func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, loc) })
+
+	cases := []struct {
+		text string
+		want map[string]string
+	}{
+		{"明天北京天气", map[string]string{"date": "2024-01-02", "city": "北京"}},
+		{"大后天上海市下雨吗", map[string]string{"date": "2024-01-04", "city": "上海"}},
+		{"下周五去深圳", map[string]string{"date": "2024-01-12", "city": "深圳"}},
+		{"周日", map[string]string{"date": "2024-01-07"}},
+		{"3月5日杭州", map[string]string{"date": "2024-03-05", "city": "杭州"}},
+		{"2024/02/29 的天气", map[string]string{"date": "2024-02-29"}},
+		{"weather in Beijing tomorrow", map[string]string{"date": "2024-01-02", "city": "北京"}},
+		{"next friday in shanghai", map[string]string{"date": "2024-01-12", "city": "上海"}},
+		{"March 5th, 2025", map[string]string{"date": "2025-03-05"}},
+		{"退款99.5元", map[string]string{"amount": "99.5 CNY"}},
+		{"price is $1,200", map[string]string{"amount": "1200 USD"}},
+		{"我的手机是+86 138-0013-8000", map[string]string{"phone": "13800138000"}},
+		{"座机 010-12345678", map[string]string{"phone": "010-12345678"}},
+		{"邮箱 Alice.Wang@Example.com", map[string]string{"email": "alice.wang@example.com"}},
+		{"订单号：ab20240101001 没收到", map[string]string{"order_number": "AB20240101001"}},
+		{"order #A1234567", map[string]string{"order_number": "A1234567"}},
+	}
+	for _, c := range cases {
+		engine.Respond("7001", c.text)
+		slots := engine.GetContext("7001").Slots
+		for slot, want := range c.want {
+			assert.Equal(t, want, slots[slot], "%s: %s", c.text, slot)
+		}
+		engine.Respond("7001", "重新开始")
+	}
+}
+
+func TestExtractNumbers(t *testing.T) {
+	rules, err := chatbot.LoadChatBotRulesFromFile("config/chatbot_rules.yml")
+	assert.NoError(t, err)
+	engine := chatbot.NewChatBotEngineWithRules(nil, rules)
+
+	engine.Respond("7002", "我要三百二十五个，再加12件，查一下")
+	var numbers []string
+	for _, entity := range engine.GetContext("7002").Entities {
+		if entity.Type == chatbot.EntityNumber {
+			numbers = append(numbers, entity.Value)
+		}
+	}
+	assert.Equal(t, []string{"325", "12"}, numbers)
+	// 数字配置为不填充槽位
+	_, ok := engine.GetContext("7002").Slots["number"]
+	assert.False(t, ok)
+}
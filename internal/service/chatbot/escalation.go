@@ -0,0 +1,105 @@
+package chatbot
+
+import (
+	"log"
+
+	"gochat/internal/service/event"
+	"gochat/internal/service/i18n"
+)
+
+// EventEscalation 会话升级事件，Payload 为 Escalation
+const EventEscalation = "chatbot.escalation"
+
+// ActionRedirectToHuman 升级动作：转接人工
+const ActionRedirectToHuman = "redirect_to_human"
+
+// Escalation 会话升级事件内容
+type Escalation struct {
+	BotID         string `json:"bot_id"`
+	CustomerID    string `json:"customer_id"`
+	State         string `json:"state"`
+	Condition     string `json:"condition"` // 触发的升级条件
+	Action        string `json:"action"`
+	FallbackCount int    `json:"fallback_count"`
+}
+
+// EscalationRule 升级规则，条件可使用 fallback.count、error.code、user.*、slot.*
+type EscalationRule struct {
+	Condition string            `mapstructure:"condition"`
+	Action    string            `mapstructure:"action"`
+	Content   string            `mapstructure:"content"` // 升级时的回复，为空时使用 escalation_message
+	I18n      map[string]string `mapstructure:"i18n"`
+}
+
+// FallbackTemplate 连续兜底时轮换使用的话术
+type FallbackTemplate struct {
+	Content string            `mapstructure:"content"`
+	I18n    map[string]string `mapstructure:"i18n"`
+}
+
+// retryPolicyCondition 由 retry_policy.max_attempts 生成的隐式升级规则
+const retryPolicyCondition = "retry_policy.max_attempts"
+
+// SetEventBus 替换事件总线，默认使用 event.Default
+func (e *ChatBotEngine) SetEventBus(bus *event.Bus) {
+	e.bus = bus
+}
+
+// handleFallback 记录连续兜底次数，按次数轮换话术，达到阈值时升级
+func (e *ChatBotEngine) handleFallback(reply Reply, ctx *ConversationContext) Reply {
+	ctx.FallbackCount++
+	handling := e.rules.ErrorHandling
+
+	if rule, ok := e.matchEscalation(ctx); ok {
+		e.bus.Publish(EventEscalation, Escalation{
+			BotID:         e.botID,
+			CustomerID:    ctx.CustomerID,
+			State:         ctx.CurrentState,
+			Condition:     rule.Condition,
+			Action:        rule.Action,
+			FallbackCount: ctx.FallbackCount,
+		})
+		ctx.FallbackCount, ctx.LastErrorCode = 0, 0
+
+		reply.Text = i18n.Pick(handling.EscalationMessageI18n, ctx.Locale, handling.EscalationMessage)
+		if rule.Content != "" {
+			reply.Text = i18n.Pick(rule.I18n, ctx.Locale, rule.Content)
+		}
+		reply.Handoff = rule.Action == ActionRedirectToHuman
+		reply.Escalated = true
+		return reply
+	}
+
+	// 超过 rephrase_after 次后换一种说法
+	if templates := handling.FallbackTemplates; len(templates) > 0 && ctx.FallbackCount > handling.RephraseAfter {
+		template := templates[(ctx.FallbackCount-handling.RephraseAfter-1)%len(templates)]
+		reply.Text = i18n.Pick(template.I18n, ctx.Locale, template.Content)
+	}
+	return reply
+}
+
+// matchEscalation 依次匹配配置的升级规则和重试策略
+func (e *ChatBotEngine) matchEscalation(ctx *ConversationContext) (EscalationRule, bool) {
+	vars := map[string]interface{}{
+		"fallback": map[string]interface{}{"count": ctx.FallbackCount},
+		"error":    map[string]interface{}{"code": ctx.LastErrorCode},
+		"user":     ctx.User,
+		"slot":     ctx.Slots,
+		"memory":   ctx.Memory,
+	}
+	for _, rule := range e.rules.ErrorHandling.EscalationRules {
+		ok, err := evalCondition(rule.Condition, vars)
+		if err != nil {
+			log.Printf("升级规则条件错误 %q: %v", rule.Condition, err)
+			continue
+		}
+		if ok {
+			return rule, true
+		}
+	}
+
+	if maxAttempts := e.rules.ErrorHandling.RetryPolicy.MaxAttempts; maxAttempts > 0 && ctx.FallbackCount > maxAttempts {
+		return EscalationRule{Condition: retryPolicyCondition, Action: ActionRedirectToHuman}, true
+	}
+	return EscalationRule{}, false
+}
@@ -0,0 +1,45 @@
+package chatbot_test
+
+import (
+	"testing"
+	"time"
+
+	"gochat/internal/service/chatbot"
+	"gochat/internal/service/event"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscalationAfterRepeatedFallbacks(t *testing.T) {
+	rules, err := chatbot.LoadChatBotRulesFromFile("config/chatbot_rules.yml")
+	assert.NoError(t, err)
+
+	bus := event.NewBus()
+	escalations := make(chan chatbot.Escalation, 1)
+	bus.Subscribe(chatbot.EventEscalation, func(e event.Event) {
+		escalations <- e.Payload.(chatbot.Escalation)
+	})
+
+	engine := chatbot.NewChatBotEngineWithRules(nil, rules)
+	engine.SetEventBus(bus)
+
+	for i := 0; i < rules.ErrorHandling.RetryPolicy.MaxAttempts; i++ {
+		reply := engine.Respond("2001", "看不懂的问题")
+		assert.True(t, reply.Fallback)
+		assert.False(t, reply.Handoff)
+	}
+
+	reply := engine.Respond("2001", "还是看不懂")
+	assert.True(t, reply.Escalated)
+	assert.True(t, reply.Handoff)
+	assert.Equal(t, 0, engine.GetContext("2001").FallbackCount)
+
+	select {
+	case escalation := <-escalations:
+		assert.Equal(t, "2001", escalation.CustomerID)
+		assert.Equal(t, chatbot.ActionRedirectToHuman, escalation.Action)
+		assert.Equal(t, rules.ErrorHandling.RetryPolicy.MaxAttempts+1, escalation.FallbackCount)
+	case <-time.After(time.Second):
+		t.Fatal("未收到升级事件")
+	}
+}
@@ -0,0 +1,136 @@
+package chatbot
+
+import (
+	"hash/fnv"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gochat/internal/service/i18n"
+)
+
+// VariantGroup A/B 测试分组，按权重将客户分配到不同版本
+type VariantGroup struct {
+	Name     string    `mapstructure:"name"`
+	Variants []Variant `mapstructure:"variants"`
+}
+
+// Variant 实验版本，Weight 支持 "50%" 或数字
+type Variant struct {
+	Name    string            `mapstructure:"name"`
+	Weight  string            `mapstructure:"weight"`
+	Content string            `mapstructure:"content"`
+	I18n    map[string]string `mapstructure:"i18n"`
+}
+
+// variantName 未配置名称的版本使用序号命名
+func (g VariantGroup) variantName(i int) string {
+	if g.Variants[i].Name != "" {
+		return g.Variants[i].Name
+	}
+	return "v" + strconv.Itoa(i+1)
+}
+
+func (v Variant) weight() float64 {
+	w, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(v.Weight), "%")), 64)
+	if err != nil || w < 0 {
+		log.Printf("实验版本权重无效 %q，按0处理", v.Weight)
+		return 0
+	}
+	return w
+}
+
+// assign 按客户ID哈希确定性地分配版本，同一客户始终落在同一版本
+func (g VariantGroup) assign(customerID string) (string, bool) {
+	var total float64
+	for _, v := range g.Variants {
+		total += v.weight()
+	}
+	if total <= 0 {
+		return "", false
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(g.Name + "/" + customerID))
+	point := float64(h.Sum32()%10000) / 10000 * total
+
+	var cumulative float64
+	for i, v := range g.Variants {
+		cumulative += v.weight()
+		if point < cumulative {
+			return g.variantName(i), true
+		}
+	}
+	return g.variantName(len(g.Variants) - 1), true
+}
+
+// find 按名称查找版本
+func (g VariantGroup) find(name string) (Variant, bool) {
+	for i, v := range g.Variants {
+		if g.variantName(i) == name {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
+
+func (r *ChatBotRules) findVariantGroup(name string) (VariantGroup, bool) {
+	for _, group := range r.Experimental.VariantGroups {
+		if group.Name == name {
+			return group, true
+		}
+	}
+	return VariantGroup{}, false
+}
+
+// assignVariants 为客户分配所有实验分组的版本
+func (e *ChatBotEngine) assignVariants(customerID string) map[string]string {
+	variants := make(map[string]string)
+	for _, group := range e.rules.Experimental.VariantGroups {
+		if name, ok := group.assign(customerID); ok {
+			variants[group.Name] = name
+		}
+	}
+	return variants
+}
+
+// Variants 返回客户在各实验分组中的版本
+func (e *ChatBotEngine) Variants(customerID string) map[string]string {
+	return e.GetContext(customerID).Variants
+}
+
+// variantContent 返回客户所在版本的回复内容
+func (e *ChatBotEngine) variantContent(groupName string, ctx *ConversationContext) (string, bool) {
+	group, ok := e.rules.findVariantGroup(groupName)
+	if !ok {
+		log.Printf("实验分组不存在: %s", groupName)
+		return "", false
+	}
+	variant, ok := group.find(ctx.Variants[groupName])
+	if !ok {
+		return "", false
+	}
+	return i18n.Pick(variant.I18n, ctx.Locale, variant.Content), true
+}
+
+// FormatVariants 将版本分配编码为 "group=variant" 逗号分隔的字符串，用于持久化
+func FormatVariants(variants map[string]string) string {
+	pairs := make([]string, 0, len(variants))
+	for group, variant := range variants {
+		pairs = append(pairs, group+"="+variant)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// ParseVariants 解析 FormatVariants 编码的版本分配
+func ParseVariants(value string) map[string]string {
+	variants := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if group, variant, ok := strings.Cut(strings.TrimSpace(pair), "="); ok {
+			variants[group] = variant
+		}
+	}
+	return variants
+}
@@ -0,0 +1,46 @@
+package chatbot_test
+
+import (
+	"strconv"
+	"testing"
+
+	"gochat/internal/service/chatbot"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVariantAssignment(t *testing.T) {
+	var rules chatbot.ChatBotRules
+	rules.Experimental.VariantGroups = []chatbot.VariantGroup{{
+		Name: "welcome_message",
+		Variants: []chatbot.Variant{
+			{Name: "new_greeting", Weight: "30%"},
+			{Name: "control", Weight: "70%"},
+		},
+	}}
+
+	t.Run("同一客户分配结果固定", func(t *testing.T) {
+		first := chatbot.NewChatBotEngineWithRules(nil, rules).Variants("42")
+		second := chatbot.NewChatBotEngineWithRules(nil, rules).Variants("42")
+		assert.Equal(t, first, second)
+		assert.Contains(t, []string{"new_greeting", "control"}, first["welcome_message"])
+	})
+
+	t.Run("按权重分配", func(t *testing.T) {
+		engine := chatbot.NewChatBotEngineWithRules(nil, rules)
+		counts := map[string]int{}
+		for i := 0; i < 2000; i++ {
+			counts[engine.Variants(strconv.Itoa(i))["welcome_message"]]++
+		}
+		assert.InDelta(t, 600, counts["new_greeting"], 100)
+		assert.InDelta(t, 1400, counts["control"], 100)
+	})
+}
+
+func TestFormatVariants(t *testing.T) {
+	variants := map[string]string{"welcome_message": "control", "menu": "v2"}
+	encoded := chatbot.FormatVariants(variants)
+	assert.Equal(t, "menu=v2,welcome_message=control", encoded)
+	assert.Equal(t, variants, chatbot.ParseVariants(encoded))
+	assert.Empty(t, chatbot.ParseVariants(""))
+}
@@ -0,0 +1,92 @@
+package chatbot
+
+import (
+	"strconv"
+	"strings"
+
+	"gochat/internal/service/faq"
+	"gochat/internal/service/i18n"
+)
+
+// 常见问题相关的意图与按钮回传前缀
+const (
+	IntentFAQ           = "faq"            // 直接命中常见问题
+	IntentFAQSuggestion = "faq_suggestion" // 多个问题得分接近，给出候选
+	FAQPayloadPrefix    = "FAQ:"           // 候选按钮回传 FAQ:<id>
+)
+
+// FAQConfig 未识别意图时的常见问题检索配置
+type FAQConfig struct {
+	Threshold            float64           `mapstructure:"threshold"`     // BM25 得分阈值，低于该值仍走兜底
+	SuggestRatio         float64           `mapstructure:"suggest_ratio"` // 得分不低于最高分该比例的问题作为候选，如 0.85
+	MaxSuggestions       int               `mapstructure:"max_suggestions"`
+	SuggestionPrompt     string            `mapstructure:"suggestion_prompt"`
+	SuggestionPromptI18n map[string]string `mapstructure:"suggestion_prompt_i18n"`
+}
+
+// SetFAQIndex 替换常见问题检索索引，nil 表示不检索
+func (e *ChatBotEngine) SetFAQIndex(index *faq.Index) {
+	e.faqIndex = index
+}
+
+// answerFAQ 检索常见问题：唯一高分问题直接回答，多个得分接近时给出“您是不是想问”候选
+func (e *ChatBotEngine) answerFAQ(message string, ctx *ConversationContext) (Reply, bool) {
+	cfg := e.rules.FAQ
+	if e.faqIndex == nil || cfg.Threshold <= 0 {
+		return Reply{}, false
+	}
+	maxSuggestions := cfg.MaxSuggestions
+	if maxSuggestions <= 0 {
+		maxSuggestions = 3
+	}
+
+	results := e.faqIndex.Search(message, maxSuggestions)
+	if len(results) == 0 || results[0].Score < cfg.Threshold {
+		return Reply{}, false
+	}
+
+	candidates := results[:1]
+	if cfg.SuggestRatio > 0 {
+		for _, result := range results[1:] {
+			if result.Score >= results[0].Score*cfg.SuggestRatio {
+				candidates = append(candidates, result)
+			}
+		}
+	}
+	if len(candidates) == 1 {
+		return Reply{Text: candidates[0].FAQ.Answer, Intent: IntentFAQ}, true
+	}
+
+	buttons := make([]Button, 0, len(candidates))
+	for _, candidate := range candidates {
+		buttons = append(buttons, Button{
+			Title:   candidate.FAQ.Question,
+			Payload: FAQPayloadPrefix + strconv.FormatUint(uint64(candidate.FAQ.ID), 10),
+		})
+	}
+	prompt := cfg.SuggestionPrompt
+	if prompt == "" {
+		prompt = "您是不是想问："
+	}
+	return Reply{
+		Text:   i18n.Pick(cfg.SuggestionPromptI18n, ctx.Locale, prompt),
+		Rich:   []RichContent{{Type: RichTypeQuickReply, Buttons: buttons}},
+		Intent: IntentFAQSuggestion,
+	}, true
+}
+
+// faqForPayload 处理候选问题按钮回传，payload 不是 FAQ:<id> 时返回 false
+func (e *ChatBotEngine) faqForPayload(payload string) (Reply, bool) {
+	if e.faqIndex == nil || !strings.HasPrefix(strings.ToUpper(payload), FAQPayloadPrefix) {
+		return Reply{}, false
+	}
+	id, err := strconv.ParseUint(payload[len(FAQPayloadPrefix):], 10, 64)
+	if err != nil {
+		return Reply{}, false
+	}
+	item, ok := e.faqIndex.Get(uint(id))
+	if !ok {
+		return Reply{}, false
+	}
+	return Reply{Text: item.Answer, Intent: IntentFAQ}, true
+}
@@ -0,0 +1,46 @@
+package chatbot_test
+
+import (
+	"testing"
+	"time"
+
+	"gochat/internal/model"
+	"gochat/internal/service/chatbot"
+	"gochat/internal/service/faq"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnknownIntentAnsweredFromFAQ(t *testing.T) {
+	rules, err := chatbot.LoadChatBotRulesFromFile("config/chatbot_rules.yml")
+	assert.NoError(t, err)
+
+	engine := chatbot.NewChatBotEngineWithRules(nil, rules)
+	engine.SetClock(func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) })
+	engine.SetFAQIndex(faq.NewIndex([]model.FAQ{
+		{ID: 1, Question: "如何修改登录密码", Answer: "在“我的-账号安全”中修改密码。", Tags: "账号,密码", Enabled: true},
+		{ID: 2, Question: "忘记密码怎么办", Answer: "在登录页点击“忘记密码”重置。", Tags: "账号,密码", Enabled: true},
+		{ID: 3, Question: "订单多久发货", Answer: "付款后48小时内发货。", Tags: "订单,物流", Enabled: true},
+	}))
+
+	reply := engine.Respond("3001", "订单什么时候发货")
+	assert.Equal(t, chatbot.IntentFAQ, reply.Intent)
+	assert.Equal(t, "付款后48小时内发货。", reply.Text)
+	assert.False(t, reply.Fallback)
+
+	// 得分接近时给出候选，点击候选按钮返回对应答案
+	reply = engine.Respond("3001", "怎么修改密码")
+	assert.Equal(t, chatbot.IntentFAQSuggestion, reply.Intent)
+	assert.Equal(t, "您是不是想问：", reply.Text)
+	if assert.Len(t, reply.Rich, 1) && assert.Len(t, reply.Rich[0].Buttons, 2) {
+		assert.Equal(t, "FAQ:2", reply.Rich[0].Buttons[0].Payload)
+		assert.Equal(t, "FAQ:1", reply.Rich[0].Buttons[1].Payload)
+	}
+
+	reply = engine.HandlePostback("3001", "FAQ:1")
+	assert.Equal(t, "在“我的-账号安全”中修改密码。", reply.Text)
+
+	// 得分不足时仍走兜底
+	reply = engine.Respond("3001", "看不懂的问题")
+	assert.True(t, reply.Fallback)
+}
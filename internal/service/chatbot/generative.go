@@ -0,0 +1,107 @@
+package chatbot
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gochat/internal/model"
+	"gochat/internal/service/i18n"
+	"gochat/internal/service/llm"
+
+	"gorm.io/gorm"
+)
+
+// IntentGenerated 由生成式模型回复的意图
+const IntentGenerated = "generated"
+
+// GenerativeFallbackConfig 意图识别失败时的生成式回复配置
+type GenerativeFallbackConfig struct {
+	Enabled          bool              `mapstructure:"enabled"`
+	SystemPrompt     string            `mapstructure:"system_prompt"` // 支持 ${bot_name}
+	SystemPromptI18n map[string]string `mapstructure:"system_prompt_i18n"`
+	MaxTokens        int               `mapstructure:"max_tokens"`         // 回复的最大 token 数
+	MaxContextTokens int               `mapstructure:"max_context_tokens"` // 历史消息的估算 token 上限
+	HistoryLimit     int               `mapstructure:"history_limit"`      // 最多携带的历史消息条数
+	TimeoutMs        int               `mapstructure:"timeout_ms"`         // 超时后退回兜底回复
+}
+
+// HistoryLoader 加载客户与指定机器人最近的对话记录，按时间顺序返回；
+// 同一客户与其他机器人的对话不应出现在历史中
+type HistoryLoader func(botID, customerID string, limit int) []llm.Message
+
+// SetResponseGenerator 替换生成式回复提供方，nil 表示不启用
+func (e *ChatBotEngine) SetResponseGenerator(generator llm.ResponseGenerator) {
+	e.generator = generator
+}
+
+// SetHistoryLoader 替换对话记录加载方式
+func (e *ChatBotEngine) SetHistoryLoader(loader HistoryLoader) {
+	e.loadHistory = loader
+}
+
+// newDBHistoryLoader 从 messages 表加载最近的文本消息，按所属会话的 bot_id 过滤，用户消息以外均视为助手回复
+func newDBHistoryLoader(db *gorm.DB) HistoryLoader {
+	return func(botID, customerID string, limit int) []llm.Message {
+		var messages []model.Message
+		if err := db.Select("messages.message", "messages.sender").
+			Joins("JOIN conversations ON conversations.id = messages.conversation_id").
+			Where("messages.customer_id = ? AND messages.message_type <> ? AND conversations.bot_id = ?",
+				customerID, model.MessageTypeFeedback, botID).
+			Order("messages.id DESC").Limit(limit).Find(&messages).Error; err != nil {
+			log.Printf("加载对话记录失败 %s: %v", customerID, err)
+			return nil
+		}
+
+		history := make([]llm.Message, 0, len(messages))
+		for i := len(messages) - 1; i >= 0; i-- {
+			role := llm.RoleAssistant
+			if messages[i].Sender == "user" {
+				role = llm.RoleUser
+			}
+			history = append(history, llm.Message{Role: role, Content: messages[i].Message})
+		}
+		return history
+	}
+}
+
+// generateReply 调用生成式模型回复，未启用、超时或出错时返回 false，由调用方走兜底
+func (e *ChatBotEngine) generateReply(customerID, message string, ctx *ConversationContext) (Reply, bool) {
+	cfg := e.rules.GenerativeFallback
+	if !cfg.Enabled || e.generator == nil {
+		return Reply{}, false
+	}
+
+	var history []llm.Message
+	if e.loadHistory != nil && cfg.HistoryLimit > 0 {
+		history = e.loadHistory(e.botID, customerID, cfg.HistoryLimit+1)
+		// 本轮消息在回复前已入库，避免重复发送
+		if n := len(history); n > 0 && history[n-1].Role == llm.RoleUser && history[n-1].Content == message {
+			history = history[:n-1]
+		}
+		if len(history) > cfg.HistoryLimit {
+			history = history[len(history)-cfg.HistoryLimit:]
+		}
+		history = llm.TrimHistory(history, cfg.MaxContextTokens)
+	}
+
+	timeout := time.Duration(cfg.TimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	reqCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	prompt := i18n.Pick(cfg.SystemPromptI18n, ctx.Locale, cfg.SystemPrompt)
+	text, err := e.generator.Generate(reqCtx, llm.Request{
+		SystemPrompt: renderTemplate(prompt, map[string]interface{}{"bot_name": e.rules.Metadata.BotName}),
+		History:      history,
+		Prompt:       message,
+		MaxTokens:    cfg.MaxTokens,
+	})
+	if err != nil {
+		log.Printf("生成式回复失败，使用兜底回复 %s: %v", customerID, err)
+		return Reply{}, false
+	}
+	return Reply{Text: text, Intent: IntentGenerated, Generated: true}, true
+}
@@ -0,0 +1,70 @@
+package chatbot_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gochat/internal/service/chatbot"
+	"gochat/internal/service/llm"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockGenerator struct {
+	reply    string
+	err      error
+	requests []llm.Request
+}
+
+func (g *mockGenerator) Generate(ctx context.Context, req llm.Request) (string, error) {
+	g.requests = append(g.requests, req)
+	return g.reply, g.err
+}
+
+func TestGenerativeFallback(t *testing.T) {
+	rules, err := chatbot.LoadChatBotRulesFromFile("config/chatbot_rules.yml")
+	assert.NoError(t, err)
+
+	generator := &mockGenerator{reply: "发票可以在订单详情页申请。"}
+	engine := chatbot.NewChatBotEngineWithRules(nil, rules)
+	engine.SetClock(func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) })
+	engine.SetResponseGenerator(generator)
+	var historyBots []string
+	engine.SetHistoryLoader(func(botID, customerID string, limit int) []llm.Message {
+		historyBots = append(historyBots, botID)
+		return []llm.Message{
+			{Role: llm.RoleUser, Content: "你好"},
+			{Role: llm.RoleAssistant, Content: "您好，请问需要什么帮助？"},
+			{Role: llm.RoleUser, Content: "怎么开发票"},
+		}
+	})
+
+	reply := engine.Respond("4001", "怎么开发票")
+	assert.True(t, reply.Generated)
+	assert.False(t, reply.Fallback)
+	assert.Equal(t, chatbot.IntentGenerated, reply.Intent)
+	assert.Equal(t, "发票可以在订单详情页申请。", reply.Text)
+
+	if assert.Len(t, generator.requests, 1) {
+		req := generator.requests[0]
+		assert.Contains(t, req.SystemPrompt, rules.Metadata.BotName)
+		assert.Equal(t, "怎么开发票", req.Prompt)
+		assert.Equal(t, rules.GenerativeFallback.MaxTokens, req.MaxTokens)
+		// 本轮消息已入库，不重复出现在历史中
+		assert.Len(t, req.History, 2)
+	}
+	// 只加载当前机器人的对话记录
+	assert.Equal(t, []string{chatbot.DefaultBotID}, historyBots)
+
+	// 已识别的意图不调用模型
+	engine.Respond("4001", "你好")
+	assert.Len(t, generator.requests, 1)
+
+	// 模型出错时退回兜底回复
+	generator.err = errors.New("timeout")
+	reply = engine.Respond("4001", "看不懂的问题")
+	assert.False(t, reply.Generated)
+	assert.True(t, reply.Fallback)
+}
@@ -0,0 +1,43 @@
+package chatbot_test
+
+import (
+	"flag"
+	"testing"
+
+	"gochat/internal/service/chatbot"
+)
+
+var (
+	goldenRules   = flag.String("rules", "config/chatbot_rules.yml", "对话脚本使用的规则文件")
+	goldenScripts = flag.String("scripts", "testdata/conversations", "对话脚本目录")
+)
+
+// TestGoldenConversations 回放脚本目录下的所有对话脚本
+func TestGoldenConversations(t *testing.T) {
+	scripts, err := chatbot.LoadConversationScripts(*goldenScripts)
+	if err != nil {
+		t.Fatalf("加载对话脚本失败: %v", err)
+	}
+	if len(scripts) == 0 {
+		t.Skipf("目录 %s 下没有对话脚本", *goldenScripts)
+	}
+
+	for _, script := range scripts {
+		script := script
+		t.Run(script.Name, func(t *testing.T) {
+			rulesPath := script.RulesPath()
+			if rulesPath == "" {
+				rulesPath = *goldenRules
+			}
+			rules, err := chatbot.LoadChatBotRulesFromFile(rulesPath)
+			if err != nil {
+				t.Fatalf("加载规则失败: %v", err)
+			}
+
+			engine := chatbot.NewChatBotEngineWithRules(nil, rules)
+			for _, failure := range script.Run(engine) {
+				t.Errorf("%s: %v", script.File(), failure)
+			}
+		})
+	}
+}
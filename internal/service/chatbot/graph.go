@@ -0,0 +1,338 @@
+package chatbot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 状态图输出格式
+const (
+	GraphFormatDOT     = "dot"     // Graphviz
+	GraphFormatMermaid = "mermaid" // Mermaid stateDiagram-v2
+)
+
+// FlowNode 状态图中的状态
+type FlowNode struct {
+	Name         string   `json:"name"`
+	EntryActions []string `json:"entry_actions,omitempty"` // 进入动作类型
+	Unreachable  bool     `json:"unreachable"`             // 从 welcome 出发无法到达
+	DeadEnd      bool     `json:"dead_end"`                // 无法离开该状态：没有转到其他状态的转移，不能返回调用方，也不是子对话
+}
+
+// FlowEdge 由意图触发的转移，From 为空表示全局转移
+type FlowEdge struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Intent    string `json:"intent"`
+	Condition string `json:"condition,omitempty"`
+	Push      bool   `json:"push,omitempty"` // 进入子对话
+	Back      bool   `json:"back,omitempty"` // 返回上一步，目标在运行时确定，图中画为到各个调用方的边，To 为空表示返回任意状态
+}
+
+// FlowGraph 对话状态机的静态结构，自定义动作在运行时指定的状态不在分析范围内
+type FlowGraph struct {
+	Name  string     `json:"name"`
+	Nodes []FlowNode `json:"nodes"`
+	Edges []FlowEdge `json:"edges"`
+}
+
+// backTransition 返回上一步的转移，state 为空表示全局转移
+type backTransition struct {
+	state      string
+	transition Transition
+}
+
+// NewFlowGraph 根据规则构建状态图并标记不可达状态和无出口状态
+func NewFlowGraph(rules ChatBotRules) FlowGraph {
+	graph := FlowGraph{Name: rules.Metadata.BotName}
+	index := make(map[string]int)
+	for _, state := range rules.DialogueFlow.States {
+		node := FlowNode{Name: state.Name}
+		for _, action := range state.EntryActions {
+			node.EntryActions = append(node.EntryActions, action.Type)
+		}
+		index[state.Name] = len(graph.Nodes)
+		graph.Nodes = append(graph.Nodes, node)
+	}
+
+	// 先收集确定目标的转移，返回上一步的目标取决于调用方，最后展开
+	var backs []backTransition
+	addEdges := func(from string, transitions []Transition) {
+		for _, transition := range transitions {
+			if transitionGoesBack(transition) {
+				backs = append(backs, backTransition{state: from, transition: transition})
+				continue
+			}
+			if edge, ok := rules.flowEdge(from, transition); ok {
+				graph.Edges = append(graph.Edges, edge)
+			}
+		}
+	}
+	addEdges("", rules.DialogueFlow.GlobalTransitions)
+	for _, state := range rules.DialogueFlow.States {
+		addEdges(state.Name, state.Transitions)
+	}
+
+	callers := graph.callers()
+	for _, back := range backs {
+		states := []string{back.state}
+		if back.state == "" {
+			states = states[:0]
+			for _, node := range graph.Nodes {
+				states = append(states, node.Name)
+			}
+		}
+		for _, state := range states {
+			for _, caller := range callers[state] {
+				graph.Edges = append(graph.Edges, FlowEdge{From: state, To: caller, Intent: back.transition.Intent, Condition: back.transition.Condition, Back: true})
+			}
+		}
+	}
+
+	// 子对话中无法处理的意图会返回入口状态，视为有出口
+	exits := make(map[string]bool)
+	for _, edge := range graph.Edges {
+		if edge.Push {
+			exits[edge.To] = true
+		}
+		if edge.From != "" && edge.To != edge.From {
+			exits[edge.From] = true
+		}
+	}
+	for i := range graph.Nodes {
+		graph.Nodes[i].DeadEnd = !exits[graph.Nodes[i].Name]
+	}
+
+	reachable := graph.reachable(index)
+	for i := range graph.Nodes {
+		graph.Nodes[i].Unreachable = !reachable[graph.Nodes[i].Name]
+	}
+	return graph
+}
+
+// transitionGoesBack 转移是否执行 back 动作
+func transitionGoesBack(transition Transition) bool {
+	for _, action := range transition.Actions {
+		if action.Type == ActionBack {
+			return true
+		}
+	}
+	return false
+}
+
+// callers 每个状态的调用方：有转移进入该状态的其他状态，全局转移的调用方为空字符串，表示任意状态
+func (g FlowGraph) callers() map[string][]string {
+	callers := make(map[string][]string)
+	seen := make(map[[2]string]bool)
+	for _, edge := range g.Edges {
+		key := [2]string{edge.To, edge.From}
+		if edge.Back || edge.To == edge.From || seen[key] {
+			continue
+		}
+		seen[key] = true
+		callers[edge.To] = append(callers[edge.To], edge.From)
+	}
+	return callers
+}
+
+// flowEdge 确定转移的目标状态：默认为 next_state，reset 回到 welcome，未指定时保持当前状态；目标状态不存在时忽略
+func (r ChatBotRules) flowEdge(from string, transition Transition) (FlowEdge, bool) {
+	edge := FlowEdge{From: from, To: transition.NextState, Intent: transition.Intent, Condition: transition.Condition, Push: transition.Push}
+	for _, action := range transition.Actions {
+		if action.Type == ActionReset {
+			edge.To = "welcome"
+		}
+	}
+	if edge.To == "" {
+		edge.To = from
+	}
+	if edge.To == "" {
+		// 全局转移保持当前状态，画为全局节点的自环
+		return edge, true
+	}
+	return edge, r.findState(edge.To) != nil
+}
+
+// reachable 从 welcome 出发广度遍历，全局转移的目标在任意状态下均可到达
+func (g FlowGraph) reachable(index map[string]int) map[string]bool {
+	visited := make(map[string]bool)
+	if _, ok := index["welcome"]; !ok {
+		return visited
+	}
+	queue := []string{"welcome"}
+	for _, edge := range g.Edges {
+		if edge.From == "" && edge.To != "" {
+			queue = append(queue, edge.To)
+		}
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		if visited[state] {
+			continue
+		}
+		visited[state] = true
+		for _, edge := range g.Edges {
+			if edge.From == state && !visited[edge.To] {
+				queue = append(queue, edge.To)
+			}
+		}
+	}
+	return visited
+}
+
+// label 转移的显示文本
+func (edge FlowEdge) label() string {
+	label := edge.Intent
+	if edge.Condition != "" {
+		label += " [" + edge.Condition + "]"
+	}
+	if edge.Push {
+		label += " (push)"
+	}
+	if edge.Back {
+		label += " (back)"
+	}
+	return label
+}
+
+// Render 按格式输出状态图
+func (g FlowGraph) Render(format string) (string, error) {
+	switch format {
+	case GraphFormatDOT:
+		return g.DOT(), nil
+	case GraphFormatMermaid:
+		return g.Mermaid(), nil
+	default:
+		return "", fmt.Errorf("不支持的格式 %s，可选 %s、%s", format, GraphFormatDOT, GraphFormatMermaid)
+	}
+}
+
+// DOT 输出 Graphviz 格式：方框为有进入动作的状态，红色为无出口状态，灰色虚线为不可达状态
+func (g FlowGraph) DOT() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(g.Name))
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=ellipse];\n")
+	b.WriteString("  \"__start\" [shape=point];\n")
+	b.WriteString("  \"__start\" -> \"welcome\";\n")
+	if g.hasGlobalEdges() {
+		b.WriteString("  \"__global\" [label=\"任意状态\", shape=diamond, style=dashed];\n")
+	}
+
+	for _, node := range g.Nodes {
+		attrs := []string{}
+		if len(node.EntryActions) > 0 {
+			attrs = append(attrs, "shape=box", "label="+dotQuote(node.Name+"\nentry: "+strings.Join(node.EntryActions, ", ")))
+		}
+		if node.DeadEnd {
+			attrs = append(attrs, "color=red", "penwidth=2")
+		}
+		if node.Unreachable {
+			attrs = append(attrs, "style=\"dashed,filled\"", "fillcolor=lightgrey", "fontcolor=gray40")
+		}
+		if len(attrs) == 0 {
+			fmt.Fprintf(&b, "  %s;\n", dotQuote(node.Name))
+			continue
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(node.Name), strings.Join(attrs, ", "))
+	}
+
+	for _, edge := range g.Edges {
+		from, to := dotQuote(edge.From), dotQuote(edge.To)
+		style := ""
+		if edge.From == "" {
+			from, style = "\"__global\"", ", style=dashed"
+			if edge.To == "" {
+				to = from
+			}
+		} else if edge.Back {
+			style = ", style=dotted"
+			if edge.To == "" {
+				to = "\"__global\""
+			}
+		}
+		fmt.Fprintf(&b, "  %s -> %s [label=%s%s];\n", from, to, dotQuote(edge.label()), style)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid 输出 stateDiagram-v2 格式，高亮样式与 DOT 相同
+func (g FlowGraph) Mermaid() string {
+	ids := make(map[string]string, len(g.Nodes))
+	for i, node := range g.Nodes {
+		ids[node.Name] = fmt.Sprintf("s%d", i)
+	}
+
+	var b strings.Builder
+	b.WriteString("stateDiagram-v2\n")
+	if g.Name != "" {
+		fmt.Fprintf(&b, "  %%%% %s\n", mermaidText(g.Name))
+	}
+	if g.hasGlobalEdges() {
+		b.WriteString("  state \"任意状态\" as global\n")
+	}
+	var entry, deadEnd, unreachable []string
+	for _, node := range g.Nodes {
+		id := ids[node.Name]
+		fmt.Fprintf(&b, "  state \"%s\" as %s\n", mermaidText(node.Name), id)
+		if len(node.EntryActions) > 0 {
+			fmt.Fprintf(&b, "  %s : entry / %s\n", id, mermaidText(strings.Join(node.EntryActions, ", ")))
+			entry = append(entry, id)
+		}
+		if node.DeadEnd {
+			deadEnd = append(deadEnd, id)
+		}
+		if node.Unreachable {
+			unreachable = append(unreachable, id)
+		}
+	}
+
+	if id, ok := ids["welcome"]; ok {
+		fmt.Fprintf(&b, "  [*] --> %s\n", id)
+	}
+	for _, edge := range g.Edges {
+		from, to := ids[edge.From], ids[edge.To]
+		if edge.From == "" {
+			from = "global"
+		}
+		if edge.To == "" {
+			to = "global"
+		}
+		fmt.Fprintf(&b, "  %s --> %s : %s\n", from, to, mermaidText(edge.label()))
+	}
+
+	b.WriteString("  classDef entry fill:#e8f0fe\n")
+	b.WriteString("  classDef deadEnd stroke:#d33,stroke-width:2px\n")
+	b.WriteString("  classDef unreachable fill:#eee,color:#999,stroke-dasharray:5 5\n")
+	for _, class := range []struct {
+		name  string
+		nodes []string
+	}{{"entry", entry}, {"deadEnd", deadEnd}, {"unreachable", unreachable}} {
+		if len(class.nodes) > 0 {
+			fmt.Fprintf(&b, "  class %s %s\n", strings.Join(class.nodes, ","), class.name)
+		}
+	}
+	return b.String()
+}
+
+func (g FlowGraph) hasGlobalEdges() bool {
+	for _, edge := range g.Edges {
+		if edge.From == "" || edge.To == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// dotQuote 转义为 DOT 的双引号字符串
+func dotQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+	return `"` + s + `"`
+}
+
+// mermaidText 去除会破坏 Mermaid 语法的字符
+func mermaidText(s string) string {
+	return strings.NewReplacer(`"`, "'", "\n", " ", ":", "：", ";", "；").Replace(s)
+}
@@ -0,0 +1,82 @@
+package chatbot_test
+
+import (
+	"testing"
+
+	"gochat/internal/service/chatbot"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlowGraph(t *testing.T) {
+	rules, err := chatbot.ParseChatBotRules([]byte(`
+metadata:
+  bot_name: "测试"
+dialogue_flow:
+  global_transitions:
+    - intent: "cancel"
+      actions:
+        - type: "reset"
+    - intent: "help"
+      next_state: "help"
+      push: true
+    - intent: "go_back"
+      actions:
+        - type: "back"
+  states:
+    - name: "welcome"
+      transitions:
+        - intent: "order_query"
+          next_state: "order"
+    - name: "order"
+      entry_actions:
+        - type: "call_api"
+          endpoint: "http://localhost/orders"
+      transitions:
+        - intent: "confirm"
+          next_state: "welcome"
+    - name: "help"
+    - name: "orphan"
+      transitions:
+        - intent: "greeting"
+`))
+	assert.NoError(t, err)
+
+	graph := chatbot.NewFlowGraph(rules)
+	assert.Equal(t, []chatbot.FlowNode{
+		{Name: "welcome"},
+		{Name: "order", EntryActions: []string{"call_api"}},
+		{Name: "help"},
+		{Name: "orphan", Unreachable: true, DeadEnd: true},
+	}, graph.Nodes)
+	assert.Equal(t, []chatbot.FlowEdge{
+		{To: "welcome", Intent: "cancel"},
+		{To: "help", Intent: "help", Push: true},
+		{From: "welcome", To: "order", Intent: "order_query"},
+		{From: "order", To: "welcome", Intent: "confirm"},
+		{From: "orphan", To: "orphan", Intent: "greeting"},
+		{From: "welcome", Intent: "go_back", Back: true},
+		{From: "welcome", To: "order", Intent: "go_back", Back: true},
+		{From: "order", To: "welcome", Intent: "go_back", Back: true},
+		{From: "help", Intent: "go_back", Back: true},
+	}, graph.Edges)
+
+	dot, err := graph.Render(chatbot.GraphFormatDOT)
+	assert.NoError(t, err)
+	assert.Contains(t, dot, `"welcome" -> "order" [label="order_query"];`)
+	assert.Contains(t, dot, `"order" [shape=box, label="order\nentry: call_api"];`)
+	assert.Contains(t, dot, `"__global" -> "welcome" [label="cancel", style=dashed];`)
+	assert.Contains(t, dot, `"order" -> "welcome" [label="go_back (back)", style=dotted];`)
+
+	mermaid, err := graph.Render(chatbot.GraphFormatMermaid)
+	assert.NoError(t, err)
+	assert.Contains(t, mermaid, "s0 --> s1 : order_query")
+	assert.Contains(t, mermaid, "s1 --> s0 : go_back (back)")
+	assert.Contains(t, mermaid, "s2 --> global : go_back (back)")
+	assert.NotContains(t, mermaid, "global --> global")
+	assert.Contains(t, mermaid, "class s3 deadEnd")
+	assert.Contains(t, mermaid, "class s3 unreachable")
+
+	_, err = graph.Render("png")
+	assert.Error(t, err)
+}
@@ -0,0 +1,118 @@
+package chatbot
+
+import (
+	"errors"
+	"time"
+
+	"gochat/internal/service/i18n"
+)
+
+// 沉默跟进回复的意图
+const (
+	IntentInactivityNudge = "inactivity_nudge" // 客户沉默后发送的跟进消息
+	IntentInactivityEnd   = "inactivity_end"   // 多次跟进后客户仍沉默，结束会话
+)
+
+// InactivityConfig 客户沉默跟进规则，可配置在 dialogue_flow.inactivity 作为默认值，
+// 或配置在状态的 inactivity 中覆盖默认值，如槽位填充未完成时提醒客户补充信息
+type InactivityConfig struct {
+	AfterSeconds int               `mapstructure:"after_seconds"` // 客户沉默多少秒后跟进，为 0 时不跟进
+	Message      string            `mapstructure:"message"`       // 跟进消息，可使用 ${slot.xxx} 等占位符
+	MessageI18n  map[string]string `mapstructure:"message_i18n"`
+	MaxNudges    int               `mapstructure:"max_nudges"` // 连续跟进次数上限，默认 1；客户回复后重新计数
+
+	// 跟进次数用完后客户仍沉默 after_seconds 秒时结束会话：发送 end_message、重置对话并断开连接
+	EndConversation bool              `mapstructure:"end_conversation"`
+	EndMessage      string            `mapstructure:"end_message"`
+	EndMessageI18n  map[string]string `mapstructure:"end_message_i18n"`
+}
+
+func (c InactivityConfig) maxNudges() int {
+	if c.MaxNudges <= 0 {
+		return 1
+	}
+	return c.MaxNudges
+}
+
+func (c InactivityConfig) validate() error {
+	if c.AfterSeconds < 0 || c.MaxNudges < 0 {
+		return errors.New("after_seconds 和 max_nudges 不能为负数")
+	}
+	if c.AfterSeconds > 0 && c.Message == "" {
+		return errors.New("缺少 message")
+	}
+	return nil
+}
+
+// inactivityConfig 返回状态的沉默跟进规则，状态未配置时使用 dialogue_flow.inactivity
+func (e *ChatBotEngine) inactivityConfig(state string) (InactivityConfig, bool) {
+	for _, s := range e.rules.DialogueFlow.States {
+		if s.Name == state && s.Inactivity.AfterSeconds > 0 {
+			return s.Inactivity, true
+		}
+	}
+	cfg := e.rules.DialogueFlow.Inactivity
+	return cfg, cfg.AfterSeconds > 0
+}
+
+// nextNudge 返回下一次跟进的时间：客户最后一条消息或上一次跟进之后 after_seconds 秒；
+// 跟进次数用完且不结束会话、或会话已结束时返回 false
+func (e *ChatBotEngine) nextNudge(ctx ConversationContext) (time.Time, bool) {
+	cfg, ok := e.inactivityConfig(ctx.CurrentState)
+	if !ok || ctx.Nudges > cfg.maxNudges() || (ctx.Nudges == cfg.maxNudges() && !cfg.EndConversation) {
+		return time.Time{}, false
+	}
+	last := ctx.LastActive
+	if ctx.LastNudge.After(last) {
+		last = ctx.LastNudge
+	}
+	return last.Add(time.Duration(cfg.AfterSeconds) * time.Second), true
+}
+
+// InactivityDelay 返回客户当前状态下距离下一次沉默跟进的时长，不需要跟进时返回 false；
+// 连接在每次回复后调用以重新计时
+func (e *ChatBotEngine) InactivityDelay(customerID string) (time.Duration, bool) {
+	ctx, ok := e.contexts.snapshot(customerID)
+	if !ok {
+		return 0, false
+	}
+	at, ok := e.nextNudge(ctx)
+	if !ok {
+		return 0, false
+	}
+	delay := at.Sub(e.now())
+	if delay < 0 {
+		delay = 0
+	}
+	return delay, true
+}
+
+// Nudge 客户沉默达到配置时长时返回跟进消息；客户期间发过消息或不需要跟进时返回 false。
+// 跟进次数用完后返回的回复 EndConversation 为 true，对话已重置，连接应随后断开
+func (e *ChatBotEngine) Nudge(customerID string) (Reply, bool) {
+	var (
+		reply Reply
+		ok    bool
+	)
+	e.contexts.modify(customerID, func(ctx *ConversationContext) {
+		at, due := e.nextNudge(*ctx)
+		if !due || e.now().Before(at) {
+			return
+		}
+		cfg, _ := e.inactivityConfig(ctx.CurrentState)
+		trace := newTrace("", false, e.dryRun, ctx)
+		trace.Source = TraceSourceInactivity
+		actx := e.newActionContext(ctx)
+
+		if ctx.Nudges < cfg.maxNudges() {
+			reply = Reply{Text: actx.Render(i18n.Pick(cfg.MessageI18n, ctx.Locale, cfg.Message)), Intent: IntentInactivityNudge}
+		} else {
+			reply = Reply{Text: actx.Render(i18n.Pick(cfg.EndMessageI18n, ctx.Locale, cfg.EndMessage)), Intent: IntentInactivityEnd, EndConversation: true}
+			resetDialogue(ctx)
+		}
+		ctx.Nudges++
+		ctx.LastNudge = e.now()
+		reply, ok = trace.finish(reply, ctx), true
+	})
+	return reply, ok
+}
@@ -0,0 +1,108 @@
+package chatbot_test
+
+import (
+	"testing"
+	"time"
+
+	"gochat/internal/service/chatbot"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const inactivityRules = `
+intent_detection:
+  regex_patterns:
+    - intent: "order_query"
+      patterns: ["查订单"]
+dialogue_flow:
+  states:
+    - name: "welcome"
+      transitions:
+        - intent: "order_query"
+          next_state: "ask_order"
+          actions:
+            - type: "response"
+              content: "请提供订单号"
+    - name: "ask_order"
+      inactivity:
+        after_seconds: 60
+        message: "还在吗？请提供订单号"
+        max_nudges: 2
+        end_conversation: true
+        end_message: "长时间未收到回复，本次会话已结束"
+error_handling:
+  default_fallback: "抱歉，我没有理解。"
+`
+
+func TestInactivityNudge(t *testing.T) {
+	rules, err := chatbot.ParseChatBotRules([]byte(inactivityRules))
+	assert.NoError(t, err)
+	assert.NoError(t, rules.Validate())
+	engine := chatbot.NewChatBotEngineWithRules(nil, rules)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	engine.SetClock(func() time.Time { return now })
+	engine.OpenSession("9001")
+	defer engine.CloseSession("9001")
+
+	// welcome 状态未配置跟进
+	engine.Respond("9001", "你好")
+	_, ok := engine.InactivityDelay("9001")
+	assert.False(t, ok)
+
+	engine.Respond("9001", "查订单")
+	delay, ok := engine.InactivityDelay("9001")
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, delay)
+
+	// 沉默时间不足时不跟进
+	now = now.Add(30 * time.Second)
+	_, ok = engine.Nudge("9001")
+	assert.False(t, ok)
+
+	for i := 0; i < 2; i++ {
+		now = now.Add(time.Minute)
+		reply, ok := engine.Nudge("9001")
+		assert.True(t, ok)
+		assert.Equal(t, "还在吗？请提供订单号", reply.Text)
+		assert.Equal(t, chatbot.IntentInactivityNudge, reply.Intent)
+		assert.Equal(t, chatbot.TraceSourceInactivity, reply.Trace.Source)
+		assert.False(t, reply.EndConversation)
+	}
+
+	// 跟进次数用完后再次沉默时结束会话并重置对话
+	now = now.Add(time.Minute)
+	reply, ok := engine.Nudge("9001")
+	assert.True(t, ok)
+	assert.Equal(t, "长时间未收到回复，本次会话已结束", reply.Text)
+	assert.True(t, reply.EndConversation)
+	assert.Equal(t, "welcome", engine.GetContext("9001").CurrentState)
+	_, ok = engine.InactivityDelay("9001")
+	assert.False(t, ok)
+}
+
+func TestInactivityReset(t *testing.T) {
+	rules, err := chatbot.ParseChatBotRules([]byte(inactivityRules))
+	assert.NoError(t, err)
+	engine := chatbot.NewChatBotEngineWithRules(nil, rules)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	engine.SetClock(func() time.Time { return now })
+	engine.OpenSession("9002")
+	defer engine.CloseSession("9002")
+
+	engine.Respond("9002", "查订单")
+	now = now.Add(time.Minute)
+	_, ok := engine.Nudge("9002")
+	assert.True(t, ok)
+	assert.Equal(t, 1, engine.GetContext("9002").Nudges)
+
+	// 客户回复后重新计数，从最后一条消息开始计时
+	now = now.Add(10 * time.Second)
+	engine.Respond("9002", "稍等")
+	assert.Zero(t, engine.GetContext("9002").Nudges)
+	delay, _ := engine.InactivityDelay("9002")
+	assert.Equal(t, time.Minute, delay)
+
+	// 未连接的客户不跟进
+	_, ok = engine.Nudge("9999")
+	assert.False(t, ok)
+}
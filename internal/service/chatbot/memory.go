@@ -0,0 +1,180 @@
+package chatbot
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"gochat/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MemoryConfig 客户长期记忆配置，对应 context_management.memory
+type MemoryConfig struct {
+	Slots []MemorySlot `mapstructure:"slots"` // 值变化时写入长期记忆的槽位
+}
+
+// MemorySlot 写入长期记忆的槽位
+type MemorySlot struct {
+	Slot     string `mapstructure:"slot"`
+	Key      string `mapstructure:"key"`       // 记忆键，默认与槽位同名
+	TTLHours int    `mapstructure:"ttl_hours"` // 过期时间，为 0 时不过期
+	Restore  bool   `mapstructure:"restore"`   // 新会话开始时恢复到槽位，客户无需再次提供
+}
+
+// MemoryKey 返回槽位对应的记忆键
+func (s MemorySlot) MemoryKey() string {
+	if s.Key != "" {
+		return s.Key
+	}
+	return s.Slot
+}
+
+// MemoryEntry 一条长期记忆
+type MemoryEntry struct {
+	Key       string
+	Value     string
+	ExpiresAt *time.Time // 为 nil 时不过期
+}
+
+// MemoryStore 客户长期记忆的存储，Load 只返回未过期的记忆
+type MemoryStore interface {
+	Load(customerID string) map[string]string
+	Save(customerID, botID string, entries []MemoryEntry)
+}
+
+// SetMemoryStore 替换长期记忆存储，nil 表示不使用长期记忆
+func (e *ChatBotEngine) SetMemoryStore(store MemoryStore) {
+	e.memory = store
+}
+
+// dbMemoryStore 使用 customer_memories 表保存长期记忆
+type dbMemoryStore struct {
+	db *gorm.DB
+}
+
+func newDBMemoryStore(db *gorm.DB) MemoryStore {
+	return dbMemoryStore{db: db}
+}
+
+func (s dbMemoryStore) Load(customerID string) map[string]string {
+	var items []model.CustomerMemory
+	if err := s.db.Where("customer_id = ? AND (expires_at IS NULL OR expires_at > ?)", customerID, time.Now()).
+		Find(&items).Error; err != nil {
+		log.Printf("加载客户长期记忆失败 %s: %v", customerID, err)
+		return map[string]string{}
+	}
+	memory := make(map[string]string, len(items))
+	for _, item := range items {
+		memory[item.Key] = item.Value
+	}
+	return memory
+}
+
+func (s dbMemoryStore) Save(customerID, botID string, entries []MemoryEntry) {
+	id, err := strconv.ParseUint(customerID, 10, 64)
+	if err != nil {
+		log.Printf("客户ID无效，不保存长期记忆: %s", customerID)
+		return
+	}
+	for _, entry := range entries {
+		item := model.CustomerMemory{CustomerID: id, Key: entry.Key, Value: entry.Value, BotID: botID, ExpiresAt: entry.ExpiresAt}
+		if err := s.db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "customer_id"}, {Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "bot_id", "expires_at", "updated_at"}),
+		}).Create(&item).Error; err != nil {
+			log.Printf("保存客户长期记忆失败 %s.%s: %v", customerID, entry.Key, err)
+		}
+	}
+}
+
+// readOnlyMemoryStore 只读取不写入，影子评估使用
+type readOnlyMemoryStore struct {
+	MemoryStore
+}
+
+func (readOnlyMemoryStore) Save(string, string, []MemoryEntry) {}
+
+// restoreMemory 新会话开始时加载长期记忆，并恢复配置了 restore 的槽位
+func (e *ChatBotEngine) restoreMemory(ctx *ConversationContext) {
+	if e.memory == nil {
+		return
+	}
+	ctx.Memory = e.memory.Load(ctx.CustomerID)
+	for _, slot := range e.rules.ContextManagement.Memory.Slots {
+		value := ctx.Memory[slot.MemoryKey()]
+		if !slot.Restore || value == "" || ctx.Slots[slot.Slot] != "" {
+			continue
+		}
+		if ctx.Slots == nil {
+			ctx.Slots = make(map[string]string)
+		}
+		ctx.Slots[slot.Slot] = value
+	}
+}
+
+// rememberSlots 本轮新填或修改的槽位写入长期记忆；槽位被清空时保留原有记忆
+func (e *ChatBotEngine) rememberSlots(changes []SlotChange, ctx *ConversationContext) {
+	if e.memory == nil || e.dryRun || len(changes) == 0 {
+		return
+	}
+	var entries []MemoryEntry
+	for _, change := range changes {
+		if change.After == "" {
+			continue
+		}
+		for _, slot := range e.rules.ContextManagement.Memory.Slots {
+			if slot.Slot != change.Slot {
+				continue
+			}
+			entry := MemoryEntry{Key: slot.MemoryKey(), Value: change.After}
+			if slot.TTLHours > 0 {
+				expiresAt := e.now().Add(time.Duration(slot.TTLHours) * time.Hour)
+				entry.ExpiresAt = &expiresAt
+			}
+			if ctx.Memory == nil {
+				ctx.Memory = make(map[string]string)
+			}
+			ctx.Memory[entry.Key] = entry.Value
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) > 0 {
+		e.memory.Save(ctx.CustomerID, e.botID, entries)
+	}
+}
+
+// forgetMemory 删除上下文中的记忆，keys 为空时删除全部；由记忆恢复且未被修改的槽位一并清除
+func forgetMemory(ctx *ConversationContext, config MemoryConfig, keys []string) {
+	if len(keys) == 0 {
+		for key := range ctx.Memory {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		value, ok := ctx.Memory[key]
+		if !ok {
+			continue
+		}
+		delete(ctx.Memory, key)
+		for _, slot := range config.Slots {
+			if slot.Restore && slot.MemoryKey() == key && ctx.Slots[slot.Slot] == value {
+				delete(ctx.Slots, slot.Slot)
+			}
+		}
+	}
+}
+
+// ForgetMemory 客户清除长期记忆后，同步删除各机器人在线会话中的记忆，keys 为空时删除全部
+func (r *BotRegistry) ForgetMemory(customerID string, keys ...string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for botID, contexts := range r.contexts {
+		config := r.rules[botID].ContextManagement.Memory
+		contexts.modify(customerID, func(ctx *ConversationContext) {
+			forgetMemory(ctx, config, keys)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package chatbot_test
+
+import (
+	"testing"
+	"time"
+
+	"gochat/internal/service/chatbot"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const memoryRules = `
+intent_detection:
+  regex_patterns:
+    - intent: "weather_query"
+      patterns: ["天气"]
+    - intent: "my_city"
+      patterns: ["我的城市"]
+context_management:
+  entity_extraction:
+    extractors: ["city"]
+  memory:
+    slots:
+      - slot: "city"
+        ttl_hours: 24
+        restore: true
+dialogue_flow:
+  states:
+    - name: "welcome"
+      transitions:
+        - intent: "weather_query"
+          actions:
+            - type: "response"
+              content: "正在查询${slot.city}的天气"
+        - intent: "my_city"
+          actions:
+            - type: "response"
+              content: "您常用的城市是${memory.city}"
+personalization:
+  user_segments:
+    - name: "beijing"
+      condition: "${memory.city == '北京'}"
+      response_modifier:
+        prefix: "【北京】"
+error_handling:
+  default_fallback: "抱歉，我没有理解。"
+`
+
+// fakeMemoryStore 进程内的长期记忆，按 now 判断过期
+type fakeMemoryStore struct {
+	now     func() time.Time
+	entries map[string]map[string]chatbot.MemoryEntry
+}
+
+func (s *fakeMemoryStore) Load(customerID string) map[string]string {
+	memory := map[string]string{}
+	for key, entry := range s.entries[customerID] {
+		if entry.ExpiresAt == nil || entry.ExpiresAt.After(s.now()) {
+			memory[key] = entry.Value
+		}
+	}
+	return memory
+}
+
+func (s *fakeMemoryStore) Save(customerID, botID string, entries []chatbot.MemoryEntry) {
+	if s.entries[customerID] == nil {
+		s.entries[customerID] = map[string]chatbot.MemoryEntry{}
+	}
+	for _, entry := range entries {
+		s.entries[customerID][entry.Key] = entry
+	}
+}
+
+func TestCustomerMemoryAcrossSessions(t *testing.T) {
+	rules, err := chatbot.ParseChatBotRules([]byte(memoryRules))
+	assert.NoError(t, err)
+	assert.NoError(t, rules.Validate())
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := &fakeMemoryStore{now: func() time.Time { return now }, entries: map[string]map[string]chatbot.MemoryEntry{}}
+	registry := chatbot.NewBotRegistry("", chatbot.RoutingPolicy{})
+	registry.Register(chatbot.DefaultBotID, rules)
+	engine := registry.Engine(nil, chatbot.DefaultBotID)
+	engine.SetCustomerLoader(nil)
+	engine.SetClock(func() time.Time { return now })
+	engine.SetMemoryStore(store)
+
+	engine.OpenSession("8001")
+	assert.Equal(t, "正在查询北京的天气", engine.Respond("8001", "北京天气怎么样").Text)
+	engine.CloseSession("8001")
+	if assert.Contains(t, store.entries["8001"], "city") {
+		entry := store.entries["8001"]["city"]
+		assert.Equal(t, "北京", entry.Value)
+		assert.Equal(t, now.Add(24*time.Hour), *entry.ExpiresAt)
+	}
+
+	// 新会话恢复槽位，模板和条件可读取记忆
+	engine.OpenSession("8001")
+	assert.Equal(t, "北京", engine.GetContext("8001").Slots["city"])
+	assert.Equal(t, "【北京】正在查询北京的天气", engine.Respond("8001", "天气").Text)
+	assert.Equal(t, "【北京】您常用的城市是北京", engine.Respond("8001", "我的城市").Text)
+
+	// 客户清除记忆后在线会话同步删除
+	registry.ForgetMemory("8001", "city")
+	ctx := engine.GetContext("8001")
+	assert.Empty(t, ctx.Memory)
+	assert.Empty(t, ctx.Slots["city"])
+	engine.CloseSession("8001")
+
+	// 过期后不再恢复
+	engine.OpenSession("8002")
+	engine.Respond("8002", "上海天气")
+	engine.CloseSession("8002")
+	now = now.Add(25 * time.Hour)
+	engine.OpenSession("8002")
+	defer engine.CloseSession("8002")
+	assert.Empty(t, engine.GetContext("8002").Slots["city"])
+}
@@ -0,0 +1,84 @@
+package chatbot
+
+import (
+	"fmt"
+	"log"
+)
+
+// 内置文本归一化器
+const (
+	NormalizerWidth       = "width"       // 全角转半角
+	NormalizerTraditional = "traditional" // 繁体转简体
+	NormalizerLowercase   = "lowercase"   // 转小写
+	NormalizerEmoji       = "emoji"       // 去除表情符号
+	NormalizerPunctuation = "punctuation" // 去除标点和符号
+	NormalizerRepeat      = "repeat"      // 连续重复3次及以上的字母折叠为1个，如 hellooo → hello
+	NormalizerWhitespace  = "whitespace"  // 合并空白并去除首尾空白
+	NormalizerSynonym     = "synonym"     // 按同义词词典替换
+)
+
+// Normalizer 文本归一化器，在意图识别前依次处理用户消息
+type Normalizer interface {
+	Name() string
+	Normalize(text string) string
+}
+
+// NormalizationConfig 文本归一化配置，对应 intent_detection.normalization
+type NormalizationConfig struct {
+	Normalizers []string          `mapstructure:"normalizers"` // 按顺序执行，未配置时仅转小写
+	Synonyms    map[string]string `mapstructure:"synonyms"`    // 词 → 标准词，在 synonym 之前的归一化结果上匹配
+}
+
+// NormalizerFactory 根据配置创建归一化器
+type NormalizerFactory func(config NormalizationConfig) (Normalizer, error)
+
+var normalizerFactories = map[string]NormalizerFactory{}
+
+// RegisterNormalizer 注册归一化器，规则中按名称启用；同名注册会覆盖内置归一化器
+func RegisterNormalizer(name string, factory NormalizerFactory) {
+	normalizerFactories[name] = factory
+}
+
+// newNormalizers 按配置创建归一化器链
+func newNormalizers(config NormalizationConfig) ([]Normalizer, error) {
+	names := config.Normalizers
+	if len(names) == 0 {
+		names = []string{NormalizerLowercase}
+	}
+	normalizers := make([]Normalizer, 0, len(names))
+	for _, name := range names {
+		factory, ok := normalizerFactories[name]
+		if !ok {
+			return nil, fmt.Errorf("文本归一化器 %s 未注册", name)
+		}
+		normalizer, err := factory(config)
+		if err != nil {
+			return nil, fmt.Errorf("文本归一化器 %s 创建失败: %w", name, err)
+		}
+		normalizers = append(normalizers, normalizer)
+	}
+	return normalizers, nil
+}
+
+// loadNormalizers 创建规则中配置的归一化器，配置无效时仅转小写
+func loadNormalizers(config NormalizationConfig) []Normalizer {
+	normalizers, err := newNormalizers(config)
+	if err != nil {
+		log.Printf("文本归一化配置无效: %v", err)
+		normalizers, _ = newNormalizers(NormalizationConfig{})
+	}
+	return normalizers
+}
+
+// NormalizeText 依次执行归一化器
+func NormalizeText(normalizers []Normalizer, text string) string {
+	for _, normalizer := range normalizers {
+		text = normalizer.Normalize(text)
+	}
+	return text
+}
+
+// Normalize 返回意图识别实际使用的文本，原始消息不受影响
+func (e *ChatBotEngine) Normalize(text string) string {
+	return NormalizeText(e.normalizers, text)
+}
@@ -0,0 +1,167 @@
+package chatbot
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func init() {
+	RegisterNormalizer(NormalizerWidth, staticNormalizer(NormalizerWidth, foldWidth))
+	RegisterNormalizer(NormalizerTraditional, staticNormalizer(NormalizerTraditional, toSimplified))
+	RegisterNormalizer(NormalizerLowercase, staticNormalizer(NormalizerLowercase, strings.ToLower))
+	RegisterNormalizer(NormalizerEmoji, staticNormalizer(NormalizerEmoji, stripEmoji))
+	RegisterNormalizer(NormalizerPunctuation, staticNormalizer(NormalizerPunctuation, stripPunctuation))
+	RegisterNormalizer(NormalizerRepeat, staticNormalizer(NormalizerRepeat, collapseRepeats))
+	RegisterNormalizer(NormalizerWhitespace, staticNormalizer(NormalizerWhitespace, collapseWhitespace))
+	RegisterNormalizer(NormalizerSynonym, func(config NormalizationConfig) (Normalizer, error) {
+		return newSynonymNormalizer(config.Synonyms), nil
+	})
+}
+
+// normalizerFunc 无配置的归一化器
+type normalizerFunc struct {
+	name string
+	fn   func(string) string
+}
+
+func (n normalizerFunc) Name() string                 { return n.name }
+func (n normalizerFunc) Normalize(text string) string { return n.fn(text) }
+
+func staticNormalizer(name string, fn func(string) string) NormalizerFactory {
+	return func(NormalizationConfig) (Normalizer, error) {
+		return normalizerFunc{name: name, fn: fn}, nil
+	}
+}
+
+// foldWidth 全角字母、数字、标点转半角，全角空格转普通空格
+func foldWidth(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '　':
+			return ' '
+		case r >= '！' && r <= '～':
+			return r - 0xFEE0
+		}
+		return r
+	}, text)
+}
+
+// toSimplified 按字表将常用繁体字转为简体
+func toSimplified(text string) string {
+	return strings.Map(func(r rune) rune {
+		if simplified, ok := traditionalToSimplified[r]; ok {
+			return simplified
+		}
+		return r
+	}, text)
+}
+
+// isEmoji 表情符号及其修饰符：国旗、肤色、变体选择符、零宽连接符、键帽
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // 表情、交通、国旗、肤色等
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // 杂项符号与装饰符号
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF: // 箭头与星形
+		return true
+	case r >= 0xFE00 && r <= 0xFE0F, r == 0x200D, r == 0x20E3:
+		return true
+	case r >= 0xE0020 && r <= 0xE007F: // 旗帜标签
+		return true
+	}
+	return false
+}
+
+// stripEmoji 表情符号替换为空格，避免前后文字粘连
+func stripEmoji(text string) string {
+	return strings.Map(func(r rune) rune {
+		if isEmoji(r) {
+			return ' '
+		}
+		return r
+	}, text)
+}
+
+// stripPunctuation 标点和符号替换为空格
+func stripPunctuation(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			return ' '
+		}
+		return r
+	}, text)
+}
+
+// collapseRepeats 连续重复3次及以上的字母折叠为1个，保留 hello 中的 ll、谢谢中的叠字
+func collapseRepeats(text string) string {
+	runes := []rune(text)
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		j := i + 1
+		for j < len(runes) && runes[j] == runes[i] {
+			j++
+		}
+		if j-i >= 3 && unicode.IsLetter(runes[i]) {
+			b.WriteRune(runes[i])
+		} else {
+			b.WriteString(string(runes[i:j]))
+		}
+		i = j
+	}
+	return b.String()
+}
+
+func collapseWhitespace(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// synonymNormalizer 按同义词词典替换，优先匹配最长的词；英文词需完整匹配单词
+type synonymNormalizer struct {
+	words    []string
+	synonyms map[string]string
+}
+
+func newSynonymNormalizer(synonyms map[string]string) synonymNormalizer {
+	words := make([]string, 0, len(synonyms))
+	for word := range synonyms {
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if len(words[i]) != len(words[j]) {
+			return len(words[i]) > len(words[j])
+		}
+		return words[i] < words[j]
+	})
+	return synonymNormalizer{words: words, synonyms: synonyms}
+}
+
+func (n synonymNormalizer) Name() string { return NormalizerSynonym }
+
+func (n synonymNormalizer) Normalize(text string) string {
+	if len(n.words) == 0 {
+		return text
+	}
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		matched := false
+		for _, word := range n.words {
+			if strings.HasPrefix(text[i:], word) && wordBounded(text, i, i+len(word)) {
+				b.WriteString(n.synonyms[word])
+				i += len(word)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			b.WriteString(text[i : i+size])
+			i += size
+		}
+	}
+	return b.String()
+}
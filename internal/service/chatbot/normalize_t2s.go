@@ -0,0 +1,49 @@
+package chatbot
+
+import "strings"
+
+// traditionalPairs 常用繁体字 → 简体字，每组为“繁简”两个字；一繁对多简的字取客服场景最常用的写法
+const traditionalPairs = `
+們们 這这 個个 來来 為为 會会 說说 時时 過过 還还 沒没 麼么 後后 現现 從从 問问 題题 對对 開开 關关
+與与 義义 點点 當当 實实 經经 進进 動动 種种 發发 髮发 體体 學学 長长 東东 車车 門门 見见 頭头 話话
+讓让 給给 幾几 氣气 兩两 應应 將将 無无 電电 機机 號号 錢钱 買买 賣卖 單单 價价 業业 務务 員员 處处
+辦办 決决 聯联 係系 繫系 網网 絡络 線线 帳账 賬账 戶户 碼码 錄录 註注 冊册 證证 驗验 確确 認认 請请
+謝谢 幫帮 歡欢 嗎吗 聽听 讀读 寫写 語语 計计 劃划 設设 備备 軟软 腦脑 隻只 臺台 颱台 灣湾 廣广 圖图
+書书 館馆 場场 醫医 藥药 療疗 護护 雞鸡 魚鱼 飯饭 飲饮 麵面 湯汤 樂乐 遊游 戲戏 歲岁 內内 萬万 億亿
+雙双 層层 樓楼 區区 縣县 鄉乡 鎮镇 國国 際际 華华 溫温 熱热 雲云 風风 陰阴 陽阳 霧雾 預预 報报 週周
+曆历 歷历 鐘钟 錶表 間间 紀纪 壞坏 貴贵 質质 優优 減减 費费 稅税 額额 盤盘 貨货 運运 輸输 遞递 郵邮
+達达 裝装 倉仓 庫库 訂订 購购 換换 賠赔 償偿 損损 維维 險险 紅红 綠绿 藍蓝 黃黄 顏颜 導导 師师 議议
+論论 變变 選选 擇择 歸归 類类 標标 準准 傳传 統统 專专 響响 聲声 態态 總总 結结 籤签 簽签 紙纸 筆笔
+檔档 資资 訊讯 調调 記记 識识 別别 斷断 續续 緊紧 難难 盡尽 興兴 舊旧 親亲 愛爱 樣样 邊边 裡里 裏里
+誰谁 覺觉 雖虽 雜杂 亂乱 讚赞 謊谎 錯错 誤误 罰罚 規规 則则 條条 約约 權权 責责 協协 針针 彈弹 強强
+勁劲 壓压 擊击 戰战 爭争 鬥斗 殺杀 傷伤 災灾 禍祸 喪丧 鬧闹 靜静 輕轻 鬆松 緩缓 漸渐 漲涨 貼贴 補补
+贈赠 獎奖 勵励 懲惩 職职 辭辞 參参 觀观 訪访 衛卫 隊队 團团 夥伙 鄰邻 屬属 於于 並并 兒儿 孫孙 媽妈
+爺爷 婦妇 嬰婴 園园 廳厅 廚厨 廁厕 臥卧 牆墙 燈灯 鎖锁 鑰钥 鐵铁 鋼钢 銀银 銅铜 鑽钻 寶宝 獅狮 貓猫
+豬猪 馬马 鳥鸟 龍龙 龜龟 蟲虫 葉叶 樹树 橋桥 島岛 濕湿 淨净 潔洁 髒脏 濃浓 淺浅 厲厉 嚴严 肅肃 惡恶
+憂忧 慮虑 驚惊 嚇吓 懼惧 慘惨 憤愤 憐怜 願愿 懷怀 夢梦 憶忆 練练 習习 課课 級级 測测 試试 績绩 獲获
+勝胜 敗败 贏赢 競竞 賽赛 隨随 順顺 遠远 離离 閉闭 聞闻 闊阔 闖闯 隱隐 顯显 頁页 項项 須须 頓顿 領领
+頻频 顧顾 飛飞 飽饱 饋馈 餓饿 駕驾 駛驶 騎骑 騙骗 驅驱 鮮鲜 鳳凤 鴨鸭 鵝鹅 鹽盐 麥麦 齊齐 齒齿 嘆叹
+歎叹 啟启 圓圆 圍围 壽寿 夠够 奪夺 奮奋 寧宁 寬宽 審审 尋寻 屆届 岡冈 巖岩 幣币 幹干 廢废 廠厂 彎弯
+徵征 悅悦 惱恼 慣惯 慶庆 憑凭 擁拥 據据 擔担 擴扩 擬拟 攜携 攝摄 敵敌 數数 斂敛 暫暂 曬晒 棄弃 構构
+槍枪 歐欧 殘残 氫氢 湧涌 滅灭 滿满 漁渔 潛潜 澤泽 濟济 灑洒 燒烧 營营 爐炉 牽牵 猶犹 獨独 獻献 環环
+畫画 畢毕 異异 痠酸 癢痒 盜盗 監监 眾众 睏困 矯矫 礙碍 禮礼 禦御 稱称 穩稳 窮穷 節节 範范 築筑 簡简
+糧粮 紐纽 純纯 紗纱 紛纷 細细 終终 組组 絕绝 絲丝 綁绑 緣缘 編编 縮缩 織织 繩绳 繳缴 纏缠 罷罢 羅罗
+聖圣 聰聪 脫脱 腳脚 臉脸 臨临 舉举 艱艰 藝艺 莊庄 蘋苹 蘇苏 蝦虾 螢萤 蠟蜡 衝冲 襪袜 襯衬 覽览 視视
+觸触 訓训 託托 詢询 詳详 誠诚 誇夸 誌志 講讲 貝贝 負负 財财 貢贡 貧贫 販贩 貪贪 貫贯 貸贷 貿贸 賀贺
+賓宾 賞赏 賤贱 賴赖 賺赚 趕赶 趨趋 躍跃 蹤踪 軌轨 軍军 較较 載载 輔辅 輛辆 輪轮 轉转 農农 遲迟 適适
+遺遗 醜丑 釋释 鈔钞 鉛铅 銷销 鋪铺 鏈链 鏡镜 閃闪 閒闲 閱阅 闆板 陣阵 陸陆 陳陈 靈灵 韓韩 頂顶 頒颁
+顆颗 飄飘 飾饰 餅饼 餘余 骯肮 鬍胡 魯鲁 鳴鸣 麗丽 黨党 齡龄
+`
+
+var traditionalToSimplified = parseTraditionalPairs(traditionalPairs)
+
+func parseTraditionalPairs(pairs string) map[rune]rune {
+	table := make(map[rune]rune)
+	for _, pair := range strings.Fields(pairs) {
+		runes := []rune(pair)
+		if len(runes) == 2 {
+			table[runes[0]] = runes[1]
+		}
+	}
+	return table
+}
@@ -0,0 +1,41 @@
+package chatbot_test
+
+import (
+	"testing"
+
+	"gochat/internal/service/chatbot"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeText(t *testing.T) {
+	rules, err := chatbot.ParseChatBotRules([]byte(`
+intent_detection:
+  normalization:
+    normalizers: ["width", "traditional", "lowercase", "emoji", "punctuation", "repeat", "whitespace", "synonym"]
+    synonyms:
+      hi: "hello"
+      天气预报: "天气"
+`))
+	assert.NoError(t, err)
+	engine := chatbot.NewChatBotEngineWithRules(nil, rules)
+
+	for input, expected := range map[string]string{
+		"ＨＥＬＬＯＯＯ！！":        "hello",
+		"Hellooo   World":  "hello world",
+		"👍🏻好的，謝謝！":         "好的 谢谢",
+		"明天天氣預報？😀":         "明天天气",
+		"hi there":         "hello there",
+		"this is it":       "this is it", // 英文同义词需完整匹配单词
+		"订单号：SO-2024-0001": "订单号 so 2024 0001",
+	} {
+		assert.Equal(t, expected, engine.Normalize(input), input)
+	}
+
+	// 未配置时仅转小写，与之前的行为一致
+	plain := chatbot.NewChatBotEngineWithRules(nil, chatbot.ChatBotRules{})
+	assert.Equal(t, "ｈｅｌｌｏ!!", plain.Normalize("ＨＥＬＬＯ!!"))
+
+	rules.IntentDetection.Normalization.Normalizers = []string{"no_such_normalizer"}
+	assert.ErrorContains(t, rules.Validate(), "文本归一化器 no_such_normalizer 未注册")
+}
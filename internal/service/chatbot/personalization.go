@@ -0,0 +1,157 @@
+package chatbot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gochat/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ResponseModifier 回复修饰：前缀、后缀或替换模板（模板中 ${response} 为原回复）
+type ResponseModifier struct {
+	Prefix   string `mapstructure:"prefix"`
+	Suffix   string `mapstructure:"suffix"`
+	Template string `mapstructure:"template"`
+}
+
+// CustomerLoader 加载客户属性，用于用户分群条件判断
+type CustomerLoader func(customerID string) map[string]interface{}
+
+// apply 按 模板 → 前缀 → 后缀 的顺序修饰回复文本
+func (m ResponseModifier) apply(text string, vars map[string]interface{}) string {
+	if m.Template != "" {
+		scoped := map[string]interface{}{"response": text}
+		for k, v := range vars {
+			scoped[k] = v
+		}
+		text = renderTemplate(m.Template, scoped)
+	}
+	return m.Prefix + text + m.Suffix
+}
+
+// newDBCustomerLoader 从 customers 表加载客户属性
+func newDBCustomerLoader(db *gorm.DB) CustomerLoader {
+	return func(customerID string) map[string]interface{} {
+		var customer model.Customer
+		if err := db.Where("id = ?", customerID).Take(&customer).Error; err != nil {
+			log.Printf("加载客户属性失败 %s: %v", customerID, err)
+			return map[string]interface{}{}
+		}
+		return map[string]interface{}{
+			"id":     customer.ID,
+			"name":   customer.CustomerName,
+			"level":  customer.Level,
+			"locale": customer.Locale,
+		}
+	}
+}
+
+// SetCustomerLoader 替换客户属性加载方式
+func (e *ChatBotEngine) SetCustomerLoader(loader CustomerLoader) {
+	e.loadCustomer = loader
+}
+
+// SetClock 替换当前时间来源，用于测试时间规则
+func (e *ChatBotEngine) SetClock(now func() time.Time) {
+	e.now = now
+}
+
+// personalize 根据用户分群和时间规则修饰回复
+func (e *ChatBotEngine) personalize(reply Reply, ctx *ConversationContext) Reply {
+	if reply.Text == "" {
+		return reply
+	}
+	vars := map[string]interface{}{
+		"user":   ctx.User,
+		"slot":   ctx.Slots,
+		"memory": ctx.Memory,
+	}
+
+	for _, segment := range e.rules.Personalization.UserSegments {
+		ok, err := evalCondition(segment.Condition, vars)
+		if err != nil {
+			log.Printf("用户分群 %s 条件错误: %v", segment.Name, err)
+			continue
+		}
+		if ok {
+			reply.Text = segment.ResponseModifier.apply(reply.Text, vars)
+		}
+	}
+
+	now := e.now().In(e.location)
+	for _, rule := range e.rules.Personalization.TimeBasedRules {
+		window, err := parseTimeRange(rule.TimeRange)
+		if err != nil {
+			log.Printf("时间规则错误: %v", err)
+			continue
+		}
+		if window.contains(now) {
+			reply.Text = rule.ResponseModifier.apply(reply.Text, vars) + rule.ResponseSuffix
+		}
+	}
+	return reply
+}
+
+// timeRange 一天内的时间窗口（分钟），start > end 表示跨越零点
+type timeRange struct {
+	start, end int
+}
+
+// parseTimeRange 解析 "00:00-06:00" 形式的时间窗口
+func parseTimeRange(value string) (timeRange, error) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 2 {
+		return timeRange{}, fmt.Errorf("无效的时间范围 %q", value)
+	}
+	start, err := parseClock(parts[0])
+	if err != nil {
+		return timeRange{}, fmt.Errorf("无效的时间范围 %q: %w", value, err)
+	}
+	end, err := parseClock(parts[1])
+	if err != nil {
+		return timeRange{}, fmt.Errorf("无效的时间范围 %q: %w", value, err)
+	}
+	return timeRange{start: start, end: end}, nil
+}
+
+func parseClock(value string) (int, error) {
+	hm := strings.Split(strings.TrimSpace(value), ":")
+	if len(hm) != 2 {
+		return 0, fmt.Errorf("时间格式应为 HH:MM")
+	}
+	hour, err := strconv.Atoi(hm[0])
+	if err != nil || hour < 0 || hour > 24 {
+		return 0, fmt.Errorf("无效的小时 %q", hm[0])
+	}
+	minute, err := strconv.Atoi(hm[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("无效的分钟 %q", hm[1])
+	}
+	return hour*60 + minute, nil
+}
+
+func (r timeRange) contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if r.start <= r.end {
+		return minute >= r.start && minute < r.end
+	}
+	return minute >= r.start || minute < r.end
+}
+
+// loadLocation 加载配置的时区，未配置或无效时使用本地时区
+func loadLocation(name string) *time.Location {
+	if name == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("时区 %s 无效，使用本地时区: %v", name, err)
+		return time.Local
+	}
+	return loc
+}
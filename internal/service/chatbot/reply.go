@@ -0,0 +1,133 @@
+package chatbot
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// 富媒体消息类型
+const (
+	RichTypeQuickReply = "quick_reply"
+	RichTypeCarousel   = "carousel"
+	RichTypeCard       = "card"
+)
+
+// Reply 机器人回复，可同时包含文本和富媒体消息
+type Reply struct {
+	Text      string        `json:"text,omitempty"`
+	Rich      []RichContent `json:"rich,omitempty"`
+	Intent    string        `json:"intent,omitempty"`    // 本轮识别到的意图
+	Fallback  bool          `json:"fallback,omitempty"`  // 是否为兜底回复
+	Handoff   bool          `json:"handoff,omitempty"`   // 是否需要转接人工
+	Escalated bool          `json:"escalated,omitempty"` // 是否因连续兜底等原因触发升级
+	Generated bool          `json:"generated,omitempty"` // 是否由生成式模型生成，入库时标记为 ai
+
+	EndConversation bool   `json:"end_conversation,omitempty"` // 客户多次沉默后结束会话，发送后断开连接
+	Trace           *Trace `json:"trace,omitempty"`            // 本轮的决策记录
+}
+
+// RichContent 富媒体消息：快捷回复按钮、轮播或卡片
+type RichContent struct {
+	Type     string        `mapstructure:"type" json:"type"`
+	Name     string        `mapstructure:"name" json:"-"` // 模板名，供 rich 动作引用
+	Title    string        `mapstructure:"title" json:"title,omitempty"`
+	Subtitle string        `mapstructure:"subtitle" json:"subtitle,omitempty"`
+	Image    string        `mapstructure:"image" json:"image,omitempty"`
+	Buttons  []Button      `mapstructure:"buttons" json:"buttons,omitempty"`
+	Items    []RichContent `mapstructure:"items" json:"items,omitempty"` // 轮播中的卡片
+}
+
+// Button 按钮，点击后客户端回传 Payload 或打开 URL
+type Button struct {
+	Title   string `mapstructure:"title" json:"title"`
+	Payload string `mapstructure:"payload" json:"payload,omitempty"`
+	URL     string `mapstructure:"url" json:"url,omitempty"`
+}
+
+// Postback 客户端点击按钮后回传的消息
+type Postback struct {
+	Type    string `json:"type"` // 固定为 postback
+	Payload string `json:"payload"`
+	Title   string `json:"title,omitempty"`
+}
+
+// ParsePostback 解析客户端消息，非 postback 消息返回 false
+func ParsePostback(msg string) (Postback, bool) {
+	var postback Postback
+	if !strings.HasPrefix(strings.TrimSpace(msg), "{") {
+		return postback, false
+	}
+	if err := json.Unmarshal([]byte(msg), &postback); err != nil {
+		return postback, false
+	}
+	return postback, postback.Type == "postback" && postback.Payload != ""
+}
+
+// IsRich 是否包含富媒体消息
+func (r Reply) IsRich() bool {
+	return len(r.Rich) > 0
+}
+
+// PlainText 返回回复的纯文本形式，仅有富媒体消息时使用其标题
+func (r Reply) PlainText() string {
+	if r.Text != "" || !r.IsRich() {
+		return r.Text
+	}
+	var titles []string
+	for _, rich := range r.Rich {
+		titles = append(titles, rich.summary()...)
+	}
+	return strings.Join(titles, " / ")
+}
+
+func (c RichContent) summary() []string {
+	var titles []string
+	if c.Title != "" {
+		titles = append(titles, c.Title)
+	}
+	for _, button := range c.Buttons {
+		titles = append(titles, button.Title)
+	}
+	for _, item := range c.Items {
+		titles = append(titles, item.summary()...)
+	}
+	return titles
+}
+
+// RichJSON 返回富媒体消息的JSON，用于持久化
+func (r Reply) RichJSON() string {
+	if !r.IsRich() {
+		return ""
+	}
+	data, _ := json.Marshal(r.Rich)
+	return string(data)
+}
+
+// TraceJSON 返回决策记录的JSON，用于持久化
+func (r Reply) TraceJSON() string {
+	if r.Trace == nil {
+		return ""
+	}
+	data, _ := json.Marshal(r.Trace)
+	return string(data)
+}
+
+// findRichTemplate 按名称查找富媒体模板
+func (r *ChatBotRules) findRichTemplate(name string) (RichContent, bool) {
+	for _, rich := range r.ResponseTemplates.RichContent {
+		if rich.Name == name {
+			return rich, true
+		}
+	}
+	return RichContent{}, false
+}
+
+// intentForPayload 将按钮回传的 payload 映射为意图，未配置时使用小写的 payload
+func (r *ChatBotRules) intentForPayload(payload string) string {
+	for _, postback := range r.IntentDetection.Postbacks {
+		if strings.EqualFold(postback.Payload, payload) {
+			return postback.Intent
+		}
+	}
+	return strings.ToLower(payload)
+}
@@ -0,0 +1,52 @@
+package chatbot_test
+
+import (
+	"testing"
+
+	"gochat/internal/service/chatbot"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const responseRules = `
+intent_detection:
+  regex_patterns:
+    - intent: "greeting"
+      patterns: ["你好"]
+dialogue_flow:
+  states:
+    - name: "welcome"
+      transitions:
+        - intent: "greeting"
+          next_state: "welcome"
+          actions:
+            - type: "set_context"
+              key: "city"
+              value: "北京"
+            - type: "response"
+              content: "${user.name}您好，${slot.city}今天晴，${slot.date}再见"
+              i18n:
+                en-US: "Hi ${user.name}, it's sunny in ${slot.city}"
+error_handling:
+  default_fallback: "抱歉，我没有理解。"
+`
+
+func TestResponseRendersPlaceholders(t *testing.T) {
+	rules, err := chatbot.ParseChatBotRules([]byte(responseRules))
+	assert.NoError(t, err)
+	assert.NoError(t, rules.Validate())
+
+	engine := chatbot.NewChatBotEngineWithRules(nil, rules)
+	engine.SetCustomerLoader(func(customerID string) map[string]interface{} {
+		return map[string]interface{}{"name": "张三"}
+	})
+
+	// 槽位和客户属性被替换，未定义的占位符保持原样
+	reply := engine.Respond("4101", "你好")
+	assert.Equal(t, "张三您好，北京今天晴，${slot.date}再见", reply.Text)
+
+	// 多语言文本同样渲染
+	engine.SetLocale("4102", "en-US")
+	reply = engine.Respond("4102", "你好")
+	assert.Equal(t, "Hi 张三, it's sunny in 北京", reply.Text)
+}
@@ -0,0 +1,144 @@
+package chatbot
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gochat/internal/model"
+	"gochat/internal/service/i18n"
+	"gochat/internal/service/reminder"
+)
+
+// 定时消息动作类型
+const (
+	ActionScheduleMessage = "schedule_message" // 在指定时间向客户发送 content，任务ID写入 result_key 槽位
+	ActionCancelSchedule  = "cancel_schedule"  // 取消 key 槽位中的定时消息并清空该槽位
+)
+
+// defaultScheduleTime params.at 只有日期时使用的发送时间
+const defaultScheduleTime = "09:00"
+
+func init() {
+	RegisterActionHandler(ActionScheduleMessage, ActionHandlerFunc(scheduleMessageAction))
+	RegisterActionHandler(ActionCancelSchedule, ActionHandlerFunc(cancelScheduleAction))
+}
+
+// SetScheduler 替换定时消息调度器，nil 表示不支持定时消息
+func (e *ChatBotEngine) SetScheduler(scheduler reminder.Scheduler) {
+	e.scheduler = scheduler
+}
+
+// scheduleMessageAction 渲染 content 后按 params.at 或 params.delay 创建定时消息：
+// at 渲染后为 "2006-01-02 15:04"、"2006-01-02" 或 "15:04"，按机器人时区解析，只有日期时使用 params.default_time；
+// 只有时间且已过时顺延到明天；delay 为时长，如 30m、2h。时间无效或已过时错误码为 400，未配置调度器时为 503
+func scheduleMessageAction(actx *ActionContext, action Action) (ActionResult, error) {
+	scheduler := actx.engine.scheduler
+	if scheduler == nil {
+		return ActionResult{}, &ActionError{Code: http.StatusServiceUnavailable, Err: errors.New("未配置定时消息调度器")}
+	}
+	customerID, err := strconv.ParseUint(actx.CustomerID, 10, 64)
+	if err != nil {
+		return ActionResult{}, &ActionError{Code: http.StatusBadRequest, Err: fmt.Errorf("客户ID无效: %s", actx.CustomerID)}
+	}
+	content := actx.Render(i18n.Pick(action.I18n, actx.Locale, action.Content))
+	if content == "" {
+		return ActionResult{}, fmt.Errorf("schedule_message 缺少 content")
+	}
+	dueAt, err := scheduleTime(actx, action.Params)
+	if err != nil {
+		return ActionResult{}, &ActionError{Code: http.StatusBadRequest, Err: err}
+	}
+
+	item, err := scheduler.Schedule(customerID, actx.BotID, content, dueAt)
+	if err != nil {
+		return ActionResult{}, &ActionError{Code: http.StatusServiceUnavailable, Err: err}
+	}
+	var result ActionResult
+	if action.ResultKey != "" {
+		result.Slots = map[string]string{action.ResultKey: strconv.FormatUint(uint64(item.ID), 10)}
+	}
+	return result, nil
+}
+
+// scheduleTime 计算发送时间
+func scheduleTime(actx *ActionContext, params map[string]interface{}) (time.Time, error) {
+	now := actx.Now.In(actx.engine.location)
+	if raw, ok := params["delay"]; ok {
+		delay, err := time.ParseDuration(actx.Render(fmt.Sprint(raw)))
+		if err != nil || delay <= 0 {
+			return time.Time{}, fmt.Errorf("delay 无效: %v", raw)
+		}
+		return now.Add(delay), nil
+	}
+
+	raw, ok := params["at"]
+	if !ok {
+		return time.Time{}, errors.New("schedule_message 缺少 params.at 或 params.delay")
+	}
+	// 未填充的槽位按空值处理，如只有时间时 "${slot.date} 09:00" 视为 "09:00"
+	at := strings.TrimSpace(placeholderPattern.ReplaceAllString(actx.Render(fmt.Sprint(raw)), ""))
+	if date, err := time.ParseInLocation("2006-01-02", at, now.Location()); err == nil {
+		clock := defaultScheduleTime
+		if value, ok := params["default_time"]; ok {
+			clock = actx.Render(fmt.Sprint(value))
+		}
+		at = date.Format("2006-01-02") + " " + clock
+	}
+
+	var dueAt time.Time
+	if clock, err := time.ParseInLocation("15:04", at, now.Location()); err == nil {
+		dueAt = time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+		if !dueAt.After(now) {
+			dueAt = dueAt.AddDate(0, 0, 1)
+		}
+	} else if dueAt, err = time.ParseInLocation("2006-01-02 15:04", at, now.Location()); err != nil {
+		return time.Time{}, fmt.Errorf("提醒时间无效: %q", at)
+	}
+	if !dueAt.After(now) {
+		return time.Time{}, fmt.Errorf("提醒时间已过: %s", dueAt.Format("2006-01-02 15:04"))
+	}
+	return dueAt, nil
+}
+
+// cancelScheduleAction 取消 key 槽位中保存的定时消息，已投递或已取消时只清空槽位
+func cancelScheduleAction(actx *ActionContext, action Action) (ActionResult, error) {
+	scheduler := actx.engine.scheduler
+	if scheduler == nil {
+		return ActionResult{}, &ActionError{Code: http.StatusServiceUnavailable, Err: errors.New("未配置定时消息调度器")}
+	}
+	if action.Key == "" {
+		return ActionResult{}, fmt.Errorf("cancel_schedule 缺少 key")
+	}
+	customerID, err := strconv.ParseUint(actx.CustomerID, 10, 64)
+	if err != nil {
+		return ActionResult{}, &ActionError{Code: http.StatusBadRequest, Err: fmt.Errorf("客户ID无效: %s", actx.CustomerID)}
+	}
+	id, err := strconv.ParseUint(actx.Slots[action.Key], 10, 64)
+	if err != nil {
+		return ActionResult{}, &ActionError{Code: http.StatusNotFound, Err: reminder.ErrNotFound}
+	}
+
+	err = scheduler.Cancel(customerID, uint(id))
+	switch {
+	case errors.Is(err, reminder.ErrNotFound):
+		return ActionResult{}, &ActionError{Code: http.StatusNotFound, Err: err}
+	case err != nil && !errors.Is(err, reminder.ErrNotCancellable):
+		return ActionResult{}, &ActionError{Code: http.StatusServiceUnavailable, Err: err}
+	}
+	return ActionResult{Slots: map[string]string{action.Key: ""}}, nil
+}
+
+// discardScheduler 不保存任何任务，影子评估使用，避免候选规则向客户发送消息
+type discardScheduler struct{}
+
+func (discardScheduler) Schedule(customerID uint64, botID, message string, dueAt time.Time) (model.ScheduledMessage, error) {
+	return model.ScheduledMessage{CustomerID: customerID, BotID: botID, Message: message, DueAt: dueAt, Status: model.ScheduledPending}, nil
+}
+
+func (discardScheduler) Cancel(uint64, uint) error { return nil }
+
+func (discardScheduler) List(uint64, ...string) ([]model.ScheduledMessage, error) { return nil, nil }
@@ -0,0 +1,193 @@
+package chatbot_test
+
+import (
+	"testing"
+	"time"
+
+	"gochat/internal/model"
+	"gochat/internal/service/chatbot"
+	"gochat/internal/service/reminder"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const scheduleRules = `
+intent_detection:
+  regex_patterns:
+    - intent: "set_reminder"
+      patterns: ["提醒我", "(?i)remind me"]
+    - intent: "follow_up"
+      patterns: ["稍后联系"]
+    - intent: "cancel_reminder"
+      patterns: ["不用提醒"]
+context_management:
+  entity_extraction:
+    extractors: ["date", "time"]
+dialogue_flow:
+  states:
+    - name: "welcome"
+      transitions:
+        - intent: "set_reminder"
+          actions:
+            - type: "schedule_message"
+              content: "提醒：${slot.date} ${slot.time}"
+              params:
+                at: "${slot.date} ${slot.time}"
+              result_key: "reminder_id"
+            - type: "response"
+              content: "好的，已设置提醒"
+        - intent: "follow_up"
+          actions:
+            - type: "schedule_message"
+              content: "您好，请问问题解决了吗？"
+              params:
+                delay: "30m"
+        - intent: "cancel_reminder"
+          actions:
+            - type: "cancel_schedule"
+              key: "reminder_id"
+            - type: "response"
+              content: "已取消提醒"
+personalization:
+  timezone: "Asia/Shanghai"
+error_handling:
+  default_fallback: "抱歉，我没有理解。"
+`
+
+// fakeScheduler 进程内保存定时消息
+type fakeScheduler struct {
+	items []model.ScheduledMessage
+}
+
+func (s *fakeScheduler) Schedule(customerID uint64, botID, message string, dueAt time.Time) (model.ScheduledMessage, error) {
+	item := model.ScheduledMessage{ID: uint(len(s.items) + 1), CustomerID: customerID, BotID: botID, Message: message, DueAt: dueAt, Status: model.ScheduledPending}
+	s.items = append(s.items, item)
+	return item, nil
+}
+
+func (s *fakeScheduler) Cancel(customerID uint64, id uint) error {
+	for i := range s.items {
+		if s.items[i].ID == id && s.items[i].CustomerID == customerID {
+			if s.items[i].Status != model.ScheduledPending {
+				return reminder.ErrNotCancellable
+			}
+			s.items[i].Status = model.ScheduledCancelled
+			return nil
+		}
+	}
+	return reminder.ErrNotFound
+}
+
+func (s *fakeScheduler) List(customerID uint64, statuses ...string) ([]model.ScheduledMessage, error) {
+	return s.items, nil
+}
+
+func TestScheduleMessage(t *testing.T) {
+	rules, err := chatbot.ParseChatBotRules([]byte(scheduleRules))
+	assert.NoError(t, err)
+	assert.NoError(t, rules.Validate())
+	engine := chatbot.NewChatBotEngineWithRules(nil, rules)
+	scheduler := &fakeScheduler{}
+	engine.SetScheduler(scheduler)
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, loc)
+	engine.SetClock(func() time.Time { return now })
+
+	reply := engine.Respond("8001", "明天上午9点提醒我开会")
+	assert.Equal(t, "好的，已设置提醒", reply.Text)
+	if assert.Len(t, scheduler.items, 1) {
+		item := scheduler.items[0]
+		assert.Equal(t, uint64(8001), item.CustomerID)
+		assert.Equal(t, "提醒：2024-01-02 09:00", item.Message)
+		assert.True(t, item.DueAt.Equal(time.Date(2024, 1, 2, 9, 0, 0, 0, loc)))
+	}
+	assert.Equal(t, "1", engine.GetContext("8001").Slots["reminder_id"])
+
+	reply = engine.Respond("8001", "不用提醒了")
+	assert.Equal(t, "已取消提醒", reply.Text)
+	assert.Equal(t, model.ScheduledCancelled, scheduler.items[0].Status)
+	assert.Empty(t, engine.GetContext("8001").Slots["reminder_id"])
+
+	// 只有时间且已过时顺延到明天
+	engine.Respond("8002", "remind me at 8am")
+	assert.True(t, scheduler.items[1].DueAt.Equal(time.Date(2024, 1, 2, 8, 0, 0, 0, loc)))
+
+	engine.Respond("8003", "稍后联系我")
+	assert.True(t, scheduler.items[2].DueAt.Equal(now.Add(30*time.Minute)))
+
+	// 提醒时间已过时兜底
+	reply = engine.Respond("8004", "2023-12-31 9:00 提醒我")
+	assert.Equal(t, "抱歉，我没有理解。", reply.Text)
+	assert.Len(t, scheduler.items, 3)
+}
+
+// TestScheduleReminderClearsSlots 连续设置两条提醒，第二条只说时间时不沿用第一条的日期
+func TestScheduleReminderClearsSlots(t *testing.T) {
+	rules, err := chatbot.LoadChatBotRulesFromFile("../../../config/chatbot_rules.yml")
+	assert.NoError(t, err)
+	engine := chatbot.NewChatBotEngineWithRules(nil, rules)
+	scheduler := &fakeScheduler{}
+	engine.SetScheduler(scheduler)
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, loc)
+	engine.SetClock(func() time.Time { return now })
+
+	assert.Equal(t, "好的，已为您设置提醒。", engine.Respond("8005", "明天9点提醒我开会").Text)
+	ctx := engine.GetContext("8005")
+	assert.Empty(t, ctx.Slots["date"])
+	assert.Empty(t, ctx.Slots["time"])
+
+	assert.Equal(t, "好的，已为您设置提醒。", engine.Respond("8005", "下午3点提醒我喝水").Text)
+	if assert.Len(t, scheduler.items, 2) {
+		assert.True(t, scheduler.items[0].DueAt.Equal(time.Date(2024, 1, 2, 9, 0, 0, 0, loc)))
+		assert.True(t, scheduler.items[1].DueAt.Equal(time.Date(2024, 1, 1, 15, 0, 0, 0, loc)))
+	}
+	assert.Equal(t, "2", engine.GetContext("8005").Slots["reminder_id"])
+}
+
+func TestScheduleMessageWithoutScheduler(t *testing.T) {
+	rules, err := chatbot.ParseChatBotRules([]byte(scheduleRules))
+	assert.NoError(t, err)
+	engine := chatbot.NewChatBotEngineWithRules(nil, rules)
+	engine.SetScheduler(nil)
+
+	reply := engine.Respond("8101", "明天9点提醒我")
+	assert.True(t, reply.Fallback)
+	assert.Equal(t, 503, engine.GetContext("8101").LastErrorCode)
+}
+
+func TestExtractTime(t *testing.T) {
+	rules, err := chatbot.ParseChatBotRules([]byte(scheduleRules))
+	assert.NoError(t, err)
+	engine := chatbot.NewChatBotEngineWithRules(nil, rules)
+
+	cases := map[string]string{
+		"明天9点提醒我":          "09:00",
+		"下午3点半":            "15:30",
+		"晚上十点一刻":           "22:15",
+		"中午12点":            "12:00",
+		"凌晨12点":            "00:00",
+		"14:05 开会":         "14:05",
+		"tomorrow at 9":    "09:00",
+		"tomorrow at 9 pm": "21:00",
+		"7:30am":           "07:30",
+		"12 a.m.":          "00:00",
+		"lunch at noon":    "12:00",
+		"ten o'clock":      "",
+		"3 o'clock":        "03:00",
+		"快一点":              "",
+		"我要3个":             "",
+		"25点":              "",
+	}
+	for text, want := range cases {
+		engine.Respond("8201", text)
+		var got string
+		for _, entity := range engine.GetContext("8201").Entities {
+			if entity.Type == chatbot.EntityTime {
+				got = entity.Value
+				break
+			}
+		}
+		assert.Equal(t, want, got, text)
+	}
+}
@@ -0,0 +1,171 @@
+package chatbot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConversationScript 对话脚本，描述一段完整的用户对话及每轮的期望结果
+type ConversationScript struct {
+	Name       string                 `yaml:"name"`
+	Rules      string                 `yaml:"rules"`       // 可选，规则文件路径（相对脚本文件），为空时使用调用方指定的规则
+	CustomerID string                 `yaml:"customer_id"` // 可选，默认为脚本名
+	Now        string                 `yaml:"now"`         // 可选，RFC3339格式的当前时间，默认为白天的固定时间
+	User       map[string]interface{} `yaml:"user"`        // 可选，客户属性，如 level
+	Turns      []ScriptTurn           `yaml:"turns"`
+
+	file string // 脚本来源文件
+}
+
+// ScriptTurn 单轮对话：用户输入（或按钮回传）及期望的回复、状态、槽位
+type ScriptTurn struct {
+	User          string            `yaml:"user"`
+	Postback      string            `yaml:"postback"`       // 按钮回传的 payload，设置时忽略 user
+	Reply         *string           `yaml:"reply"`          // 完全匹配
+	ReplyContains string            `yaml:"reply_contains"` // 子串匹配
+	ReplyRegex    string            `yaml:"reply_regex"`    // 正则匹配
+	Rich          []string          `yaml:"rich"`           // 期望的富媒体消息类型，按顺序匹配
+	State         string            `yaml:"state"`
+	Slots         map[string]string `yaml:"slots"`
+}
+
+// File 返回脚本来源文件
+func (s ConversationScript) File() string {
+	return s.file
+}
+
+// RulesPath 返回脚本指定的规则文件路径，未指定时返回空字符串
+func (s ConversationScript) RulesPath() string {
+	if s.Rules == "" || filepath.IsAbs(s.Rules) || s.file == "" {
+		return s.Rules
+	}
+	return filepath.Join(filepath.Dir(s.file), s.Rules)
+}
+
+// LoadConversationScript 加载单个对话脚本
+func LoadConversationScript(path string) (ConversationScript, error) {
+	var script ConversationScript
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return script, err
+	}
+	if err := yaml.Unmarshal(data, &script); err != nil {
+		return script, fmt.Errorf("脚本解析失败 %s: %w", path, err)
+	}
+	script.file = path
+	if script.Name == "" {
+		script.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if script.CustomerID == "" {
+		script.CustomerID = script.Name
+	}
+	return script, nil
+}
+
+// LoadConversationScripts 加载目录下所有 .yml/.yaml 对话脚本
+func LoadConversationScripts(dir string) ([]ConversationScript, error) {
+	var files []string
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+
+	scripts := make([]ConversationScript, 0, len(files))
+	for _, file := range files {
+		script, err := LoadConversationScript(file)
+		if err != nil {
+			return nil, err
+		}
+		scripts = append(scripts, script)
+	}
+	return scripts, nil
+}
+
+// defaultScriptTime 脚本未指定时间时使用的固定时间，避免结果受运行时刻影响
+const defaultScriptTime = "2024-01-01T12:00:00+08:00"
+
+// Run 在引擎上逐轮回放脚本，返回所有不符合期望的结果；
+// 脚本会替换引擎的时钟和客户属性来源，每个脚本应使用独立的引擎
+func (s ConversationScript) Run(engine *ChatBotEngine) []error {
+	now := s.Now
+	if now == "" {
+		now = defaultScriptTime
+	}
+	at, err := time.Parse(time.RFC3339, now)
+	if err != nil {
+		return []error{fmt.Errorf("%s 时间格式无效 %q: %w", s.Name, now, err)}
+	}
+	engine.SetClock(func() time.Time { return at })
+	engine.SetCustomerLoader(func(string) map[string]interface{} {
+		user := make(map[string]interface{}, len(s.User))
+		for k, v := range s.User {
+			user[k] = v
+		}
+		return user
+	})
+
+	var failures []error
+	for i, turn := range s.Turns {
+		input := turn.User
+		var reply Reply
+		if turn.Postback != "" {
+			input = "postback:" + turn.Postback
+			reply = engine.HandlePostback(s.CustomerID, turn.Postback)
+		} else {
+			reply = engine.Respond(s.CustomerID, turn.User)
+		}
+		ctx := engine.GetContext(s.CustomerID)
+		for _, err := range turn.check(reply, ctx) {
+			failures = append(failures, fmt.Errorf("%s 第%d轮 %q: %w", s.Name, i+1, input, err))
+		}
+	}
+	return failures
+}
+
+func (t ScriptTurn) check(result Reply, ctx ConversationContext) []error {
+	var errs []error
+	reply := result.PlainText()
+	if t.Reply != nil && reply != *t.Reply {
+		errs = append(errs, fmt.Errorf("回复 %q，期望 %q", reply, *t.Reply))
+	}
+	if t.ReplyContains != "" && !strings.Contains(reply, t.ReplyContains) {
+		errs = append(errs, fmt.Errorf("回复 %q 不包含 %q", reply, t.ReplyContains))
+	}
+	if t.ReplyRegex != "" {
+		re, err := regexp.Compile(t.ReplyRegex)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("无效的正则 %q: %w", t.ReplyRegex, err))
+		} else if !re.MatchString(reply) {
+			errs = append(errs, fmt.Errorf("回复 %q 不匹配 %q", reply, t.ReplyRegex))
+		}
+	}
+	if t.Rich != nil {
+		var types []string
+		for _, rich := range result.Rich {
+			types = append(types, rich.Type)
+		}
+		if strings.Join(types, ",") != strings.Join(t.Rich, ",") {
+			errs = append(errs, fmt.Errorf("富媒体消息 %v，期望 %v", types, t.Rich))
+		}
+	}
+	if t.State != "" && ctx.CurrentState != t.State {
+		errs = append(errs, fmt.Errorf("状态 %q，期望 %q", ctx.CurrentState, t.State))
+	}
+	for key, want := range t.Slots {
+		if got, ok := ctx.Slots[key]; !ok || got != want {
+			errs = append(errs, fmt.Errorf("槽位 %s=%q，期望 %q", key, got, want))
+		}
+	}
+	return errs
+}
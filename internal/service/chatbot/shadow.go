@@ -0,0 +1,99 @@
+package chatbot
+
+import (
+	"gochat/internal/service/event"
+
+	"gorm.io/gorm"
+)
+
+// shadowCandidate 影子评估中的候选规则
+type shadowCandidate struct {
+	version int
+	rules   ChatBotRules
+	engine  *ChatBotEngine // 首次获取时创建，候选规则的所有连接共享
+}
+
+// ShadowComparison 同一条消息在生产规则与候选规则下的回复差异
+type ShadowComparison struct {
+	IntentChanged   bool
+	ResponseChanged bool
+	FallbackChanged bool
+	HandoffChanged  bool
+}
+
+// Changed 是否存在任何差异
+func (c ShadowComparison) Changed() bool {
+	return c.IntentChanged || c.ResponseChanged || c.FallbackChanged || c.HandoffChanged
+}
+
+// CompareShadow 比较生产回复与候选回复，回复内容包含文本和富媒体
+func CompareShadow(production, candidate Reply) ShadowComparison {
+	return ShadowComparison{
+		IntentChanged:   production.Intent != candidate.Intent,
+		ResponseChanged: production.PlainText() != candidate.PlainText() || production.RichJSON() != candidate.RichJSON(),
+		FallbackChanged: production.Fallback != candidate.Fallback,
+		HandoffChanged:  production.Handoff != candidate.Handoff,
+	}
+}
+
+// SetCandidate 设置机器人的影子评估候选规则，新连接会同时在候选规则上处理消息
+func (r *BotRegistry) SetCandidate(botID string, version int, rules ChatBotRules) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.candidates[botID] = shadowCandidate{version: version, rules: rules}
+}
+
+// ClearCandidate 停止机器人的影子评估
+func (r *BotRegistry) ClearCandidate(botID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.candidates, botID)
+}
+
+// Candidates 返回正在影子评估的机器人及候选版本
+func (r *BotRegistry) Candidates() map[string]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := make(map[string]int, len(r.candidates))
+	for botID, candidate := range r.candidates {
+		versions[botID] = candidate.version
+	}
+	return versions
+}
+
+// ShadowEngine 返回候选规则的影子引擎，未设置候选时返回 false；
+// 影子引擎以试运行方式处理消息：跳过 call_api 等有副作用的动作，不发布事件、不调用生成式模型、不写入长期记忆，
+// 只用于比较，不回复用户；上下文独立于生产引擎
+func (r *BotRegistry) ShadowEngine(db *gorm.DB, botID string) (*ChatBotEngine, int, bool) {
+	r.mu.RLock()
+	candidate, ok := r.candidates[botID]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, 0, false
+	}
+	if candidate.engine != nil {
+		return candidate.engine, candidate.version, true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	candidate, ok = r.candidates[botID]
+	if !ok {
+		return nil, 0, false
+	}
+	if candidate.engine == nil {
+		candidate.engine = NewChatBotEngineWithRules(db, candidate.rules)
+		candidate.engine.botID = botID
+		candidate.engine.dryRun = true
+		candidate.engine.bus = event.NewBus()
+		candidate.engine.generator = nil
+		if candidate.engine.scheduler != nil {
+			candidate.engine.scheduler = discardScheduler{}
+		}
+		if candidate.engine.memory != nil {
+			candidate.engine.memory = readOnlyMemoryStore{candidate.engine.memory}
+		}
+		r.candidates[botID] = candidate
+	}
+	return candidate.engine, candidate.version, true
+}
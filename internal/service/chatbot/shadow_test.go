@@ -0,0 +1,99 @@
+package chatbot_test
+
+import (
+	"testing"
+	"time"
+
+	"gochat/internal/service/chatbot"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShadowEngineComparesCandidate(t *testing.T) {
+	production, err := chatbot.LoadChatBotRulesFromFile("config/chatbot_rules.yml")
+	assert.NoError(t, err)
+	candidate, err := chatbot.LoadChatBotRulesFromFile("config/chatbot_rules.yml")
+	assert.NoError(t, err)
+	// 候选规则不再识别“天气”
+	candidate.IntentDetection.RegexPatterns = candidate.IntentDetection.RegexPatterns[:1]
+	candidate.IntentDetection.KeywordMatching.Keywords = nil
+
+	registry := chatbot.NewBotRegistry("", chatbot.RoutingPolicy{})
+	registry.Register(chatbot.DefaultBotID, production)
+
+	_, _, ok := registry.ShadowEngine(nil, chatbot.DefaultBotID)
+	assert.False(t, ok)
+
+	registry.SetCandidate(chatbot.DefaultBotID, 7, candidate)
+	assert.Equal(t, map[string]int{chatbot.DefaultBotID: 7}, registry.Candidates())
+
+	clock := func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+	live := registry.Engine(nil, chatbot.DefaultBotID)
+	live.SetClock(clock)
+	shadow, version, ok := registry.ShadowEngine(nil, chatbot.DefaultBotID)
+	assert.True(t, ok)
+	assert.Equal(t, 7, version)
+	shadow.SetClock(clock)
+
+	comparison := chatbot.CompareShadow(live.Respond("6001", "你好"), shadow.Respond("6001", "你好"))
+	assert.False(t, comparison.Changed())
+
+	comparison = chatbot.CompareShadow(live.Respond("6001", "明天天气怎么样"), shadow.Respond("6001", "明天天气怎么样"))
+	assert.True(t, comparison.IntentChanged)
+	assert.True(t, comparison.ResponseChanged)
+	assert.True(t, comparison.FallbackChanged)
+	assert.False(t, comparison.HandoffChanged)
+
+	// 影子引擎的状态独立于生产引擎
+	assert.Equal(t, "weather_query", live.GetContext("6001").CurrentState)
+	assert.Equal(t, "welcome", shadow.GetContext("6001").CurrentState)
+
+	registry.ClearCandidate(chatbot.DefaultBotID)
+	assert.Empty(t, registry.Candidates())
+}
+
+func TestShadowEngineSkipsSideEffects(t *testing.T) {
+	var refunds int
+	chatbot.RegisterActionHandler("shadow_test_refund", chatbot.ActionHandlerFunc(
+		func(actx *chatbot.ActionContext, action chatbot.Action) (chatbot.ActionResult, error) {
+			refunds++
+			return chatbot.ActionResult{}, nil
+		}))
+	rules, err := chatbot.ParseChatBotRules([]byte(`
+intent_detection:
+  regex_patterns:
+    - intent: "refund"
+      patterns: ["退款"]
+dialogue_flow:
+  states:
+    - name: "welcome"
+      transitions:
+        - intent: "refund"
+          actions:
+            - type: "shadow_test_refund"
+            - type: "response"
+              content: "已为您提交退款"
+error_handling:
+  default_fallback: "抱歉，我没有理解。"
+`))
+	assert.NoError(t, err)
+	assert.NoError(t, rules.Validate())
+
+	registry := chatbot.NewBotRegistry("", chatbot.RoutingPolicy{})
+	registry.Register(chatbot.DefaultBotID, rules)
+	registry.SetCandidate(chatbot.DefaultBotID, 2, rules)
+	live := registry.Engine(nil, chatbot.DefaultBotID)
+	shadow, _, ok := registry.ShadowEngine(nil, chatbot.DefaultBotID)
+	assert.True(t, ok)
+
+	// 影子引擎跳过自定义动作，只执行回复
+	reply := shadow.Respond("6101", "我要退款")
+	assert.Equal(t, "已为您提交退款", reply.Text)
+	assert.Equal(t, 0, refunds)
+	if assert.Len(t, reply.Trace.Actions, 2) {
+		assert.True(t, reply.Trace.Actions[0].Skipped)
+	}
+
+	live.Respond("6101", "我要退款")
+	assert.Equal(t, 1, refunds)
+}
@@ -0,0 +1,199 @@
+package chatbot
+
+import (
+	"fmt"
+	"sort"
+
+	"gochat/internal/service/event"
+	"gochat/internal/service/i18n"
+)
+
+// 回复来源
+const (
+	TraceSourceRules      = "rules"      // 规则状态机
+	TraceSourceFAQ        = "faq"        // 常见问题检索
+	TraceSourceGenerated  = "generated"  // 生成式模型
+	TraceSourceInactivity = "inactivity" // 客户沉默后的跟进
+)
+
+// traceCandidates 每个意图识别阶段记录的候选意图数
+const traceCandidates = 5
+
+// Trace 单轮消息的决策记录，随机器人回复入库，用于排查回复的原因
+type Trace struct {
+	Input         string        `json:"input"`
+	Postback      bool          `json:"postback,omitempty"`   // 按钮回传，不经过意图识别
+	Normalized    string        `json:"normalized,omitempty"` // 归一化后的文本
+	Entities      []Entity      `json:"entities,omitempty"`
+	Stages        []StageTrace  `json:"stages,omitempty"` // 依次执行的意图识别阶段
+	Intent        string        `json:"intent"`
+	Classifier    string        `json:"classifier,omitempty"`    // 给出意图的分类器
+	Pattern       string        `json:"pattern,omitempty"`       // 命中的正则或关键词，澄清时为客户的回答
+	Clarification []string      `json:"clarification,omitempty"` // 追问或客户回答的候选意图
+	Source        string        `json:"source"`
+	StateBefore   string        `json:"state_before"`
+	StateAfter    string        `json:"state_after"`
+	Actions       []ActionTrace `json:"actions,omitempty"`
+	SlotChanges   []SlotChange  `json:"slot_changes,omitempty"`
+	Fallback      bool          `json:"fallback,omitempty"`
+	Escalated     bool          `json:"escalated,omitempty"`
+	DryRun        bool          `json:"dry_run,omitempty"`
+
+	slots map[string]string // 处理前的槽位
+}
+
+// StageTrace 意图识别阶段的结果，Accepted 表示最高分达到阈值并被采用
+type StageTrace struct {
+	Classifier string        `json:"classifier"`
+	Threshold  float64       `json:"threshold"`
+	Candidates []IntentScore `json:"candidates"`
+	Accepted   bool          `json:"accepted"`
+}
+
+// ActionTrace 执行的动作；试运行时有副作用的动作被跳过
+type ActionTrace struct {
+	Type    string `json:"type"`
+	Skipped bool   `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// SlotChange 槽位变化，Before 为空表示新增，After 为空表示删除
+type SlotChange struct {
+	Slot   string `json:"slot"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+func newTrace(input string, postback, dryRun bool, ctx *ConversationContext) *Trace {
+	return &Trace{
+		Input:       input,
+		Postback:    postback,
+		Source:      TraceSourceRules,
+		StateBefore: ctx.CurrentState,
+		DryRun:      dryRun,
+		slots:       cloneMap(ctx.Slots),
+	}
+}
+
+// stage 记录意图识别阶段的候选意图
+func (t *Trace) stage(stage classifierStage, scores []IntentScore, accepted bool) {
+	if t == nil {
+		return
+	}
+	if len(scores) > traceCandidates {
+		scores = scores[:traceCandidates]
+	}
+	t.Stages = append(t.Stages, StageTrace{
+		Classifier: stage.classifier.Name(),
+		Threshold:  stage.threshold,
+		Candidates: append([]IntentScore(nil), scores...),
+		Accepted:   accepted,
+	})
+	if accepted {
+		t.Classifier, t.Pattern = stage.classifier.Name(), scores[0].Pattern
+	}
+}
+
+func (t *Trace) action(action ActionTrace) {
+	if t != nil {
+		t.Actions = append(t.Actions, action)
+	}
+}
+
+func (t *Trace) source(source string) {
+	if t != nil {
+		t.Source = source
+	}
+}
+
+// finish 记录处理后的状态和槽位变化，并附加到回复
+func (t *Trace) finish(reply Reply, ctx *ConversationContext) Reply {
+	t.Intent = reply.Intent
+	t.Entities = ctx.Entities
+	t.StateAfter = ctx.CurrentState
+	t.Fallback, t.Escalated = reply.Fallback, reply.Escalated
+	t.SlotChanges = diffSlots(t.slots, ctx.Slots)
+	reply.Trace = t
+	return reply
+}
+
+func diffSlots(before, after map[string]string) []SlotChange {
+	var changes []SlotChange
+	for slot, value := range after {
+		if before[slot] != value {
+			changes = append(changes, SlotChange{Slot: slot, Before: before[slot], After: value})
+		}
+	}
+	for slot, value := range before {
+		if _, ok := after[slot]; !ok {
+			changes = append(changes, SlotChange{Slot: slot, Before: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Slot < changes[j].Slot })
+	return changes
+}
+
+// process 处理一轮消息或按钮回传，回复附带本轮的决策记录
+func (e *ChatBotEngine) process(customerID, input string, postback bool, ctx *ConversationContext) Reply {
+	trace := newTrace(input, postback, e.dryRun, ctx)
+	ctx.trace = trace
+	ctx.Nudges = 0
+	ctx.LastErrorCode = 0 // 错误码只对本轮的升级判断有效
+	defer func() { ctx.trace = nil }()
+
+	var reply Reply
+	if postback {
+		reply = e.handlePostback(input, ctx)
+	} else {
+		reply = e.respond(customerID, input, ctx)
+	}
+	reply = trace.finish(reply, ctx)
+	e.rememberSlots(trace.SlotChanges, ctx)
+	return reply
+}
+
+// ExplainRequest 试运行请求
+type ExplainRequest struct {
+	CustomerID string            // 客户在线时基于其当前上下文，否则使用新会话
+	Message    string            // 用户消息或按钮回传内容
+	Postback   bool              // Message 为按钮回传内容
+	State      string            // 指定当前状态，为空时使用上下文中的状态
+	Slots      map[string]string // 合并到上下文槽位
+	Locale     string            // 指定会话语言
+}
+
+// Explain 试运行：在上下文副本上处理消息，返回带决策记录的回复；
+// 不修改客户上下文、不发布事件、不调用生成式模型，只执行无副作用的内置动作
+func (e *ChatBotEngine) Explain(req ExplainRequest) (Reply, error) {
+	if req.State != "" && e.rules.findState(req.State) == nil {
+		return Reply{}, fmt.Errorf("状态 %s 不存在", req.State)
+	}
+
+	customerID := req.CustomerID
+	if customerID == "" {
+		customerID = "explain"
+	}
+	ctx, ok := e.contexts.snapshot(customerID)
+	if !ok {
+		ctx = e.newContext(customerID)
+	}
+	if req.State != "" {
+		ctx.CurrentState, ctx.StateStack = req.State, nil
+	}
+	for slot, value := range req.Slots {
+		if ctx.Slots == nil {
+			ctx.Slots = make(map[string]string)
+		}
+		ctx.Slots[slot] = value
+	}
+	if locale := i18n.Normalize(req.Locale); locale != "" {
+		ctx.Locale = locale
+	}
+
+	dry := *e
+	dry.dryRun = true
+	dry.bus = event.NewBus()
+	dry.generator = nil
+	dry.contexts = newContextStore()
+	return dry.process(customerID, req.Message, req.Postback, &ctx), nil
+}
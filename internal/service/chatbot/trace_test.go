@@ -0,0 +1,116 @@
+package chatbot_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gochat/internal/service/chatbot"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRespondTrace(t *testing.T) {
+	_, engine := newSharedEngine(t)
+	engine.OpenSession("5001")
+	defer engine.CloseSession("5001")
+
+	reply := engine.Respond("5001", "明天北京天气怎么样？")
+	trace := reply.Trace
+	if assert.NotNil(t, trace) {
+		assert.Equal(t, "明天北京天气怎么样？", trace.Input)
+		assert.Equal(t, "明天北京天气怎么样", trace.Normalized)
+		assert.Equal(t, "weather_query", trace.Intent)
+		assert.Equal(t, "regex", trace.Classifier)
+		assert.Equal(t, ".*(天气|气温|下雨).*", trace.Pattern)
+		assert.Equal(t, chatbot.TraceSourceRules, trace.Source)
+		assert.Equal(t, "welcome", trace.StateBefore)
+		assert.Equal(t, "weather_query", trace.StateAfter)
+		assert.Equal(t, []chatbot.ActionTrace{{Type: chatbot.ActionResponse}}, trace.Actions)
+		assert.Equal(t, []chatbot.SlotChange{
+			{Slot: "city", After: "北京"},
+			{Slot: "date", After: "2024-01-02"},
+		}, trace.SlotChanges)
+		if assert.NotEmpty(t, trace.Stages) {
+			last := trace.Stages[len(trace.Stages)-1]
+			assert.True(t, last.Accepted)
+			assert.Equal(t, "weather_query", last.Candidates[0].Intent)
+		}
+		assert.False(t, trace.DryRun)
+	}
+
+	// 每轮单独记录，槽位未变化时不记录
+	next := engine.Respond("5001", "帮助")
+	assert.Equal(t, "weather_query", next.Trace.StateBefore)
+	assert.Empty(t, next.Trace.SlotChanges)
+}
+
+const explainRules = `
+intent_detection:
+  regex_patterns:
+    - intent: "refund"
+      patterns: ["退款"]
+dialogue_flow:
+  states:
+    - name: "welcome"
+    - name: "order_detail"
+      transitions:
+        - intent: "refund"
+          next_state: "refund"
+          actions:
+            - type: "set_context"
+              key: "refund_reason"
+              value: "用户申请"
+            - type: "call_api"
+              endpoint: "%s"
+            - type: "response"
+              content: "已提交退款"
+    - name: "refund"
+error_handling:
+  default_fallback: "抱歉，我没有理解。"
+`
+
+func TestExplainDryRun(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer server.Close()
+
+	rules, err := chatbot.ParseChatBotRules([]byte(fmt.Sprintf(explainRules, server.URL)))
+	assert.NoError(t, err)
+	assert.NoError(t, rules.Validate())
+	engine := chatbot.NewChatBotEngineWithRules(nil, rules)
+	engine.SetCustomerLoader(nil)
+	engine.OpenSession("6001")
+	defer engine.CloseSession("6001")
+
+	reply, err := engine.Explain(chatbot.ExplainRequest{
+		CustomerID: "6001",
+		Message:    "我要退款",
+		State:      "order_detail",
+		Slots:      map[string]string{"order_number": "SO20240101001"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "已提交退款", reply.Text)
+	trace := reply.Trace
+	assert.True(t, trace.DryRun)
+	assert.Equal(t, "order_detail", trace.StateBefore)
+	assert.Equal(t, "refund", trace.StateAfter)
+	assert.Equal(t, []chatbot.ActionTrace{
+		{Type: chatbot.ActionSetContext},
+		{Type: chatbot.ActionCallAPI, Skipped: true},
+		{Type: chatbot.ActionResponse},
+	}, trace.Actions)
+	assert.Equal(t, []chatbot.SlotChange{{Slot: "refund_reason", After: "用户申请"}}, trace.SlotChanges)
+
+	// 试运行不调用外部接口，也不修改客户上下文
+	assert.Zero(t, calls)
+	ctx := engine.GetContext("6001")
+	assert.Equal(t, "welcome", ctx.CurrentState)
+	assert.Empty(t, ctx.Slots)
+
+	_, err = engine.Explain(chatbot.ExplainRequest{Message: "我要退款", State: "missing"})
+	assert.Error(t, err)
+}
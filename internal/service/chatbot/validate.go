@@ -0,0 +1,135 @@
+package chatbot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// ParseChatBotRules 解析 YAML 格式的规则内容
+func ParseChatBotRules(content []byte) (ChatBotRules, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+
+	var rules ChatBotRules
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return rules, fmt.Errorf("配置文件加载失败: %w", err)
+	}
+	if err := v.Unmarshal(&rules); err != nil {
+		return rules, fmt.Errorf("配置解析失败: %w", err)
+	}
+	return rules, nil
+}
+
+// Validate 检查规则的一致性：正则可编译、动作类型已注册、状态和模板引用存在、时间与时区配置有效，返回所有问题
+func (r ChatBotRules) Validate() error {
+	var errs []error
+
+	if _, err := NewRegexClassifier(r); err != nil {
+		errs = append(errs, err)
+	}
+
+	if r.findState("welcome") == nil {
+		errs = append(errs, fmt.Errorf("缺少 welcome 状态"))
+	}
+	validateTransition := func(where string, transition Transition) {
+		if transition.Intent == "" {
+			errs = append(errs, fmt.Errorf("%s: 转移缺少 intent", where))
+		}
+		if transition.NextState != "" && r.findState(transition.NextState) == nil {
+			errs = append(errs, fmt.Errorf("%s: 意图 %s 的目标状态 %s 不存在", where, transition.Intent, transition.NextState))
+		}
+		for _, action := range transition.Actions {
+			if err := r.validateAction(action); err != nil {
+				errs = append(errs, fmt.Errorf("%s: 意图 %s: %w", where, transition.Intent, err))
+			}
+		}
+	}
+	for _, transition := range r.DialogueFlow.GlobalTransitions {
+		validateTransition("全局转移", transition)
+	}
+	for _, state := range r.DialogueFlow.States {
+		for _, transition := range state.Transitions {
+			validateTransition("状态 "+state.Name, transition)
+		}
+		for _, action := range state.EntryActions {
+			if err := r.validateAction(action); err != nil {
+				errs = append(errs, fmt.Errorf("状态 %s: 进入动作: %w", state.Name, err))
+			}
+		}
+		if err := state.Inactivity.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("状态 %s: 沉默跟进: %w", state.Name, err))
+		}
+	}
+	if err := r.DialogueFlow.Inactivity.validate(); err != nil {
+		errs = append(errs, fmt.Errorf("默认沉默跟进: %w", err))
+	}
+
+	if _, err := newNormalizers(r.IntentDetection.Normalization); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := newEntityExtractors(r.ContextManagement.EntityExtraction); err != nil {
+		errs = append(errs, err)
+	}
+	for _, slot := range r.ContextManagement.Memory.Slots {
+		if slot.Slot == "" {
+			errs = append(errs, fmt.Errorf("长期记忆配置缺少 slot"))
+		}
+		if slot.TTLHours < 0 {
+			errs = append(errs, fmt.Errorf("长期记忆 %s 的 ttl_hours 不能为负数", slot.Slot))
+		}
+	}
+	if c := r.IntentDetection.Clarification; c.Margin < 0 || c.Margin > 1 || c.MinScore < 0 || c.MinScore > 1 {
+		errs = append(errs, fmt.Errorf("意图澄清的 margin 和 min_score 应在 0~1 之间"))
+	}
+
+	for _, rule := range r.Personalization.TimeBasedRules {
+		if _, err := parseTimeRange(rule.TimeRange); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if tz := r.Personalization.Timezone; tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			errs = append(errs, fmt.Errorf("时区 %s 无效: %w", tz, err))
+		}
+	}
+	for _, rule := range r.ErrorHandling.EscalationRules {
+		if rule.Condition == "" {
+			errs = append(errs, fmt.Errorf("升级规则缺少 condition"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateAction 检查动作类型已注册，引用的富媒体模板和实验分组存在，定时消息动作参数完整
+func (r ChatBotRules) validateAction(action Action) error {
+	if _, ok := actionHandlers[action.Type]; !ok {
+		return fmt.Errorf("动作类型 %s 未注册", action.Type)
+	}
+	if action.Template != "" {
+		if _, ok := r.findRichTemplate(action.Template); !ok {
+			return fmt.Errorf("富媒体模板 %s 不存在", action.Template)
+		}
+	}
+	if action.VariantGroup != "" {
+		if _, ok := r.findVariantGroup(action.VariantGroup); !ok {
+			return fmt.Errorf("实验分组 %s 不存在", action.VariantGroup)
+		}
+	}
+	switch action.Type {
+	case ActionScheduleMessage:
+		_, hasAt := action.Params["at"]
+		_, hasDelay := action.Params["delay"]
+		if action.Content == "" || (!hasAt && !hasDelay) {
+			return fmt.Errorf("schedule_message 需要 content 以及 params.at 或 params.delay")
+		}
+	case ActionCancelSchedule:
+		if action.Key == "" {
+			return fmt.Errorf("cancel_schedule 缺少 key")
+		}
+	}
+	return nil
+}
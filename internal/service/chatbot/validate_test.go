@@ -0,0 +1,27 @@
+package chatbot_test
+
+import (
+	"os"
+	"testing"
+
+	"gochat/internal/service/chatbot"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShippedRulesAreValid(t *testing.T) {
+	for _, path := range []string{
+		"config/chatbot_rules.yml",
+		"../../../config/chatbot_rules.yml",
+		"../../../config/bots/sales.yml",
+		"testdata/rules_i18n.yml",
+	} {
+		content, err := os.ReadFile(path)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		rules, err := chatbot.ParseChatBotRules(content)
+		assert.NoError(t, err, path)
+		assert.NoError(t, rules.Validate(), path)
+	}
+}
@@ -1,5 +1,7 @@
 package service
 
+import "gochat/internal/service/i18n"
+
 // 定义错误码常量
 const (
 	ErrCodeSuccess        = 0
@@ -38,3 +40,31 @@ func GetErrorMessage(code int) string {
 	}
 	return "未知错误"
 }
+
+// 其他语言的错误信息，未配置的语言使用默认的中文信息
+var localizedErrorMessages = map[string]map[int]string{
+	i18n.LocaleEnUS: {
+		ErrCodeSuccess:         "Success",
+		ErrCodeInternalServer:  "Internal server error",
+		ErrCodeInvalidRequest:  "Invalid request",
+		ErrCodeUnauthorized:    "Unauthorized",
+		ErrCodeForbidden:       "Forbidden",
+		ErrCodeNotFound:        "Resource not found",
+		ErrCodeConflict:        "Resource conflict",
+		ErrCodeUserExists:      "User already exists",
+		ErrCodeUserNotFound:    "User not found",
+		ErrCodeInvalidPassword: "Invalid password",
+		ErrCodeInvalidToken:    "Invalid token",
+	},
+}
+
+// GetLocalizedErrorMessage 根据错误码和语言获取错误信息
+func GetLocalizedErrorMessage(code int, locale string) string {
+	if messages, ok := localizedErrorMessages[i18n.Normalize(locale)]; ok {
+		if msg, ok := messages[code]; ok {
+			return msg
+		}
+		return "Unknown error"
+	}
+	return GetErrorMessage(code)
+}
@@ -0,0 +1,57 @@
+package event
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Event 系统内事件
+type Event struct {
+	Type    string
+	Time    time.Time
+	Payload interface{}
+}
+
+// Handler 事件处理函数
+type Handler func(Event)
+
+// Bus 进程内事件总线，订阅者在独立协程中异步处理，避免阻塞发布方
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// Default 默认事件总线
+var Default = NewBus()
+
+// NewBus 创建事件总线
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe 订阅指定类型的事件
+func (b *Bus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// Publish 发布事件
+func (b *Bus) Publish(eventType string, payload interface{}) {
+	b.mu.RLock()
+	handlers := b.handlers[eventType]
+	b.mu.RUnlock()
+
+	e := Event{Type: eventType, Time: time.Now(), Payload: payload}
+	for _, handler := range handlers {
+		go func(handler Handler) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("事件处理异常 %s: %v", eventType, r)
+				}
+			}()
+			handler(e)
+		}(handler)
+	}
+}
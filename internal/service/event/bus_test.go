@@ -0,0 +1,28 @@
+package event_test
+
+import (
+	"testing"
+	"time"
+
+	"gochat/internal/service/event"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBus(t *testing.T) {
+	bus := event.NewBus()
+	received := make(chan event.Event, 2)
+	bus.Subscribe("escalation", func(e event.Event) { received <- e })
+	bus.Subscribe("escalation", func(e event.Event) { panic("订阅者异常不影响其他订阅者") })
+
+	bus.Publish("other", "ignored")
+	bus.Publish("escalation", "customer-1")
+
+	select {
+	case e := <-received:
+		assert.Equal(t, "escalation", e.Type)
+		assert.Equal(t, "customer-1", e.Payload)
+	case <-time.After(time.Second):
+		t.Fatal("未收到事件")
+	}
+}
@@ -0,0 +1,139 @@
+package faq
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"gochat/internal/model"
+	"gochat/internal/service/textproc"
+)
+
+// BM25 参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Result 检索结果
+type Result struct {
+	FAQ   model.FAQ
+	Score float64
+}
+
+type document struct {
+	faq    model.FAQ
+	terms  map[string]int
+	length int
+}
+
+// Index 基于 BM25 的常见问题检索索引，并发安全，可整体重建
+type Index struct {
+	mu        sync.RWMutex
+	docs      []document
+	docFreq   map[string]int
+	avgLength float64
+}
+
+// Default 默认索引，服务启动及管理接口修改后重建
+var Default = NewIndex(nil)
+
+// NewIndex 创建索引
+func NewIndex(faqs []model.FAQ) *Index {
+	idx := &Index{}
+	idx.Rebuild(faqs)
+	return idx
+}
+
+// Rebuild 使用新的问题列表重建索引，未启用的问题不参与检索
+func (idx *Index) Rebuild(faqs []model.FAQ) {
+	docs := make([]document, 0, len(faqs))
+	docFreq := make(map[string]int)
+	var total int
+	for _, faq := range faqs {
+		if !faq.Enabled {
+			continue
+		}
+		// 问题和标签参与检索，答案不参与，避免长答案稀释相关度
+		tokens := textproc.Tokenize(faq.Question + " " + strings.Join(faq.TagList(), " "))
+		terms := make(map[string]int, len(tokens))
+		for _, token := range tokens {
+			terms[token]++
+		}
+		for term := range terms {
+			docFreq[term]++
+		}
+		total += len(tokens)
+		docs = append(docs, document{faq: faq, terms: terms, length: len(tokens)})
+	}
+
+	var avgLength float64
+	if len(docs) > 0 {
+		avgLength = float64(total) / float64(len(docs))
+	}
+
+	idx.mu.Lock()
+	idx.docs, idx.docFreq, idx.avgLength = docs, docFreq, avgLength
+	idx.mu.Unlock()
+}
+
+// Len 返回已索引的问题数量
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// Search 按 BM25 得分从高到低返回最多 limit 条结果，limit <= 0 表示不限
+func (idx *Index) Search(query string, limit int) []Result {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if len(idx.docs) == 0 {
+		return nil
+	}
+	queryTerms := make(map[string]struct{})
+	for _, token := range textproc.Tokenize(query) {
+		queryTerms[token] = struct{}{}
+	}
+
+	n := float64(len(idx.docs))
+	var results []Result
+	for _, doc := range idx.docs {
+		var score float64
+		for term := range queryTerms {
+			tf := float64(doc.terms[term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(idx.docFreq[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := 1 - bm25B + bm25B*float64(doc.length)/idx.avgLength
+			score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+		}
+		if score > 0 {
+			results = append(results, Result{FAQ: doc.faq, Score: score})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// Get 按ID查找已索引的问题
+func (idx *Index) Get(id uint) (model.FAQ, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	for _, doc := range idx.docs {
+		if doc.faq.ID == id {
+			return doc.faq, true
+		}
+	}
+	return model.FAQ{}, false
+}
@@ -0,0 +1,51 @@
+package faq_test
+
+import (
+	"testing"
+
+	"gochat/internal/model"
+	"gochat/internal/service/faq"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var faqs = []model.FAQ{
+	{ID: 1, Question: "如何修改登录密码", Answer: "在“我的-账号安全”中修改密码。", Tags: "账号,密码", Enabled: true},
+	{ID: 2, Question: "忘记密码怎么办", Answer: "在登录页点击“忘记密码”通过手机验证码重置。", Tags: "账号,密码", Enabled: true},
+	{ID: 3, Question: "订单多久发货", Answer: "付款后48小时内发货。", Tags: "订单,物流", Enabled: true},
+	{ID: 4, Question: "How do I request a refund", Answer: "Open the order and tap Refund.", Tags: "refund", Enabled: true},
+	{ID: 5, Question: "已下线的问题", Answer: "不应被检索到", Enabled: false},
+}
+
+func TestSearchRanksRelevantQuestionFirst(t *testing.T) {
+	idx := faq.NewIndex(faqs)
+	assert.Equal(t, 4, idx.Len())
+
+	results := idx.Search("订单什么时候发货", 3)
+	if assert.NotEmpty(t, results) {
+		assert.Equal(t, uint(3), results[0].FAQ.ID)
+	}
+
+	results = idx.Search("refund please", 3)
+	if assert.NotEmpty(t, results) {
+		assert.Equal(t, uint(4), results[0].FAQ.ID)
+	}
+
+	results = idx.Search("修改密码", 3)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, uint(1), results[0].FAQ.ID)
+		assert.Greater(t, results[0].Score, results[1].Score)
+	}
+}
+
+func TestSearchSkipsDisabledAndUnrelated(t *testing.T) {
+	idx := faq.NewIndex(faqs)
+	assert.Empty(t, idx.Search("下线", 0))
+	assert.Empty(t, idx.Search("今天天气", 0))
+
+	_, ok := idx.Get(5)
+	assert.False(t, ok)
+
+	idx.Rebuild(nil)
+	assert.Empty(t, idx.Search("修改密码", 0))
+}
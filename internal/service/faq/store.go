@@ -0,0 +1,17 @@
+package faq
+
+import (
+	"gochat/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// Reload 从数据库加载启用的问题并重建索引
+func (idx *Index) Reload(db *gorm.DB) error {
+	var faqs []model.FAQ
+	if err := db.Where("enabled = ?", true).Order("id").Find(&faqs).Error; err != nil {
+		return err
+	}
+	idx.Rebuild(faqs)
+	return nil
+}
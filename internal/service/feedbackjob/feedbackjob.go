@@ -0,0 +1,132 @@
+package feedbackjob
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gochat/internal/service/queue"
+	"gochat/internal/service/sentiment"
+)
+
+// QueueName 反馈情感分析队列名
+const QueueName = "feedback_sentiment"
+
+// defaultTimeout 单条反馈情感分析的超时时间
+const defaultTimeout = 10 * time.Second
+
+// Task 情感分析任务，只携带反馈 ID，处理时读取最新内容
+type Task struct {
+	FeedbackID uint `json:"feedback_id"`
+}
+
+// Default 反馈情感分析队列，未配置时为 nil，反馈保存后不分析，可用 botctl backfill-sentiment 补齐
+var Default queue.Queue
+
+// Publish 投递反馈情感分析任务
+func Publish(ctx context.Context, q queue.Queue, feedbackID uint) error {
+	payload, err := json.Marshal(Task{FeedbackID: feedbackID})
+	if err != nil {
+		return err
+	}
+	return q.Publish(ctx, payload)
+}
+
+// Enqueue 投递到默认队列，失败时只记录日志，不影响反馈保存
+func Enqueue(ctx context.Context, feedbackID uint) {
+	if Default == nil {
+		return
+	}
+	if err := Publish(ctx, Default, feedbackID); err != nil {
+		log.Printf("反馈 %d 情感分析任务投递失败: %v", feedbackID, err)
+	}
+}
+
+// Worker 从队列读取任务，分析反馈内容并写回 feedback.sentiment
+type Worker struct {
+	store    FeedbackStore
+	analyzer sentiment.Analyzer
+	timeout  time.Duration
+	now      func() time.Time
+}
+
+// NewWorker 创建情感分析任务处理器
+func NewWorker(store FeedbackStore, analyzer sentiment.Analyzer) *Worker {
+	return &Worker{store: store, analyzer: analyzer, timeout: defaultTimeout, now: time.Now}
+}
+
+// Run 处理队列中的任务直到 ctx 取消
+func (w *Worker) Run(ctx context.Context, q queue.Queue) {
+	if err := q.Consume(ctx, w.Handle); err != nil {
+		log.Printf("反馈情感分析队列停止: %v", err)
+	}
+}
+
+// Handle 处理一个队列任务，任务内容无效时不重试
+func (w *Worker) Handle(ctx context.Context, job queue.Job) error {
+	var task Task
+	if err := json.Unmarshal(job.Payload, &task); err != nil || task.FeedbackID == 0 {
+		return queue.Permanent(fmt.Errorf("情感分析任务无效: %s", job.Payload))
+	}
+	return w.Process(ctx, task.FeedbackID)
+}
+
+// Process 分析一条反馈并保存结果，反馈已删除时跳过
+func (w *Worker) Process(ctx context.Context, feedbackID uint) error {
+	comment, err := w.store.Comment(feedbackID)
+	if errors.Is(err, ErrNotFound) {
+		log.Printf("反馈 %d 不存在，跳过情感分析", feedbackID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	result, err := w.analyzer.Analyze(ctx, comment)
+	if err != nil {
+		return fmt.Errorf("反馈 %d 情感分析失败: %w", feedbackID, err)
+	}
+	err = w.store.SetSentiment(feedbackID, result, w.now())
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Backfill 分批遍历尚未分析的反馈并逐条调用 fn，limit 大于 0 时最多处理 limit 条，返回处理的条数
+func Backfill(ctx context.Context, store FeedbackStore, batch, limit int, fn func(feedbackID uint) error) (int, error) {
+	if batch <= 0 {
+		batch = 100
+	}
+	var afterID uint
+	count := 0
+	for limit <= 0 || count < limit {
+		size := batch
+		if limit > 0 && limit-count < size {
+			size = limit - count
+		}
+		ids, err := store.Pending(afterID, size)
+		if err != nil {
+			return count, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		for _, id := range ids {
+			if err := ctx.Err(); err != nil {
+				return count, err
+			}
+			if err := fn(id); err != nil {
+				return count, err
+			}
+			count++
+		}
+		afterID = ids[len(ids)-1]
+	}
+	return count, nil
+}
@@ -0,0 +1,167 @@
+package feedbackjob_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"gochat/internal/service/feedbackjob"
+	"gochat/internal/service/queue"
+	"gochat/internal/service/sentiment"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeStore 进程内的反馈表
+type fakeStore struct {
+	mu        sync.Mutex
+	comments  map[uint]string
+	sentiment map[uint]int
+}
+
+func newFakeStore(comments map[uint]string) *fakeStore {
+	return &fakeStore{comments: comments, sentiment: map[uint]int{}}
+}
+
+func (s *fakeStore) Comment(id uint) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	comment, ok := s.comments[id]
+	if !ok {
+		return "", feedbackjob.ErrNotFound
+	}
+	return comment, nil
+}
+
+func (s *fakeStore) SetSentiment(id uint, sentiment int, _ time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sentiment[id] = sentiment
+	return nil
+}
+
+func (s *fakeStore) Pending(afterID uint, limit int) ([]uint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []uint
+	for id := range s.comments {
+		if _, ok := s.sentiment[id]; !ok && id > afterID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids, nil
+}
+
+func (s *fakeStore) result(id uint) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sentiment, ok := s.sentiment[id]
+	return sentiment, ok
+}
+
+func TestWorker(t *testing.T) {
+	store := newFakeStore(map[uint]string{1: "客服很耐心", 2: "太慢了", 3: "下次再说"})
+	analyzer := sentiment.NewMock(sentiment.Neutral)
+	analyzer.Set("客服很耐心", sentiment.Positive)
+	analyzer.Set("太慢了", sentiment.Negative)
+	q := queue.NewMemoryQueue(0, queue.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go feedbackjob.NewWorker(store, analyzer).Run(ctx, q)
+
+	for _, id := range []uint{1, 2, 3, 404} {
+		assert.NoError(t, feedbackjob.Publish(ctx, q, id))
+	}
+	assert.NoError(t, q.Publish(ctx, []byte("not json")))
+
+	assert.Eventually(t, func() bool {
+		_, ok := store.result(3)
+		return ok
+	}, time.Second, 5*time.Millisecond)
+	got, _ := store.result(1)
+	assert.Equal(t, sentiment.Positive, got)
+	got, _ = store.result(2)
+	assert.Equal(t, sentiment.Negative, got)
+
+	// 不存在的反馈跳过，无效任务直接进入死信队列
+	assert.Eventually(t, func() bool {
+		dead, _ := q.DeadLetters(ctx, 0)
+		return len(dead) == 1
+	}, time.Second, 5*time.Millisecond)
+	dead, _ := q.DeadLetters(ctx, 0)
+	assert.Equal(t, "not json", string(dead[0].Payload))
+	assert.Equal(t, 1, dead[0].Attempts)
+}
+
+func TestWorkerRetry(t *testing.T) {
+	store := newFakeStore(map[uint]string{1: "服务很好"})
+	analyzer := sentiment.NewMock(sentiment.Positive)
+	analyzer.Fail(errors.New("服务不可用"))
+	q := queue.NewMemoryQueue(0, queue.RetryPolicy{MaxAttempts: 5, Backoff: 20 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go feedbackjob.NewWorker(store, analyzer).Run(ctx, q)
+	assert.NoError(t, feedbackjob.Publish(ctx, q, 1))
+
+	// 提供方恢复后重试成功
+	assert.Eventually(t, func() bool { return len(analyzer.Calls()) >= 2 }, time.Second, 5*time.Millisecond)
+	analyzer.Fail(nil)
+	assert.Eventually(t, func() bool {
+		got, ok := store.result(1)
+		return ok && got == sentiment.Positive
+	}, time.Second, 5*time.Millisecond)
+	dead, _ := q.DeadLetters(ctx, 0)
+	assert.Empty(t, dead)
+
+	// 一直失败时进入死信队列
+	analyzer.Fail(errors.New("服务不可用"))
+	store.mu.Lock()
+	store.comments[2] = "还行"
+	store.mu.Unlock()
+	q2 := queue.NewMemoryQueue(0, queue.RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond})
+	go feedbackjob.NewWorker(store, analyzer).Run(ctx, q2)
+	assert.NoError(t, feedbackjob.Publish(ctx, q2, 2))
+	assert.Eventually(t, func() bool {
+		dead, _ := q2.DeadLetters(ctx, 0)
+		return len(dead) == 1
+	}, time.Second, 5*time.Millisecond)
+	dead, _ = q2.DeadLetters(ctx, 0)
+	assert.Contains(t, dead[0].Error, "服务不可用")
+}
+
+func TestBackfill(t *testing.T) {
+	store := newFakeStore(map[uint]string{1: "a", 2: "b", 3: "c", 5: "d", 8: "e"})
+	store.sentiment[2] = sentiment.Positive
+
+	var seen []uint
+	count, err := feedbackjob.Backfill(context.Background(), store, 2, 3, func(id uint) error {
+		seen = append(seen, id)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, []uint{1, 3, 5}, seen)
+
+	// 逐条分析后不再返回已分析的反馈
+	worker := feedbackjob.NewWorker(store, sentiment.NewMock(sentiment.Negative))
+	count, err = feedbackjob.Backfill(context.Background(), store, 2, 0, func(id uint) error {
+		return worker.Process(context.Background(), id)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, count)
+	ids, _ := store.Pending(0, 10)
+	assert.Empty(t, ids)
+
+	count, err = feedbackjob.Backfill(context.Background(), store, 2, 0, func(uint) error { return errors.New("x") })
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,66 @@
+package feedbackjob
+
+import (
+	"errors"
+	"time"
+
+	"gochat/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound 反馈不存在
+var ErrNotFound = errors.New("反馈不存在")
+
+// FeedbackStore 情感分析任务读写的反馈数据
+type FeedbackStore interface {
+	// Comment 返回反馈内容
+	Comment(id uint) (string, error)
+	// SetSentiment 保存情感分析结果并记录分析时间
+	SetSentiment(id uint, sentiment int, analyzedAt time.Time) error
+	// Pending 按 ID 升序返回 afterID 之后尚未分析的反馈
+	Pending(afterID uint, limit int) ([]uint, error)
+}
+
+// Store 基于数据库的反馈存储
+type Store struct {
+	db *gorm.DB
+}
+
+// NewStore 创建反馈存储
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// Comment 返回反馈内容
+func (s *Store) Comment(id uint) (string, error) {
+	var feedback model.Feedback
+	err := s.db.Select("id", "comment").First(&feedback, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", ErrNotFound
+	}
+	return feedback.Comment, err
+}
+
+// SetSentiment 保存情感分析结果并记录分析时间
+func (s *Store) SetSentiment(id uint, sentiment int, analyzedAt time.Time) error {
+	result := s.db.Model(&model.Feedback{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"sentiment": sentiment, "analyzed_at": analyzedAt})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Pending 按 ID 升序返回 afterID 之后尚未分析的反馈
+func (s *Store) Pending(afterID uint, limit int) ([]uint, error) {
+	var ids []uint
+	err := s.db.Model(&model.Feedback{}).
+		Where("id > ? AND analyzed_at IS NULL", afterID).
+		Order("id").Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
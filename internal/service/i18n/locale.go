@@ -0,0 +1,128 @@
+package i18n
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	LocaleZhCN = "zh-CN"
+	LocaleEnUS = "en-US"
+
+	// DefaultLocale 默认语言
+	DefaultLocale = LocaleZhCN
+)
+
+// SupportedLocales 支持的语言列表
+var SupportedLocales = []string{LocaleZhCN, LocaleEnUS}
+
+// Normalize 将语言标签规范化为支持的语言，如 "zh"、"zh_cn" → "zh-CN"，不支持时返回空字符串
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(strings.ReplaceAll(tag, "_", "-")))
+	if tag == "" {
+		return ""
+	}
+	for _, locale := range SupportedLocales {
+		if strings.ToLower(locale) == tag {
+			return locale
+		}
+	}
+	// 仅按主语言匹配
+	primary := strings.SplitN(tag, "-", 2)[0]
+	for _, locale := range SupportedLocales {
+		if strings.HasPrefix(strings.ToLower(locale), primary+"-") {
+			return locale
+		}
+	}
+	return ""
+}
+
+// Resolve 返回候选中第一个受支持的语言，均不支持时返回空字符串
+func Resolve(candidates ...string) string {
+	for _, candidate := range candidates {
+		if locale := Normalize(candidate); locale != "" {
+			return locale
+		}
+	}
+	return ""
+}
+
+// FromAcceptLanguage 按权重解析 Accept-Language 请求头，返回权重最高的受支持语言
+func FromAcceptLanguage(header string) string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		item := weighted{tag: fields[0], q: 1}
+		for _, field := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(field), "q="); ok {
+				if q, err := strconv.ParseFloat(v, 64); err == nil {
+					item.q = q
+				}
+			}
+		}
+		tags = append(tags, item)
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, item := range tags {
+		if locale := Normalize(item.tag); locale != "" && item.q > 0 {
+			return locale
+		}
+	}
+	return ""
+}
+
+// FromRequest 从HTTP请求头中解析语言
+func FromRequest(r *http.Request) string {
+	return FromAcceptLanguage(r.Header.Get("Accept-Language"))
+}
+
+// Detect 轻量语言检测：根据汉字与拉丁字母的占比判断，无法判断时返回空字符串
+func Detect(text string) string {
+	var han, latin int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case r < unicode.MaxASCII && unicode.IsLetter(r):
+			latin++
+		}
+	}
+	switch {
+	case han > 0 && han*3 >= latin: // 单个汉字的信息量约等于三个字母
+		return LocaleZhCN
+	case latin > 0:
+		return LocaleEnUS
+	default:
+		return ""
+	}
+}
+
+// Pick 从多语言文本中选择指定语言的版本，未配置时返回默认文本；
+// 优先使用与 locale 完全相同的键，多个键规范化后为同一语言时（如 en、en-GB）按键名排序取第一个
+func Pick(variants map[string]string, locale string, fallback string) string {
+	if len(variants) == 0 || locale == "" {
+		return fallback
+	}
+	if text, ok := variants[locale]; ok {
+		return text
+	}
+	keys := make([]string, 0, len(variants))
+	for key := range variants {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if Normalize(key) == locale {
+			return variants[key]
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,41 @@
+package i18n_test
+
+import (
+	"testing"
+
+	"gochat/internal/service/i18n"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromAcceptLanguage(t *testing.T) {
+	assert.Equal(t, i18n.LocaleEnUS, i18n.FromAcceptLanguage("en-GB,en;q=0.9,zh-CN;q=0.8"))
+	assert.Equal(t, i18n.LocaleZhCN, i18n.FromAcceptLanguage("fr;q=0.9, zh;q=0.5, en;q=0.1"))
+	assert.Equal(t, i18n.LocaleZhCN, i18n.FromAcceptLanguage("en;q=0, zh-TW"))
+	assert.Equal(t, "", i18n.FromAcceptLanguage("fr-FR"))
+	assert.Equal(t, "", i18n.FromAcceptLanguage(""))
+}
+
+func TestDetect(t *testing.T) {
+	assert.Equal(t, i18n.LocaleZhCN, i18n.Detect("明天北京天气怎么样"))
+	assert.Equal(t, i18n.LocaleZhCN, i18n.Detect("hello 你好"))
+	assert.Equal(t, i18n.LocaleEnUS, i18n.Detect("what's the weather like"))
+	assert.Equal(t, "", i18n.Detect("123 !!"))
+}
+
+func TestPick(t *testing.T) {
+	variants := map[string]string{"en-us": "Hello"}
+	assert.Equal(t, "Hello", i18n.Pick(variants, i18n.LocaleEnUS, "您好"))
+	assert.Equal(t, "您好", i18n.Pick(variants, i18n.LocaleZhCN, "您好"))
+	assert.Equal(t, "您好", i18n.Pick(nil, i18n.LocaleEnUS, "您好"))
+
+	// 多个键对应同一语言时结果固定：完全匹配优先，其次按键名排序
+	variants = map[string]string{"en": "Hi", "en-GB": "Hello there", "en-US": "Hello", "en_us": "Hey"}
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, "Hello", i18n.Pick(variants, i18n.LocaleEnUS, "您好"))
+	}
+	delete(variants, "en-US")
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, "Hi", i18n.Pick(variants, i18n.LocaleEnUS, "您好"))
+	}
+}
@@ -0,0 +1,63 @@
+package llm
+
+import (
+	"context"
+	"unicode"
+)
+
+// 对话角色
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message 对话上下文中的一条消息
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Request 生成请求
+type Request struct {
+	SystemPrompt string
+	History      []Message // 按时间顺序排列的历史消息，不含本轮输入
+	Prompt       string    // 本轮用户输入
+	MaxTokens    int       // 回复的最大 token 数，0 表示由服务端决定
+}
+
+// ResponseGenerator 生成式回复提供方，意图识别失败时用于生成回复
+type ResponseGenerator interface {
+	Generate(ctx context.Context, req Request) (string, error)
+}
+
+// Default 默认生成器，未配置时为 nil，表示不启用生成式回复
+var Default ResponseGenerator
+
+// EstimateTokens 粗略估算文本的 token 数：中日韩字符按1个计，其他字符按4个计1个
+func EstimateTokens(text string) int {
+	var cjk, other int
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
+
+// TrimHistory 从最早的消息开始丢弃，使历史消息的估算 token 数不超过 budget；budget <= 0 表示不限制
+func TrimHistory(history []Message, budget int) []Message {
+	if budget <= 0 {
+		return history
+	}
+	total := 0
+	for i := len(history) - 1; i >= 0; i-- {
+		total += EstimateTokens(history[i].Content)
+		if total > budget {
+			return history[i+1:]
+		}
+	}
+	return history
+}
@@ -0,0 +1,101 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// OpenAIConfig OpenAI 兼容接口配置，本地模型服务或模拟服务同样适用
+type OpenAIConfig struct {
+	BaseURL     string  `mapstructure:"base_url"` // 如 https://api.openai.com/v1
+	APIKey      string  `mapstructure:"api_key"`
+	Model       string  `mapstructure:"model"`
+	Temperature float64 `mapstructure:"temperature"`
+}
+
+// OpenAIGenerator 调用 /chat/completions 接口生成回复
+type OpenAIGenerator struct {
+	config OpenAIConfig
+	client *http.Client
+}
+
+// NewOpenAIGenerator 创建 OpenAI 兼容的生成器，超时由调用方通过 context 控制
+func NewOpenAIGenerator(config OpenAIConfig) *OpenAIGenerator {
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	return &OpenAIGenerator{config: config, client: &http.Client{}}
+}
+
+type chatCompletionRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Temperature float64   `json:"temperature,omitempty"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Generate 发送系统提示、历史消息和本轮输入，返回第一条候选回复
+func (g *OpenAIGenerator) Generate(ctx context.Context, req Request) (string, error) {
+	messages := make([]Message, 0, len(req.History)+2)
+	if req.SystemPrompt != "" {
+		messages = append(messages, Message{Role: RoleSystem, Content: req.SystemPrompt})
+	}
+	messages = append(messages, req.History...)
+	messages = append(messages, Message{Role: RoleUser, Content: req.Prompt})
+
+	body, err := json.Marshal(chatCompletionRequest{
+		Model:       g.config.Model,
+		Messages:    messages,
+		MaxTokens:   req.MaxTokens,
+		Temperature: g.config.Temperature,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, g.config.BaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if g.config.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+g.config.APIKey)
+	}
+
+	resp, err := g.client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("生成请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("读取生成结果失败: %w", err)
+	}
+	var result chatCompletionResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("生成结果解析失败 (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if result.Error != nil {
+			return "", fmt.Errorf("生成服务返回错误 (HTTP %d): %s", resp.StatusCode, result.Error.Message)
+		}
+		return "", fmt.Errorf("生成服务返回错误 (HTTP %d)", resp.StatusCode)
+	}
+	if len(result.Choices) == 0 || strings.TrimSpace(result.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("生成结果为空")
+	}
+	return strings.TrimSpace(result.Choices[0].Message.Content), nil
+}
@@ -0,0 +1,81 @@
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gochat/internal/service/llm"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAIGenerator(t *testing.T) {
+	var received struct {
+		Model     string        `json:"model"`
+		Messages  []llm.Message `json:"messages"`
+		MaxTokens int           `json:"max_tokens"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":" 您好，有什么可以帮您？ "}}]}`))
+	}))
+	defer server.Close()
+
+	generator := llm.NewOpenAIGenerator(llm.OpenAIConfig{BaseURL: server.URL + "/v1/", APIKey: "test-key", Model: "mock"})
+	reply, err := generator.Generate(context.Background(), llm.Request{
+		SystemPrompt: "你是客服助手",
+		History:      []llm.Message{{Role: llm.RoleUser, Content: "你好"}, {Role: llm.RoleAssistant, Content: "您好"}},
+		Prompt:       "怎么开发票",
+		MaxTokens:    128,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "您好，有什么可以帮您？", reply)
+
+	assert.Equal(t, "mock", received.Model)
+	assert.Equal(t, 128, received.MaxTokens)
+	if assert.Len(t, received.Messages, 4) {
+		assert.Equal(t, llm.RoleSystem, received.Messages[0].Role)
+		assert.Equal(t, "怎么开发票", received.Messages[3].Content)
+	}
+}
+
+func TestOpenAIGeneratorErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"message":"rate limited"}}`))
+	}))
+	defer server.Close()
+
+	generator := llm.NewOpenAIGenerator(llm.OpenAIConfig{BaseURL: server.URL})
+	_, err := generator.Generate(context.Background(), llm.Request{Prompt: "你好"})
+	assert.ErrorContains(t, err, "rate limited")
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = llm.NewOpenAIGenerator(llm.OpenAIConfig{BaseURL: slow.URL}).Generate(ctx, llm.Request{Prompt: "你好"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestTrimHistory(t *testing.T) {
+	history := []llm.Message{
+		{Role: llm.RoleUser, Content: "第一条消息"},
+		{Role: llm.RoleAssistant, Content: "第二条"},
+		{Role: llm.RoleUser, Content: "第三条"},
+	}
+	assert.Equal(t, 5, llm.EstimateTokens("第一条消息"))
+	assert.Equal(t, 2, llm.EstimateTokens("hello"))
+	assert.Equal(t, history[1:], llm.TrimHistory(history, 7))
+	assert.Equal(t, history, llm.TrimHistory(history, 0))
+	assert.Empty(t, llm.TrimHistory(history, 2))
+}
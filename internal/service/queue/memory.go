@@ -0,0 +1,86 @@
+package queue
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MemoryQueue 进程内队列，失败的任务在退避时间后重新入队
+type MemoryQueue struct {
+	jobs  chan Job
+	retry RetryPolicy
+	seq   atomic.Int64
+
+	mu   sync.Mutex
+	dead []Job
+}
+
+// NewMemoryQueue 创建进程内队列，size 为缓冲大小，默认 1024
+func NewMemoryQueue(size int, retry RetryPolicy) *MemoryQueue {
+	if size <= 0 {
+		size = 1024
+	}
+	return &MemoryQueue{jobs: make(chan Job, size), retry: retry.withDefaults()}
+}
+
+// Publish 投递任务，缓冲已满时等待
+func (q *MemoryQueue) Publish(ctx context.Context, payload []byte) error {
+	job := Job{ID: strconv.FormatInt(q.seq.Add(1), 10), Payload: payload}
+	select {
+	case q.jobs <- job:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Consume 处理任务直到 ctx 取消
+func (q *MemoryQueue) Consume(ctx context.Context, handler Handler) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case job := <-q.jobs:
+			q.handle(ctx, handler, job)
+		}
+	}
+}
+
+func (q *MemoryQueue) handle(ctx context.Context, handler Handler, job Job) {
+	err := handler(ctx, job)
+	if err == nil {
+		return
+	}
+	job.Attempts++
+	job.Error, job.FailedAt = err.Error(), time.Now()
+	if isPermanent(err) || job.Attempts >= q.retry.MaxAttempts {
+		log.Printf("任务 %s 执行 %d 次失败，进入死信队列: %v", job.ID, job.Attempts, err)
+		q.mu.Lock()
+		q.dead = append(q.dead, job)
+		q.mu.Unlock()
+		return
+	}
+	// 缓冲已满时等待消费，消费者已停止（ctx 取消）时放弃重试，避免协程一直阻塞
+	time.AfterFunc(q.retry.Delay(job.Attempts), func() {
+		select {
+		case q.jobs <- job:
+		case <-ctx.Done():
+			log.Printf("队列已停止消费，放弃重试任务 %s", job.ID)
+		}
+	})
+}
+
+// DeadLetters 按进入时间倒序返回死信任务
+func (q *MemoryQueue) DeadLetters(_ context.Context, limit int) ([]Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var jobs []Job
+	for i := len(q.dead) - 1; i >= 0 && (limit <= 0 || len(jobs) < limit); i-- {
+		jobs = append(jobs, q.dead[i])
+	}
+	return jobs, nil
+}
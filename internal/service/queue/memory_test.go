@@ -0,0 +1,126 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gochat/internal/service/queue"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := queue.RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	assert.Equal(t, time.Second, policy.Delay(1))
+	assert.Equal(t, 2*time.Second, policy.Delay(2))
+	assert.Equal(t, 4*time.Second, policy.Delay(3))
+	assert.Equal(t, 5*time.Second, policy.Delay(4))
+	assert.Equal(t, 5*time.Second, policy.Delay(10))
+}
+
+func TestMemoryQueue(t *testing.T) {
+	q := queue.NewMemoryQueue(0, queue.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var flaky, broken, invalid atomic.Int32
+	done := make(chan struct{})
+	go q.Consume(ctx, func(_ context.Context, job queue.Job) error {
+		switch string(job.Payload) {
+		case "flaky":
+			// 前两次失败，第三次成功
+			if flaky.Add(1) < 3 {
+				return errors.New("暂时不可用")
+			}
+			close(done)
+		case "broken":
+			broken.Add(1)
+			return errors.New("一直失败")
+		case "invalid":
+			invalid.Add(1)
+			return queue.Permanent(errors.New("任务无效"))
+		}
+		return nil
+	})
+
+	assert.NoError(t, q.Publish(ctx, []byte("invalid")))
+	assert.NoError(t, q.Publish(ctx, []byte("broken")))
+	assert.NoError(t, q.Publish(ctx, []byte("flaky")))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("重试的任务未完成")
+	}
+	assert.Eventually(t, func() bool {
+		dead, _ := q.DeadLetters(ctx, 0)
+		return len(dead) == 2
+	}, time.Second, 5*time.Millisecond)
+
+	dead, err := q.DeadLetters(ctx, 0)
+	assert.NoError(t, err)
+	// 最近进入死信队列的在前
+	assert.Equal(t, "broken", string(dead[0].Payload))
+	assert.Equal(t, 3, dead[0].Attempts)
+	assert.Equal(t, "一直失败", dead[0].Error)
+	assert.Equal(t, "invalid", string(dead[1].Payload))
+	assert.Equal(t, 1, dead[1].Attempts)
+	assert.Equal(t, int32(3), broken.Load())
+	assert.Equal(t, int32(1), invalid.Load())
+	assert.Equal(t, int32(3), flaky.Load())
+
+	dead, _ = q.DeadLetters(ctx, 1)
+	assert.Len(t, dead, 1)
+}
+
+// TestMemoryQueueRetryAfterStop 消费者停止后到期的重试不再阻塞等待入队
+func TestMemoryQueueRetryAfterStop(t *testing.T) {
+	q := queue.NewMemoryQueue(1, queue.RetryPolicy{MaxAttempts: 3, Backoff: 20 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	failed := make(chan struct{}, 1)
+	stopped := make(chan struct{})
+	go func() {
+		q.Consume(ctx, func(context.Context, queue.Job) error {
+			failed <- struct{}{}
+			return errors.New("暂时不可用")
+		})
+		close(stopped)
+	}()
+
+	assert.NoError(t, q.Publish(context.Background(), []byte("flaky")))
+	<-failed
+	cancel()
+	<-stopped
+
+	// 缓冲已满，重试到期时消费者已停止，任务被放弃
+	assert.NoError(t, q.Publish(context.Background(), []byte("next")))
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	received := make(chan string, 2)
+	go q.Consume(ctx, func(_ context.Context, job queue.Job) error {
+		received <- string(job.Payload)
+		return nil
+	})
+	assert.Equal(t, "next", <-received)
+	select {
+	case payload := <-received:
+		t.Fatalf("消费者停止后仍重新投递了任务 %s", payload)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestNew(t *testing.T) {
+	q, err := queue.New(queue.Config{}, "test", nil)
+	assert.NoError(t, err)
+	assert.IsType(t, &queue.MemoryQueue{}, q)
+
+	_, err = queue.New(queue.Config{Driver: queue.DriverRedis}, "test", nil)
+	assert.Error(t, err)
+	_, err = queue.New(queue.Config{Driver: "kafka"}, "test", nil)
+	assert.Error(t, err)
+}
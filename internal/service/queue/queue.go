@@ -0,0 +1,134 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 队列实现
+const (
+	DriverMemory = "memory" // 进程内队列，重启后未处理的任务丢失，适合单节点和测试
+	DriverRedis  = "redis"  // Redis Streams，多节点共享，消费者组保证每个任务只由一个节点处理
+)
+
+// Job 队列中的任务
+type Job struct {
+	ID       string    `json:"id"`
+	Payload  []byte    `json:"payload"`
+	Attempts int       `json:"attempts"`        // 已失败的次数
+	Error    string    `json:"error,omitempty"` // 最近一次失败的原因
+	FailedAt time.Time `json:"failed_at,omitempty"`
+}
+
+// Handler 处理任务，返回错误时按退避策略重试，超过次数后进入死信队列
+type Handler func(ctx context.Context, job Job) error
+
+// Queue 任务队列
+type Queue interface {
+	// Publish 投递任务
+	Publish(ctx context.Context, payload []byte) error
+	// Consume 阻塞处理任务直到 ctx 取消，可在多个协程中同时调用
+	Consume(ctx context.Context, handler Handler) error
+	// DeadLetters 返回最近进入死信队列的任务
+	DeadLetters(ctx context.Context, limit int) ([]Job, error)
+}
+
+// RetryPolicy 失败重试策略：第 n 次失败后等待 backoff*2^(n-1)，不超过 max_backoff
+type RetryPolicy struct {
+	MaxAttempts int           // 最多执行次数，默认 5
+	Backoff     time.Duration // 默认 1s
+	MaxBackoff  time.Duration // 默认 1m
+}
+
+// withDefaults 填充未配置的字段
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 5
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = time.Second
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = time.Minute
+	}
+	return p
+}
+
+// Delay 返回第 attempts 次失败后的等待时间
+func (p RetryPolicy) Delay(attempts int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempts && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
+
+// permanentError 不需要重试的错误
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent 标记不可重试的错误，如任务内容无效，任务直接进入死信队列
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var permanent permanentError
+	return errors.As(err, &permanent)
+}
+
+// Config 队列配置，对应 config.yaml 的 queue
+type Config struct {
+	Driver       string `mapstructure:"driver"`  // memory 或 redis，默认 memory
+	Workers      int    `mapstructure:"workers"` // 每个节点的消费协程数，默认 1
+	MaxAttempts  int    `mapstructure:"max_attempts"`
+	BackoffMS    int    `mapstructure:"backoff_ms"`
+	MaxBackoffMS int    `mapstructure:"max_backoff_ms"`
+	Size         int    `mapstructure:"size"` // memory 队列的缓冲大小，默认 1024
+
+	Redis struct {
+		Group       string `mapstructure:"group"`         // 消费者组，默认 gochat
+		Consumer    string `mapstructure:"consumer"`      // 消费者名，为空时使用 主机名-进程号
+		ClaimIdleMS int    `mapstructure:"claim_idle_ms"` // 任务被领取后超过该时间未确认时由其他消费者接管，默认 60000
+	} `mapstructure:"redis"`
+}
+
+// RetryPolicy 返回配置中的重试策略
+func (c Config) RetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: c.MaxAttempts,
+		Backoff:     time.Duration(c.BackoffMS) * time.Millisecond,
+		MaxBackoff:  time.Duration(c.MaxBackoffMS) * time.Millisecond,
+	}.withDefaults()
+}
+
+// New 按 driver 创建名为 name 的队列，redis 队列需要 rdb
+func New(cfg Config, name string, rdb *redis.Client) (Queue, error) {
+	switch cfg.Driver {
+	case "", DriverMemory:
+		return NewMemoryQueue(cfg.Size, cfg.RetryPolicy()), nil
+	case DriverRedis:
+		if rdb == nil {
+			return nil, errors.New("redis 队列需要 Redis 连接")
+		}
+		return NewRedisQueue(rdb, name, RedisOptions{
+			Group:     cfg.Redis.Group,
+			Consumer:  cfg.Redis.Consumer,
+			ClaimIdle: time.Duration(cfg.Redis.ClaimIdleMS) * time.Millisecond,
+			Retry:     cfg.RetryPolicy(),
+		}), nil
+	}
+	return nil, fmt.Errorf("队列类型 %s 不支持", cfg.Driver)
+}
@@ -0,0 +1,219 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisOptions Redis Streams 队列参数
+type RedisOptions struct {
+	Group     string        // 消费者组，默认 gochat
+	Consumer  string        // 消费者名，为空时使用 主机名-进程号
+	ClaimIdle time.Duration // 任务被领取后超过该时间未确认时由其他消费者接管，默认 1m
+	Retry     RetryPolicy
+}
+
+// RedisQueue 基于 Redis Streams 的队列：任务写入 queue:<name>，消费者组内每个任务只投递给一个消费者；
+// 失败的任务写入有序集合 queue:<name>:retry 等待退避时间后重新入队，超过次数后写入 queue:<name>:dead；
+// 消费者崩溃时未确认的任务在 ClaimIdle 后由其他消费者接管
+type RedisQueue struct {
+	rdb      *redis.Client
+	stream   string
+	retryKey string
+	deadKey  string
+	opts     RedisOptions
+}
+
+// NewRedisQueue 创建 Redis Streams 队列
+func NewRedisQueue(rdb *redis.Client, name string, opts RedisOptions) *RedisQueue {
+	if opts.Group == "" {
+		opts.Group = "gochat"
+	}
+	if opts.Consumer == "" {
+		host, _ := os.Hostname()
+		opts.Consumer = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	if opts.ClaimIdle <= 0 {
+		opts.ClaimIdle = time.Minute
+	}
+	opts.Retry = opts.Retry.withDefaults()
+	stream := "queue:" + name
+	return &RedisQueue{rdb: rdb, stream: stream, retryKey: stream + ":retry", deadKey: stream + ":dead", opts: opts}
+}
+
+// Publish 追加任务到流
+func (q *RedisQueue) Publish(ctx context.Context, payload []byte) error {
+	return q.add(ctx, q.stream, Job{Payload: payload})
+}
+
+func (q *RedisQueue) add(ctx context.Context, stream string, job Job) error {
+	return q.rdb.XAdd(ctx, &redis.XAddArgs{Stream: stream, Values: jobValues(job)}).Err()
+}
+
+// jobValues 任务写入流的字段，与 decodeJob 对应
+func jobValues(job Job) []interface{} {
+	values := []interface{}{"payload", job.Payload, "attempts", job.Attempts}
+	if job.Error != "" {
+		values = append(values, "error", job.Error, "failed_at", job.FailedAt.Format(time.RFC3339))
+	}
+	return values
+}
+
+// Consume 以消费者组读取任务直到 ctx 取消；每轮先将到期的重试任务移回流，并接管超时未确认的任务
+func (q *RedisQueue) Consume(ctx context.Context, handler Handler) error {
+	err := q.rdb.XGroupCreateMkStream(ctx, q.stream, q.opts.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	for ctx.Err() == nil {
+		if err := q.promoteRetries(ctx); err != nil {
+			log.Printf("队列 %s 重试任务入队失败: %v", q.stream, err)
+		}
+		claimed, _, err := q.rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   q.stream,
+			Group:    q.opts.Group,
+			Consumer: q.opts.Consumer,
+			MinIdle:  q.opts.ClaimIdle,
+			Start:    "0-0",
+			Count:    10,
+		}).Result()
+		if err != nil && ctx.Err() == nil {
+			log.Printf("队列 %s 接管任务失败: %v", q.stream, err)
+		}
+		q.handle(ctx, handler, claimed)
+
+		streams, err := q.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    q.opts.Group,
+			Consumer: q.opts.Consumer,
+			Streams:  []string{q.stream, ">"},
+			Count:    10,
+			Block:    time.Second,
+		}).Result()
+		if errors.Is(err, redis.Nil) || ctx.Err() != nil {
+			continue
+		}
+		if err != nil {
+			log.Printf("队列 %s 读取失败: %v", q.stream, err)
+			time.Sleep(time.Second)
+			continue
+		}
+		for _, stream := range streams {
+			q.handle(ctx, handler, stream.Messages)
+		}
+	}
+	return nil
+}
+
+func (q *RedisQueue) handle(ctx context.Context, handler Handler, messages []redis.XMessage) {
+	for _, message := range messages {
+		job := decodeJob(message)
+		if err := handler(ctx, job); err != nil {
+			if err := q.fail(ctx, job, err); err != nil {
+				// 未确认的任务在 ClaimIdle 后重新处理
+				log.Printf("队列 %s 记录任务 %s 失败: %v", q.stream, job.ID, err)
+				continue
+			}
+		}
+		if err := q.rdb.XAck(ctx, q.stream, q.opts.Group, message.ID).Err(); err != nil {
+			log.Printf("队列 %s 确认任务 %s 失败: %v", q.stream, job.ID, err)
+			continue
+		}
+		q.rdb.XDel(ctx, q.stream, message.ID)
+	}
+}
+
+// fail 失败的任务写入重试集合或死信队列
+func (q *RedisQueue) fail(ctx context.Context, job Job, err error) error {
+	job.Attempts++
+	job.Error, job.FailedAt = err.Error(), time.Now()
+	if isPermanent(err) || job.Attempts >= q.opts.Retry.MaxAttempts {
+		log.Printf("任务 %s 执行 %d 次失败，进入死信队列: %v", job.ID, job.Attempts, err)
+		return q.add(ctx, q.deadKey, job)
+	}
+	member, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	due := time.Now().Add(q.opts.Retry.Delay(job.Attempts))
+	return q.rdb.ZAdd(ctx, q.retryKey, redis.Z{Score: float64(due.UnixMilli()), Member: member}).Err()
+}
+
+// promoteScript 重试任务仍在集合中时写入流再移除：脚本原子执行，多个节点不会重复入队，
+// XADD 失败时脚本中止，任务留在集合中等待下一轮
+var promoteScript = redis.NewScript(`
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+  return 0
+end
+redis.call('XADD', KEYS[2], '*', unpack(ARGV, 2))
+redis.call('ZREM', KEYS[1], ARGV[1])
+return 1
+`)
+
+// promoteRetries 到期的重试任务移回流
+func (q *RedisQueue) promoteRetries(ctx context.Context) error {
+	members, err := q.rdb.ZRangeByScore(ctx, q.retryKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Count: 100,
+	}).Result()
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		var job Job
+		if err := json.Unmarshal([]byte(member), &job); err != nil {
+			log.Printf("队列 %s 重试任务无效: %v", q.stream, err)
+			if err := q.rdb.ZRem(ctx, q.retryKey, member).Err(); err != nil {
+				return err
+			}
+			continue
+		}
+		args := append([]interface{}{member}, jobValues(job)...)
+		if err := promoteScript.Run(ctx, q.rdb, []string{q.retryKey, q.stream}, args...).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeadLetters 按进入时间倒序返回死信任务
+func (q *RedisQueue) DeadLetters(ctx context.Context, limit int) ([]Job, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	messages, err := q.rdb.XRevRangeN(ctx, q.deadKey, "+", "-", int64(limit)).Result()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]Job, 0, len(messages))
+	for _, message := range messages {
+		jobs = append(jobs, decodeJob(message))
+	}
+	return jobs, nil
+}
+
+func decodeJob(message redis.XMessage) Job {
+	job := Job{ID: message.ID}
+	if payload, ok := message.Values["payload"].(string); ok {
+		job.Payload = []byte(payload)
+	}
+	if attempts, ok := message.Values["attempts"].(string); ok {
+		job.Attempts, _ = strconv.Atoi(attempts)
+	}
+	if errMsg, ok := message.Values["error"].(string); ok {
+		job.Error = errMsg
+	}
+	if failedAt, ok := message.Values["failed_at"].(string); ok {
+		job.FailedAt, _ = time.Parse(time.RFC3339, failedAt)
+	}
+	return job
+}
@@ -0,0 +1,146 @@
+//go:build integration
+
+// Redis 队列集成测试，需要可访问的 Redis（默认 localhost:6379，可通过 REDIS_ADDR 指定）：
+//
+//	REDIS_ADDR=localhost:6379 go test -tags integration ./internal/service/queue
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gochat/internal/service/queue"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestRedis 连接测试 Redis，返回唯一的队列名，测试结束时删除队列的键
+func newTestRedis(t *testing.T) (*redis.Client, string) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		t.Skipf("Redis %s 不可用: %v", addr, err)
+	}
+	name := fmt.Sprintf("test_%s_%d", t.Name(), time.Now().UnixNano())
+	t.Cleanup(func() {
+		stream := "queue:" + name
+		rdb.Del(context.Background(), stream, stream+":retry", stream+":dead")
+		rdb.Close()
+	})
+	return rdb, name
+}
+
+func TestRedisQueue(t *testing.T) {
+	rdb, name := newTestRedis(t)
+	q := queue.NewRedisQueue(rdb, name, queue.RedisOptions{
+		Consumer: "test",
+		Retry:    queue.RetryPolicy{MaxAttempts: 3, Backoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var flaky, broken, invalid atomic.Int32
+	done := make(chan struct{})
+	go q.Consume(ctx, func(_ context.Context, job queue.Job) error {
+		switch string(job.Payload) {
+		case "flaky":
+			if flaky.Add(1) < 3 {
+				return errors.New("暂时不可用")
+			}
+			close(done)
+		case "broken":
+			broken.Add(1)
+			return errors.New("一直失败")
+		case "invalid":
+			invalid.Add(1)
+			return queue.Permanent(errors.New("任务无效"))
+		}
+		return nil
+	})
+
+	assert.NoError(t, q.Publish(ctx, []byte("invalid")))
+	assert.NoError(t, q.Publish(ctx, []byte("broken")))
+	assert.NoError(t, q.Publish(ctx, []byte("flaky")))
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("重试的任务未完成")
+	}
+	assert.Eventually(t, func() bool {
+		dead, _ := q.DeadLetters(ctx, 0)
+		return len(dead) == 2
+	}, 10*time.Second, 20*time.Millisecond)
+
+	dead, err := q.DeadLetters(ctx, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "broken", string(dead[0].Payload))
+	assert.Equal(t, 3, dead[0].Attempts)
+	assert.Equal(t, "一直失败", dead[0].Error)
+	assert.Equal(t, "invalid", string(dead[1].Payload))
+	assert.Equal(t, 1, dead[1].Attempts)
+	assert.Equal(t, int32(3), flaky.Load())
+	assert.Equal(t, int64(0), rdb.ZCard(ctx, "queue:"+name+":retry").Val())
+}
+
+// TestRedisQueueRetryKeptOnAddFailure 重试任务写回流失败时留在重试集合中，恢复后继续处理
+func TestRedisQueueRetryKeptOnAddFailure(t *testing.T) {
+	rdb, name := newTestRedis(t)
+	stream := "queue:" + name
+	q := queue.NewRedisQueue(rdb, name, queue.RedisOptions{
+		Consumer: "test",
+		Retry:    queue.RetryPolicy{MaxAttempts: 3, Backoff: 200 * time.Millisecond},
+	})
+
+	var calls atomic.Int32
+	handled := make(chan queue.Job, 1)
+	handler := func(_ context.Context, job queue.Job) error {
+		if calls.Add(1) == 1 {
+			return errors.New("暂时不可用")
+		}
+		handled <- job
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		q.Consume(ctx, handler)
+		close(stopped)
+	}()
+	assert.NoError(t, q.Publish(ctx, []byte("flaky")))
+	assert.Eventually(t, func() bool {
+		return rdb.ZCard(ctx, stream+":retry").Val() == 1
+	}, 5*time.Second, 5*time.Millisecond)
+
+	// 重试到期前将流替换为字符串，XADD 失败
+	assert.NoError(t, rdb.Del(ctx, stream).Err())
+	assert.NoError(t, rdb.Set(ctx, stream, "broken", 0).Err())
+	time.Sleep(2 * time.Second)
+	cancel()
+	<-stopped
+	assert.Equal(t, int64(1), rdb.ZCard(context.Background(), stream+":retry").Val())
+
+	// 恢复后任务重新入队并处理
+	assert.NoError(t, rdb.Del(context.Background(), stream).Err())
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go q.Consume(ctx, handler)
+	select {
+	case job := <-handled:
+		assert.Equal(t, "flaky", string(job.Payload))
+		assert.Equal(t, 1, job.Attempts)
+	case <-time.After(10 * time.Second):
+		t.Fatal("重试的任务丢失")
+	}
+	assert.Equal(t, int64(0), rdb.ZCard(ctx, stream+":retry").Val())
+}
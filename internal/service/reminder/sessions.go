@@ -0,0 +1,94 @@
+package reminder
+
+import (
+	"sync"
+
+	"gochat/internal/model"
+)
+
+// Sender 将定时消息推送到一个在线连接，返回错误时消息退回离线收件箱
+type Sender func(item model.ScheduledMessage) error
+
+// Sessions 本节点的在线连接，定时消息到期时推送给在线客户
+type Sessions struct {
+	mu        sync.RWMutex
+	senders   map[uint64]map[int]Sender // 客户ID → 连接编号 → 推送函数
+	nextID    int
+	connected chan uint64 // 客户上线通知，调度器据此立即投递收件箱
+}
+
+// NewSessions 创建在线连接表
+func NewSessions() *Sessions {
+	return &Sessions{senders: make(map[uint64]map[int]Sender), connected: make(chan uint64, 64)}
+}
+
+// DefaultSessions 默认在线连接表，WebSocket 连接建立时注册
+var DefaultSessions = NewSessions()
+
+// Register 注册客户的在线连接，返回的函数在连接关闭时调用
+func (s *Sessions) Register(customerID uint64, send Sender) (unregister func()) {
+	s.mu.Lock()
+	id := s.nextID
+	s.nextID++
+	if s.senders[customerID] == nil {
+		s.senders[customerID] = make(map[int]Sender)
+	}
+	s.senders[customerID][id] = send
+	s.mu.Unlock()
+
+	select {
+	case s.connected <- customerID:
+	default: // 通知已满时由下一次轮询投递
+	}
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.senders[customerID], id)
+		if len(s.senders[customerID]) == 0 {
+			delete(s.senders, customerID)
+		}
+	}
+}
+
+// Online 返回本节点在线的客户
+func (s *Sessions) Online() []uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := make([]uint64, 0, len(s.senders))
+	for id := range s.senders {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Send 推送到客户在本节点的所有连接，至少一个连接成功时返回 true；客户不在线时返回 false
+func (s *Sessions) Send(item model.ScheduledMessage) (bool, error) {
+	s.mu.RLock()
+	senders := make([]Sender, 0, len(s.senders[item.CustomerID]))
+	for _, send := range s.senders[item.CustomerID] {
+		senders = append(senders, send)
+	}
+	s.mu.RUnlock()
+
+	var (
+		delivered bool
+		lastErr   error
+	)
+	for _, send := range senders {
+		if err := send(item); err != nil {
+			lastErr = err
+			continue
+		}
+		delivered = true
+	}
+	if delivered {
+		return true, nil
+	}
+	return false, lastErr
+}
+
+// Connected 客户上线通知
+func (s *Sessions) Connected() <-chan uint64 {
+	return s.connected
+}
@@ -0,0 +1,44 @@
+package reminder_test
+
+import (
+	"errors"
+	"testing"
+
+	"gochat/internal/model"
+	"gochat/internal/service/reminder"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessions(t *testing.T) {
+	sessions := reminder.NewSessions()
+	item := model.ScheduledMessage{ID: 1, CustomerID: 42, Message: "提醒"}
+
+	// 客户不在线时不投递，由调度器存入离线收件箱
+	delivered, err := sessions.Send(item)
+	assert.False(t, delivered)
+	assert.NoError(t, err)
+
+	var received []string
+	unregister := sessions.Register(42, func(item model.ScheduledMessage) error {
+		received = append(received, item.Message)
+		return nil
+	})
+	assert.Equal(t, uint64(42), <-sessions.Connected())
+	assert.Equal(t, []uint64{42}, sessions.Online())
+
+	// 任一连接推送成功即视为已投递
+	closed := sessions.Register(42, func(model.ScheduledMessage) error { return errors.New("连接已关闭") })
+	delivered, err = sessions.Send(item)
+	assert.True(t, delivered)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"提醒"}, received)
+
+	unregister()
+	delivered, err = sessions.Send(item)
+	assert.False(t, delivered)
+	assert.Error(t, err)
+
+	closed()
+	assert.Empty(t, sessions.Online())
+}
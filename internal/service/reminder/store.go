@@ -0,0 +1,144 @@
+package reminder
+
+import (
+	"errors"
+	"time"
+
+	"gochat/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotFound 定时消息不存在或不属于该客户
+	ErrNotFound = errors.New("定时消息不存在")
+	// ErrNotCancellable 定时消息已投递或已取消
+	ErrNotCancellable = errors.New("定时消息已投递或已取消，无法取消")
+)
+
+// Scheduler 创建、取消和查询客户的定时消息，schedule_message 动作和客户接口使用
+type Scheduler interface {
+	Schedule(customerID uint64, botID, message string, dueAt time.Time) (model.ScheduledMessage, error)
+	Cancel(customerID uint64, id uint) error
+	List(customerID uint64, statuses ...string) ([]model.ScheduledMessage, error)
+}
+
+// Default 默认调度器，未配置时为 nil，表示不支持定时消息
+var Default Scheduler
+
+// Store 定时消息存储，任务保存在数据库中，服务重启后继续投递
+type Store struct {
+	db *gorm.DB
+}
+
+// NewStore 创建定时消息存储
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// Schedule 保存一条待发送的定时消息
+func (s *Store) Schedule(customerID uint64, botID, message string, dueAt time.Time) (model.ScheduledMessage, error) {
+	item := model.ScheduledMessage{
+		CustomerID: customerID,
+		BotID:      botID,
+		Message:    message,
+		DueAt:      dueAt,
+		Status:     model.ScheduledPending,
+	}
+	err := s.db.Create(&item).Error
+	return item, err
+}
+
+// Cancel 取消客户尚未投递的定时消息，包括离线收件箱中的消息
+func (s *Store) Cancel(customerID uint64, id uint) error {
+	result := s.db.Model(&model.ScheduledMessage{}).
+		Where("id = ? AND customer_id = ? AND status IN ?", id, customerID, []string{model.ScheduledPending, model.ScheduledInbox}).
+		Update("status", model.ScheduledCancelled)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+	var count int64
+	if err := s.db.Model(&model.ScheduledMessage{}).Where("id = ? AND customer_id = ?", id, customerID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return ErrNotCancellable
+}
+
+// List 按计划发送时间列出客户的定时消息，statuses 为空时返回全部
+func (s *Store) List(customerID uint64, statuses ...string) ([]model.ScheduledMessage, error) {
+	query := s.db.Where("customer_id = ?", customerID)
+	if len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	}
+	var items []model.ScheduledMessage
+	err := query.Order("due_at, id").Find(&items).Error
+	return items, err
+}
+
+// Due 返回可领取的到期任务：已到期的待发送任务，以及领取节点崩溃、租约已过期的任务
+func (s *Store) Due(now time.Time, limit int) ([]uint, error) {
+	var ids []uint
+	err := s.db.Model(&model.ScheduledMessage{}).
+		Where("(status = ? AND due_at <= ?) OR (status = ? AND locked_until < ?)",
+			model.ScheduledPending, now, model.ScheduledSending, now).
+		Order("due_at, id").Limit(limit).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// Inbox 返回指定客户离线收件箱中的任务
+func (s *Store) Inbox(customerIDs []uint64, limit int) ([]uint, error) {
+	var ids []uint
+	if len(customerIDs) == 0 {
+		return ids, nil
+	}
+	err := s.db.Model(&model.ScheduledMessage{}).
+		Where("status = ? AND customer_id IN ?", model.ScheduledInbox, customerIDs).
+		Order("due_at, id").Limit(limit).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// Claim 以条件更新领取任务，多个节点同时领取时只有一个节点成功；
+// 领取成功后在 lease 内由该节点投递，节点崩溃时租约过期后由其他节点重新领取
+func (s *Store) Claim(id uint, node string, now time.Time, lease time.Duration) (model.ScheduledMessage, bool, error) {
+	var item model.ScheduledMessage
+	lockedUntil := now.Add(lease)
+	result := s.db.Model(&model.ScheduledMessage{}).
+		Where("id = ?", id).
+		Where("(status = ? AND due_at <= ?) OR status = ? OR (status = ? AND locked_until < ?)",
+			model.ScheduledPending, now, model.ScheduledInbox, model.ScheduledSending, now).
+		Updates(map[string]interface{}{
+			"status":       model.ScheduledSending,
+			"locked_by":    node,
+			"locked_until": lockedUntil,
+			"attempts":     gorm.Expr("attempts + 1"),
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return item, false, result.Error
+	}
+	if err := s.db.First(&item, id).Error; err != nil {
+		return item, false, err
+	}
+	// 领取后租约过期又被其他节点领取时放弃
+	return item, item.LockedBy == node, nil
+}
+
+// Finish 记录投递结果，仅领取该任务的节点可以更新
+func (s *Store) Finish(item model.ScheduledMessage, status string, now time.Time) error {
+	updates := map[string]interface{}{
+		"status":       status,
+		"locked_by":    "",
+		"locked_until": nil,
+	}
+	if status == model.ScheduledDelivered {
+		updates["delivered_at"] = now
+	}
+	return s.db.Model(&model.ScheduledMessage{}).
+		Where("id = ? AND status = ? AND locked_by = ?", item.ID, model.ScheduledSending, item.LockedBy).
+		Updates(updates).Error
+}
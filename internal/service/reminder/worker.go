@@ -0,0 +1,129 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"gochat/internal/model"
+)
+
+// 调度器默认参数
+const (
+	defaultInterval    = 5 * time.Second
+	defaultLease       = time.Minute
+	defaultBatchSize   = 100
+	defaultMaxAttempts = 5
+)
+
+// Worker 定期领取到期的定时消息并投递：客户在本节点在线时推送，否则存入离线收件箱，
+// 客户上线后由其所在节点投递。任务通过数据库条件更新领取，同一任务只由一个节点处理
+type Worker struct {
+	store       *Store
+	sessions    *Sessions
+	node        string
+	interval    time.Duration
+	lease       time.Duration
+	maxAttempts int
+	now         func() time.Time
+}
+
+// NewWorker 创建调度器，node 为空时使用 主机名-进程号
+func NewWorker(store *Store, sessions *Sessions, node string, interval time.Duration) *Worker {
+	if node == "" {
+		host, _ := os.Hostname()
+		node = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	return &Worker{
+		store:       store,
+		sessions:    sessions,
+		node:        node,
+		interval:    interval,
+		lease:       defaultLease,
+		maxAttempts: defaultMaxAttempts,
+		now:         time.Now,
+	}
+}
+
+// Poll 投递到期任务，以及本节点在线客户收件箱中的任务
+func (w *Worker) Poll() error {
+	ids, err := w.store.Due(w.now(), defaultBatchSize)
+	if err != nil {
+		return err
+	}
+	w.deliver(ids)
+	return w.flushInbox(w.sessions.Online()...)
+}
+
+// flushInbox 投递指定客户收件箱中的任务
+func (w *Worker) flushInbox(customerIDs ...uint64) error {
+	ids, err := w.store.Inbox(customerIDs, defaultBatchSize)
+	if err != nil {
+		return err
+	}
+	w.deliver(ids)
+	return nil
+}
+
+func (w *Worker) deliver(ids []uint) {
+	for _, id := range ids {
+		item, ok, err := w.store.Claim(id, w.node, w.now(), w.lease)
+		if err != nil {
+			log.Printf("领取定时消息 %d 失败: %v", id, err)
+			continue
+		}
+		if !ok {
+			continue // 已被其他节点领取或已取消
+		}
+		status := w.send(item)
+		if err := w.store.Finish(item, status, w.now()); err != nil {
+			log.Printf("更新定时消息 %d 状态失败: %v", id, err)
+		}
+	}
+}
+
+// send 推送已领取的任务并返回新状态
+func (w *Worker) send(item model.ScheduledMessage) string {
+	if item.Attempts > w.maxAttempts {
+		log.Printf("定时消息 %d 投递 %d 次未完成，不再重试", item.ID, item.Attempts-1)
+		return model.ScheduledFailed
+	}
+	delivered, err := w.sessions.Send(item)
+	if err != nil {
+		log.Printf("推送定时消息 %d 失败，存入离线收件箱: %v", item.ID, err)
+	}
+	if delivered {
+		return model.ScheduledDelivered
+	}
+	return model.ScheduledInbox
+}
+
+// Run 按间隔轮询，客户上线时立即投递其收件箱，直到 ctx 取消
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	w.poll()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.poll()
+		case customerID := <-w.sessions.Connected():
+			if err := w.flushInbox(customerID); err != nil {
+				log.Printf("投递客户 %d 离线消息失败: %v", customerID, err)
+			}
+		}
+	}
+}
+
+func (w *Worker) poll() {
+	if err := w.Poll(); err != nil {
+		log.Printf("定时消息轮询失败: %v", err)
+	}
+}
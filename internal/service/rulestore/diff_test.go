@@ -0,0 +1,58 @@
+package rulestore_test
+
+import (
+	"testing"
+
+	"gochat/internal/service/rulestore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	a := "metadata:\n  bot_name: \"智能助手\"\n  version: \"2.1.0\"\n"
+	b := "metadata:\n  bot_name: \"智能助手\"\n  version: \"2.2.0\"\n  default_lang: \"zh-CN\"\n"
+
+	assert.Equal(t, "  metadata:\n"+
+		"    bot_name: \"智能助手\"\n"+
+		"-   version: \"2.1.0\"\n"+
+		"+   version: \"2.2.0\"\n"+
+		"+   default_lang: \"zh-CN\"\n", rulestore.Diff(a, b))
+	assert.NotContains(t, rulestore.Diff(a, a), "+")
+}
+
+func TestValidate(t *testing.T) {
+	_, err := rulestore.Validate("dialogue_flow: [")
+	var validationErr *rulestore.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+
+	_, err = rulestore.Validate(`
+intent_detection:
+  regex_patterns:
+    - intent: "greeting"
+      patterns: ["(你好"]
+dialogue_flow:
+  states:
+    - name: "welcome"
+      transitions:
+        - intent: "greeting"
+          next_state: "missing"
+          actions:
+            - type: "rich"
+              template: "main_menu"
+`)
+	assert.ErrorAs(t, err, &validationErr)
+	assert.ErrorContains(t, err, "(你好")
+	assert.ErrorContains(t, err, "missing")
+	assert.ErrorContains(t, err, "main_menu")
+
+	rules, err := rulestore.Validate(`
+dialogue_flow:
+  states:
+    - name: "welcome"
+      transitions:
+        - intent: "greeting"
+          next_state: "welcome"
+`)
+	assert.NoError(t, err)
+	assert.Len(t, rules.DialogueFlow.States, 1)
+}
@@ -0,0 +1,193 @@
+package rulestore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"gochat/internal/model"
+	"gochat/internal/service/chatbot"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrVersionNotFound 版本不存在
+	ErrVersionNotFound = errors.New("规则版本不存在")
+	// ErrNoPreviousVersion 没有可回滚的版本
+	ErrNoPreviousVersion = errors.New("没有可回滚的历史版本")
+)
+
+// ValidationError 规则内容未通过校验
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return "规则校验失败: " + e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Store 规则版本存储
+type Store struct {
+	db *gorm.DB
+}
+
+// NewStore 创建规则版本存储
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// Validate 解析并校验规则内容，失败时返回 *ValidationError
+func Validate(content string) (chatbot.ChatBotRules, error) {
+	rules, err := chatbot.ParseChatBotRules([]byte(content))
+	if err != nil {
+		return rules, &ValidationError{Err: err}
+	}
+	if err := rules.Validate(); err != nil {
+		return rules, &ValidationError{Err: err}
+	}
+	return rules, nil
+}
+
+// Upload 校验并保存新版本，版本号在机器人内递增；新版本需单独激活才会生效
+func (s *Store) Upload(botID, content, author, comment string) (model.RuleVersion, error) {
+	if _, err := Validate(content); err != nil {
+		return model.RuleVersion{}, err
+	}
+
+	sum := sha256.Sum256([]byte(content))
+	version := model.RuleVersion{
+		BotID:    botID,
+		Content:  content,
+		Checksum: hex.EncodeToString(sum[:]),
+		Author:   author,
+		Comment:  comment,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Model(&model.RuleVersion{}).Where("bot_id = ?", botID).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		version.Version = latest + 1
+		return tx.Create(&version).Error
+	})
+	return version, err
+}
+
+// List 返回机器人的所有版本（不含内容），按版本号倒序
+func (s *Store) List(botID string) ([]model.RuleVersion, error) {
+	var versions []model.RuleVersion
+	err := s.db.Omit("content").Where("bot_id = ?", botID).Order("version DESC").Find(&versions).Error
+	return versions, err
+}
+
+// Get 返回指定版本
+func (s *Store) Get(botID string, version int) (model.RuleVersion, error) {
+	var item model.RuleVersion
+	err := s.db.Where("bot_id = ? AND version = ?", botID, version).Take(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return item, ErrVersionNotFound
+	}
+	return item, err
+}
+
+// Active 返回所有机器人当前生效的版本
+func (s *Store) Active() ([]model.RuleVersion, error) {
+	var versions []model.RuleVersion
+	err := s.db.Where("active = ?", true).Find(&versions).Error
+	return versions, err
+}
+
+// Activate 激活指定版本，同一机器人同时只有一个生效版本
+func (s *Store) Activate(botID string, version int) (model.RuleVersion, error) {
+	item, err := s.Get(botID, version)
+	if err != nil {
+		return item, err
+	}
+	// 激活前再次校验，避免引擎升级后旧版本已不再兼容
+	if _, err := Validate(item.Content); err != nil {
+		return item, err
+	}
+
+	now := time.Now().Local()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.RuleVersion{}).Where("bot_id = ? AND active = ?", botID, true).
+			Update("active", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&item).Updates(map[string]interface{}{"active": true, "activated_at": now}).Error
+	})
+	if err != nil {
+		return item, err
+	}
+	item.Active, item.ActivatedAt = true, &now
+	return item, nil
+}
+
+// Shadows 返回所有机器人正在影子评估的版本
+func (s *Store) Shadows() ([]model.RuleVersion, error) {
+	var versions []model.RuleVersion
+	err := s.db.Where("shadow = ?", true).Find(&versions).Error
+	return versions, err
+}
+
+// StartShadow 将指定版本设为机器人的影子评估候选，同一机器人同时只评估一个版本
+func (s *Store) StartShadow(botID string, version int) (model.RuleVersion, error) {
+	item, err := s.Get(botID, version)
+	if err != nil {
+		return item, err
+	}
+	if _, err := Validate(item.Content); err != nil {
+		return item, err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.RuleVersion{}).Where("bot_id = ? AND shadow = ?", botID, true).
+			Update("shadow", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&item).Update("shadow", true).Error
+	})
+	item.Shadow = err == nil
+	return item, err
+}
+
+// StopShadow 停止机器人的影子评估
+func (s *Store) StopShadow(botID string) error {
+	return s.db.Model(&model.RuleVersion{}).Where("bot_id = ? AND shadow = ?", botID, true).
+		Update("shadow", false).Error
+}
+
+// Rollback 重新激活当前版本之前最近一次生效的版本
+func (s *Store) Rollback(botID string) (model.RuleVersion, error) {
+	var previous model.RuleVersion
+	err := s.db.Omit("content").
+		Where("bot_id = ? AND active = ? AND activated_at IS NOT NULL", botID, false).
+		Order("activated_at DESC").Take(&previous).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return previous, ErrNoPreviousVersion
+	}
+	if err != nil {
+		return previous, err
+	}
+	return s.Activate(botID, previous.Version)
+}
+
+// Diff 比较同一机器人的两个版本
+func (s *Store) Diff(botID string, from, to int) (string, error) {
+	a, err := s.Get(botID, from)
+	if err != nil {
+		return "", fmt.Errorf("版本 %d: %w", from, err)
+	}
+	b, err := s.Get(botID, to)
+	if err != nil {
+		return "", fmt.Errorf("版本 %d: %w", to, err)
+	}
+	return Diff(a.Content, b.Content), nil
+}
@@ -0,0 +1,96 @@
+package rulestore
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gochat/internal/service/chatbot"
+)
+
+// Watcher 定期读取数据库中生效的规则版本并应用到机器人注册表，使所有节点在激活后自动切换；
+// 已建立的连接继续使用原规则，新连接使用新版本
+type Watcher struct {
+	store    *Store
+	registry *chatbot.BotRegistry
+	interval time.Duration
+	applied  map[string]string // 机器人ID → 已应用版本的校验和
+	shadows  map[string]string // 机器人ID → 已应用影子候选的校验和
+}
+
+// NewWatcher 创建规则版本监听器
+func NewWatcher(store *Store, registry *chatbot.BotRegistry, interval time.Duration) *Watcher {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	return &Watcher{store: store, registry: registry, interval: interval, applied: make(map[string]string), shadows: make(map[string]string)}
+}
+
+// Sync 应用发生变化的生效版本，校验失败的版本跳过并保留当前规则
+func (w *Watcher) Sync() error {
+	versions, err := w.store.Active()
+	if err != nil {
+		return err
+	}
+	for _, version := range versions {
+		if w.applied[version.BotID] == version.Checksum {
+			continue
+		}
+		rules, err := Validate(version.Content)
+		if err != nil {
+			log.Printf("机器人 %s 规则版本 %d 无效，保留当前规则: %v", version.BotID, version.Version, err)
+			continue
+		}
+		w.registry.Register(version.BotID, rules)
+		w.applied[version.BotID] = version.Checksum
+		log.Printf("机器人 %s 已切换到规则版本 %d", version.BotID, version.Version)
+	}
+	return w.syncShadows()
+}
+
+// syncShadows 同步影子评估候选，已停止评估的机器人清除候选
+func (w *Watcher) syncShadows() error {
+	versions, err := w.store.Shadows()
+	if err != nil {
+		return err
+	}
+	current := make(map[string]bool, len(versions))
+	for _, version := range versions {
+		current[version.BotID] = true
+		if w.shadows[version.BotID] == version.Checksum {
+			continue
+		}
+		rules, err := Validate(version.Content)
+		if err != nil {
+			log.Printf("机器人 %s 影子版本 %d 无效: %v", version.BotID, version.Version, err)
+			continue
+		}
+		w.registry.SetCandidate(version.BotID, version.Version, rules)
+		w.shadows[version.BotID] = version.Checksum
+		log.Printf("机器人 %s 开始影子评估规则版本 %d", version.BotID, version.Version)
+	}
+	for botID := range w.shadows {
+		if !current[botID] {
+			w.registry.ClearCandidate(botID)
+			delete(w.shadows, botID)
+			log.Printf("机器人 %s 停止影子评估", botID)
+		}
+	}
+	return nil
+}
+
+// Run 按间隔同步，直到 ctx 取消
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		if err := w.Sync(); err != nil {
+			log.Printf("规则版本同步失败: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
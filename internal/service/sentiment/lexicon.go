@@ -0,0 +1,209 @@
+package sentiment
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LexiconConfig 本地词典配置，追加的词与内置词典合并
+type LexiconConfig struct {
+	Positive []string `mapstructure:"positive"`
+	Negative []string `mapstructure:"negative"`
+}
+
+var (
+	positiveWords = []string{
+		// 中文
+		"好", "不错", "满意", "喜欢", "感谢", "谢谢", "多谢", "赞", "棒", "优秀", "完美", "方便", "快", "及时", "专业",
+		"耐心", "热情", "贴心", "周到", "靠谱", "给力", "推荐", "开心", "高兴", "舒服", "好用", "实惠", "划算", "值得",
+		"没问题", "解决了", "顺利", "清楚", "有帮助", "超赞", "点赞", "好评", "惊喜", "放心", "省心", "流畅",
+		// 英文
+		"good", "great", "excellent", "awesome", "amazing", "perfect", "love", "like", "nice", "thanks", "thank",
+		"helpful", "happy", "satisfied", "fast", "quick", "easy", "recommend", "friendly", "wonderful", "fantastic",
+		"smooth", "resolved", "best", "appreciate", "pleased",
+		// 表情
+		"👍", "😊", "😀", "😄", "❤", "🎉", "👏",
+	}
+	negativeWords = []string{
+		// 中文
+		"差", "差劲", "糟糕", "失望", "不满", "不满意", "投诉", "生气", "愤怒", "垃圾", "坑", "骗", "骗子", "慢", "烂",
+		"难用", "麻烦", "敷衍", "态度恶劣", "问题", "故障", "错误", "退款", "坏", "破损", "延迟", "拖延", "无语",
+		"后悔", "恶心", "讨厌", "太贵", "贵", "卡顿", "崩溃", "没用", "不行", "差评", "烦", "糟心", "着急",
+		// 英文
+		"bad", "terrible", "awful", "horrible", "poor", "worst", "hate", "angry", "disappointed", "disappointing",
+		"slow", "broken", "useless", "annoying", "problem", "issue", "complaint", "refund", "scam", "rude",
+		"expensive", "wrong", "fail", "failed", "error", "bug", "frustrated", "frustrating",
+		// 表情
+		"👎", "😡", "😠", "😞", "😢", "💔",
+	}
+	negators = []string{
+		"不", "没", "没有", "别", "无", "非", "未", "不是", "并不", "毫不", "从不",
+		"not", "no", "never", "none", "nothing", "neither", "nor", "without",
+	}
+	intensifiers = []string{
+		"很", "非常", "太", "特别", "超", "超级", "十分", "极其", "真", "真的", "好", "挺", "相当", "最",
+		"very", "really", "so", "extremely", "super", "too", "quite", "absolutely", "totally",
+	}
+	contrasts = []string{"但是", "但", "不过", "可是", "然而", "只是", "but", "however", "though", "although"}
+)
+
+// 词条类型
+const (
+	termPositive = iota + 1
+	termNegative
+	termNegator
+	termIntensifier
+	termContrast
+)
+
+// LexiconAnalyzer 基于中英文情感词典的分析：按最长匹配切分，否定词翻转、程度词加权；
+// 转折词之后有情感倾向时以转折后的内容为准，如“物流很快，但是客服态度差”判为负面
+type LexiconAnalyzer struct {
+	terms     map[string]int
+	modifiers map[string]bool // 同时是程度词的情感词，如“好”“超”
+	maxLen    int             // 中文词条的最大字数
+}
+
+// NewLexiconAnalyzer 创建本地词典分析器
+func NewLexiconAnalyzer(cfg LexiconConfig) *LexiconAnalyzer {
+	a := &LexiconAnalyzer{terms: make(map[string]int), modifiers: make(map[string]bool)}
+	add := func(words []string, kind int) {
+		for _, word := range words {
+			word = strings.ToLower(strings.TrimSpace(word))
+			if word == "" {
+				continue
+			}
+			// “好”“超”既是情感词也是程度词，记为情感词，后面紧跟情感词时在 Score 中按程度词处理
+			if a.terms[word] == termIntensifier && (kind == termPositive || kind == termNegative) {
+				a.modifiers[word] = true
+			}
+			a.terms[word] = kind
+			if n := utf8.RuneCountInString(word); n > a.maxLen {
+				a.maxLen = n
+			}
+		}
+	}
+	add(intensifiers, termIntensifier)
+	add(negators, termNegator)
+	add(contrasts, termContrast)
+	add(positiveWords, termPositive)
+	add(negativeWords, termNegative)
+	add(cfg.Positive, termPositive)
+	add(cfg.Negative, termNegative)
+	return a
+}
+
+// Analyze 不访问网络，不会返回错误
+func (a *LexiconAnalyzer) Analyze(_ context.Context, text string) (int, error) {
+	score := a.Score(text)
+	switch {
+	case score > 0:
+		return Positive, nil
+	case score < 0:
+		return Negative, nil
+	}
+	return Neutral, nil
+}
+
+// Score 返回情感得分，正数为正面、负数为负面
+func (a *LexiconAnalyzer) Score(text string) float64 {
+	var (
+		total     float64
+		before    float64 // 转折词之前的得分
+		negated   bool
+		intensity = 1.0
+	)
+	resetClause := func() {
+		negated, intensity = false, 1
+	}
+	tokens := a.tokenize(strings.ToLower(text))
+	for i, token := range tokens {
+		kind, ok := a.terms[token]
+		if !ok {
+			if isClauseBreak(token) {
+				resetClause()
+			}
+			continue
+		}
+		if a.modifiers[token] && i+1 < len(tokens) && a.isSentiment(tokens[i+1]) {
+			kind = termIntensifier // “好差”“好慢”中的“好”
+		}
+		switch kind {
+		case termNegator:
+			negated = !negated
+		case termIntensifier:
+			intensity *= 2
+		case termContrast:
+			if total != 0 {
+				before = total
+			}
+			total = 0
+			resetClause()
+		case termPositive, termNegative:
+			value := intensity
+			if kind == termNegative {
+				value = -value
+			}
+			if negated {
+				value = -value
+			}
+			total += value
+			resetClause()
+		}
+	}
+	if total == 0 {
+		return before
+	}
+	return total
+}
+
+func (a *LexiconAnalyzer) isSentiment(token string) bool {
+	kind := a.terms[token]
+	return kind == termPositive || kind == termNegative
+}
+
+// tokenize 英文按单词切分，其他文字按词典最长匹配切分，未收录的字符单独成词
+func (a *LexiconAnalyzer) tokenize(text string) []string {
+	var tokens []string
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || r == '\''):
+			j := i
+			for j < len(runes) && runes[j] < unicode.MaxASCII && (unicode.IsLetter(runes[j]) || runes[j] == '\'') {
+				j++
+			}
+			tokens = append(tokens, englishToken(string(runes[i:j])))
+			i = j
+		default:
+			n := 1
+			for size := min(a.maxLen, len(runes)-i); size > 1; size-- {
+				if _, ok := a.terms[string(runes[i:i+size])]; ok {
+					n = size
+					break
+				}
+			}
+			tokens = append(tokens, string(runes[i:i+n]))
+			i += n
+		}
+	}
+	return tokens
+}
+
+// englishToken don't、isn't 等缩写视为否定词
+func englishToken(word string) string {
+	word = strings.Trim(word, "'")
+	if strings.HasSuffix(word, "n't") {
+		return "not"
+	}
+	return word
+}
+
+func isClauseBreak(token string) bool {
+	return strings.ContainsAny(token, "，。！？；,.!?;\n")
+}
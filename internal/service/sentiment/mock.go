@@ -0,0 +1,59 @@
+package sentiment
+
+import (
+	"context"
+	"sync"
+)
+
+// MockConfig mock 提供方配置
+type MockConfig struct {
+	Sentiment int `mapstructure:"sentiment"` // 固定返回的情感倾向
+}
+
+// Mock 返回预设结果并记录调用，用于测试
+type Mock struct {
+	mu        sync.Mutex
+	sentiment int
+	results   map[string]int
+	err       error
+	calls     []string
+}
+
+// NewMock 创建默认返回 sentiment 的 Mock
+func NewMock(sentiment int) *Mock {
+	return &Mock{sentiment: sentiment, results: make(map[string]int)}
+}
+
+// Set 指定文本的分析结果
+func (m *Mock) Set(text string, sentiment int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results[text] = sentiment
+}
+
+// Fail 之后的调用返回 err，nil 表示恢复正常
+func (m *Mock) Fail(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.err = err
+}
+
+// Calls 返回已分析的文本
+func (m *Mock) Calls() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.calls...)
+}
+
+func (m *Mock) Analyze(_ context.Context, text string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, text)
+	if m.err != nil {
+		return Neutral, m.err
+	}
+	if sentiment, ok := m.results[text]; ok {
+		return sentiment, nil
+	}
+	return m.sentiment, nil
+}
@@ -0,0 +1,65 @@
+package sentiment
+
+import (
+	"context"
+	"fmt"
+)
+
+// 情感倾向，与 feedback.sentiment 字段取值一致
+const (
+	Negative = -1
+	Neutral  = 0
+	Positive = 1
+)
+
+// 内置情感分析提供方
+const (
+	ProviderLexicon = "lexicon" // 本地中英文情感词典，无需网络
+	ProviderTencent = "tencent" // 腾讯云自然语言处理
+	ProviderMock    = "mock"    // 固定结果，用于测试和本地调试
+)
+
+// Analyzer 情感分析提供方，返回 Negative、Neutral 或 Positive
+type Analyzer interface {
+	Analyze(ctx context.Context, text string) (int, error)
+}
+
+// Config 情感分析配置，对应 config.yaml 的 sentiment
+type Config struct {
+	Provider string        `mapstructure:"provider"` // 为空时使用 lexicon
+	Lexicon  LexiconConfig `mapstructure:"lexicon"`
+	Tencent  TencentConfig `mapstructure:"tencent"`
+	Mock     MockConfig    `mapstructure:"mock"`
+}
+
+// Factory 根据配置创建情感分析提供方
+type Factory func(cfg Config) (Analyzer, error)
+
+var factories = map[string]Factory{}
+
+// Register 注册情感分析提供方，配置中按名称选择；同名注册会覆盖内置提供方
+func Register(provider string, factory Factory) {
+	factories[provider] = factory
+}
+
+func init() {
+	Register(ProviderLexicon, func(cfg Config) (Analyzer, error) { return NewLexiconAnalyzer(cfg.Lexicon), nil })
+	Register(ProviderTencent, func(cfg Config) (Analyzer, error) { return NewTencentAnalyzer(cfg.Tencent) })
+	Register(ProviderMock, func(cfg Config) (Analyzer, error) { return NewMock(cfg.Mock.Sentiment), nil })
+}
+
+// New 按 provider 创建情感分析提供方
+func New(cfg Config) (Analyzer, error) {
+	provider := cfg.Provider
+	if provider == "" {
+		provider = ProviderLexicon
+	}
+	factory, ok := factories[provider]
+	if !ok {
+		return nil, fmt.Errorf("情感分析提供方 %s 未注册", provider)
+	}
+	return factory(cfg)
+}
+
+// Default 默认情感分析提供方，服务启动时按配置替换
+var Default Analyzer = NewLexiconAnalyzer(LexiconConfig{})
@@ -0,0 +1,75 @@
+package sentiment_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gochat/internal/service/sentiment"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLexiconAnalyzer(t *testing.T) {
+	analyzer := sentiment.NewLexiconAnalyzer(sentiment.LexiconConfig{Negative: []string{"缺货"}})
+	cases := map[string]int{
+		"客服很耐心，非常满意":                     sentiment.Positive,
+		"物流太慢了，很失望":                      sentiment.Negative,
+		"不好用":                            sentiment.Negative,
+		"还不错":                            sentiment.Positive,
+		"没问题，谢谢":                         sentiment.Positive,
+		"不太满意":                           sentiment.Negative,
+		"物流很快，但是客服态度差劲":                  sentiment.Negative,
+		"又缺货了":                           sentiment.Negative,
+		"好差":                             sentiment.Negative,
+		"好慢啊":                            sentiment.Negative,
+		"客服好烦":                           sentiment.Negative,
+		"服务好":                            sentiment.Positive,
+		"好满意":                            sentiment.Positive,
+		"我想查一下订单":                        sentiment.Neutral,
+		"The service was great, thanks!": sentiment.Positive,
+		"not bad at all":                 sentiment.Positive,
+		"I don't like the new app":       sentiment.Negative,
+		"Delivery was fast but the box was broken": sentiment.Negative,
+		"no problem": sentiment.Positive,
+		"👍":          sentiment.Positive,
+		"":           sentiment.Neutral,
+	}
+	for text, want := range cases {
+		got, err := analyzer.Analyze(context.Background(), text)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got, text)
+	}
+}
+
+func TestNew(t *testing.T) {
+	analyzer, err := sentiment.New(sentiment.Config{})
+	assert.NoError(t, err)
+	assert.IsType(t, &sentiment.LexiconAnalyzer{}, analyzer)
+
+	analyzer, err = sentiment.New(sentiment.Config{Provider: sentiment.ProviderMock, Mock: sentiment.MockConfig{Sentiment: sentiment.Positive}})
+	assert.NoError(t, err)
+	got, _ := analyzer.Analyze(context.Background(), "随便")
+	assert.Equal(t, sentiment.Positive, got)
+
+	// 缺少密钥时返回错误而不是在调用时出现空指针
+	_, err = sentiment.New(sentiment.Config{Provider: sentiment.ProviderTencent})
+	assert.Error(t, err)
+
+	_, err = sentiment.New(sentiment.Config{Provider: "missing"})
+	assert.Error(t, err)
+}
+
+func TestMock(t *testing.T) {
+	mock := sentiment.NewMock(sentiment.Neutral)
+	mock.Set("太差了", sentiment.Negative)
+
+	got, err := mock.Analyze(context.Background(), "太差了")
+	assert.NoError(t, err)
+	assert.Equal(t, sentiment.Negative, got)
+
+	mock.Fail(errors.New("服务不可用"))
+	_, err = mock.Analyze(context.Background(), "你好")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"太差了", "你好"}, mock.Calls())
+}
@@ -1,57 +1,62 @@
 package sentiment
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
-	"sync"
 
-	"github.com/spf13/viper"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
 	nlp "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/nlp/v20190408"
 )
 
-var (
-	nlpClient    *nlp.Client
-	keywordsOnce sync.Once
-	sentimentMap = map[string]int{
-		"positive": 1,
-		"neutral":  0,
-		"negative": -1,
-	}
-)
+// TencentConfig 腾讯云自然语言处理配置
+type TencentConfig struct {
+	SecretID  string `mapstructure:"secret_id"`
+	SecretKey string `mapstructure:"secret_key"`
+	Region    string `mapstructure:"region"` // 默认 ap-guangzhou
+}
 
-func init() {
-	keywordsOnce.Do(func() {
-		viper.SetConfigName("feedback_keywords")
-		viper.AddConfigPath("./config")
-		if err := viper.ReadInConfig(); err != nil {
-			log.Printf("无法读取关键词配置，使用默认值: %v", err)
-			return
-		}
+var tencentSentiments = map[string]int{
+	"positive": Positive,
+	"neutral":  Neutral,
+	"negative": Negative,
+}
 
-		secretId := viper.GetString("tencentcloud.secret_id")
-		secretKey := viper.GetString("tencentcloud.secret_key")
-		var err error
-		nlpClient, err = nlp.NewClientWithSecretId(secretId, secretKey, "ap-guangzhou")
-		if err != nil {
-			log.Printf("无法初始化腾讯云客户端: %v", err)
-			return
-		}
-	})
+// TencentAnalyzer 调用腾讯云情感分析接口
+type TencentAnalyzer struct {
+	client *nlp.Client
 }
 
-func AnalyzeSentiment(comment string) (int, error) {
+// NewTencentAnalyzer 创建腾讯云情感分析，缺少密钥时返回错误
+func NewTencentAnalyzer(cfg TencentConfig) (*TencentAnalyzer, error) {
+	if cfg.SecretID == "" || cfg.SecretKey == "" {
+		return nil, errors.New("腾讯云情感分析缺少 secret_id 或 secret_key")
+	}
+	region := cfg.Region
+	if region == "" {
+		region = "ap-guangzhou"
+	}
+	client, err := nlp.NewClientWithSecretId(cfg.SecretID, cfg.SecretKey, region)
+	if err != nil {
+		return nil, fmt.Errorf("无法初始化腾讯云客户端: %w", err)
+	}
+	return &TencentAnalyzer{client: client}, nil
+}
 
+// Analyze 调用 AnalyzeSentiment 接口
+func (a *TencentAnalyzer) Analyze(ctx context.Context, text string) (int, error) {
 	req := nlp.NewAnalyzeSentimentRequest()
-	req.Text = common.StringPtr(comment)
-	resp, err := nlpClient.AnalyzeSentiment(req)
+	req.Text = common.StringPtr(text)
+	resp, err := a.client.AnalyzeSentimentWithContext(ctx, req)
 	if err != nil {
-		return 0, err
+		return Neutral, err
 	}
-
-	val, exists := sentimentMap[*resp.Response.Sentiment]
+	if resp.Response == nil || resp.Response.Sentiment == nil {
+		return Neutral, errors.New("腾讯云情感分析未返回结果")
+	}
+	val, exists := tencentSentiments[*resp.Response.Sentiment]
 	if !exists {
-		return 0, fmt.Errorf("unknown sentiment: %s", *resp.Response.Sentiment)
+		return Neutral, fmt.Errorf("unknown sentiment: %s", *resp.Response.Sentiment)
 	}
 	return val, nil
 }